@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// Role is the kind of user that owns a token
+type Role string
+
+const (
+	RoleStudent   Role = "student"
+	RoleMonitor   Role = "monitor"
+	RoleProfessor Role = "professor"
+)
+
+// Claims are the typed fields stored inside every JWT
+type Claims struct {
+	UserID    primitive.ObjectID `json:"uid"`
+	Role      Role               `json:"role"`
+	ClassID   primitive.ObjectID `json:"classid"`
+	Matricula string             `json:"matricula"`
+	SessionID string             `json:"sid,omitempty"`
+	// MustChangePassword marks tokens of a first login, they can only change the password
+	MustChangePassword bool `json:"mcp,omitempty"`
+	jwt.StandardClaims
+}
+
+// RevokedFunc tells if the token was revoked before its expiration
+type RevokedFunc func(claims *Claims) (bool, error)
+
+// Policy decides if the owner of a token can go through a request
+type Policy func(claims *Claims, r *http.Request) error
+
+type contextKey int
+
+const claimsKey contextKey = 0
+
+// Valid checks the standard claims and the role of the token
+func (c *Claims) Valid() error {
+
+	if err := c.StandardClaims.Valid(); err != nil {
+		return err
+	}
+
+	switch c.Role {
+	case RoleStudent, RoleMonitor, RoleProfessor:
+	default:
+		return errors.New("Invalid token role")
+	}
+
+	if c.UserID.IsZero() {
+		return errors.New("Token without user id")
+	}
+
+	return nil
+}
+
+// HasRole return true if the claims role is one of the given roles
+func (c *Claims) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessStudent return true if the user can read and update the student data
+// Students can only access their own data, professors any student and monitors
+// the students enrolled in their class, classIDs are the classes of the student enrollments
+func (c *Claims) CanAccessStudent(studentID primitive.ObjectID, classIDs ...primitive.ObjectID) bool {
+	switch c.Role {
+	case RoleStudent:
+		return c.UserID == studentID
+	case RoleProfessor:
+		return true
+	}
+	for _, classID := range classIDs {
+		if c.ClassID == classID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessClass return true if the user can read and update data of the class
+// Professors can access any class, students and monitors only their own
+func (c *Claims) CanAccessClass(classID primitive.ObjectID) bool {
+	if c.Role == RoleProfessor {
+		return true
+	}
+	return c.ClassID == classID
+}
+
+// AllowRoles builds a policy that only accepts the given roles
+func AllowRoles(roles ...Role) Policy {
+	return func(claims *Claims, r *http.Request) error {
+		if !claims.HasRole(roles...) {
+			return errors.New("User " + string(claims.Role) + " scope not allowed")
+		}
+		return nil
+	}
+}
+
+// PasswordChanged is a policy that rejects tokens issued before the first password change
+func PasswordChanged() Policy {
+	return func(claims *Claims, r *http.Request) error {
+		if claims.MustChangePassword {
+			return errors.New("User " + claims.Matricula + " must change the password first")
+		}
+		return nil
+	}
+}
+
+// NewContext return a copy of ctx that carries the token claims
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// FromContext return the token claims stored in ctx, if any
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// ClaimsFromRequest return the claims stored by the authorization middleware
+func ClaimsFromRequest(r *http.Request) (*Claims, error) {
+	if claims, ok := FromContext(r.Context()); ok {
+		return claims, nil
+	}
+	return nil, errors.New("Request without token claims")
+}
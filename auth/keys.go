@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Key is a single signing key identified by its kid
+// Keys without private part are only used to verify old tokens
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private interface{}
+	Public  interface{}
+}
+
+// KeySet keeps every key that can verify a token and the one used to sign new tokens
+type KeySet struct {
+	mutex  sync.RWMutex
+	dir    string
+	pinned string
+	active string
+	keys   map[string]*Key
+}
+
+// JWK is the public part of a key in the JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the document served in /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewSecretKeySet builds a key set with a single HS256 key
+// Used when no key directory is given, HMAC keys are never published
+func NewSecretKeySet(secret string) *KeySet {
+	return &KeySet{
+		active: "default",
+		keys: map[string]*Key{
+			"default": {ID: "default", Method: jwt.SigningMethodHS256, Private: []byte(secret), Public: []byte(secret)},
+		},
+	}
+}
+
+// LoadKeySet reads every <kid>.pem file of the directory
+// RSA keys sign with RS256 and EC keys with ES256/ES384/ES512
+// The private key with the greatest kid signs new tokens unless activeKid is given,
+// so a key can be rotated by adding a new file and keeping the old one to verify live tokens
+func LoadKeySet(dir, activeKid string) (*KeySet, error) {
+
+	keySet := &KeySet{dir: dir, pinned: activeKid}
+
+	if err := keySet.Reload(); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Reload reads the key directory again, keeping the current keys if anything fails
+func (ks *KeySet) Reload() error {
+
+	if ks.dir == "" {
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(ks.dir, "*.pem"))
+
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*Key{}
+	var signers []string
+
+	for _, file := range files {
+
+		data, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+
+		key, err := parseKey(kid, data)
+
+		if err != nil {
+			return fmt.Errorf("Key %s: %s", file, err.Error())
+		}
+
+		keys[kid] = key
+
+		if key.Private != nil {
+			signers = append(signers, kid)
+		}
+	}
+
+	if len(signers) == 0 {
+		return errors.New("No private key found in " + ks.dir)
+	}
+
+	sort.Strings(signers)
+
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	active := signers[len(signers)-1]
+
+	if ks.pinned != "" {
+		if key, ok := keys[ks.pinned]; !ok || key.Private == nil {
+			return errors.New("Active key " + ks.pinned + " has no private key")
+		}
+		active = ks.pinned
+	}
+
+	ks.keys = keys
+	ks.active = active
+
+	return nil
+}
+
+// Signer return the key used to sign new tokens
+func (ks *KeySet) Signer() *Key {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+	return ks.keys[ks.active]
+}
+
+// Lookup return the key used to verify a token
+func (ks *KeySet) Lookup(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	ks.mutex.RLock()
+	key, ok := ks.keys[kid]
+	if kid == "" {
+		key, ok = ks.keys[ks.active]
+	}
+	ks.mutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("Unknown key id: %v", kid)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+
+	return key.Public, nil
+}
+
+// JWKS return the public keys, so anyone can verify tokens without the secret
+func (ks *KeySet) JWKS() JWKS {
+
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+
+	var kids []string
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeBigInt(public.N)
+			jwk.E = encodeBigInt(big.NewInt(int64(public.E)))
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(padBytes(public.X.Bytes(), size))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(padBytes(public.Y.Bytes(), size))
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// parseKey reads a PEM private or public key and picks its signing method
+func parseKey(kid string, data []byte) (*Key, error) {
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		return nil, errors.New("invalid PEM file")
+	}
+
+	key := &Key{ID: kid}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = private
+	case "EC PRIVATE KEY":
+		private, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = private
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = private
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = public
+	default:
+		return nil, errors.New("unsupported PEM type " + block.Type)
+	}
+
+	switch private := key.Private.(type) {
+	case *rsa.PrivateKey:
+		key.Public = &private.PublicKey
+	case *ecdsa.PrivateKey:
+		key.Public = &private.PublicKey
+	case nil:
+	default:
+		return nil, errors.New("unsupported private key")
+	}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch public.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+	default:
+		return nil, errors.New("unsupported public key")
+	}
+
+	return key, nil
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -1,27 +1,38 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/dgrijalva/jwt-go"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/sirupsen/logrus"
 	"net/http"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 )
 
-func GenerateToken(secret string, scope []string) (string, error) {
+// GenerateToken signs a new token with the user identity and role using the active key
+// Every token gets an unique id (jti) so it can be revoked
+func GenerateToken(keys *KeySet, claims Claims, ttl time.Duration) (string, error) {
 
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+	tokenID := make([]byte, 16)
 
-	claims["authorized"] = true
-	claims["exp"] = time.Now().Add(time.Minute * 30).Unix()
-	claims["scope"] = strings.Join(scope, " ")
-	tokenString, err := token.SignedString([]byte(secret))
+	if _, err := rand.Read(tokenID); err != nil {
+		return "", err
+	}
+
+	claims.Id = hex.EncodeToString(tokenID)
+	claims.Subject = claims.UserID.Hex()
+	claims.IssuedAt = time.Now().Unix()
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	signer := keys.Signer()
+
+	token := jwt.NewWithClaims(signer.Method, &claims)
+	token.Header["kid"] = signer.ID
+	tokenString, err := token.SignedString(signer.Private)
 
 	if err != nil {
 		return "", err
@@ -48,34 +59,39 @@ func ExtractToken(r *http.Request) string {
 	return ""
 }
 
-func ExtractTokenID(r *http.Request) (uint32, error) {
+// ParseToken validates the token string with the key of its kid and return its claims
+func ParseToken(tokenString string, keys *KeySet) (*Claims, error) {
 
-	tokenString := ExtractToken(r)
+	claims := &Claims{}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(os.Getenv("API_SECRET")), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, keys.Lookup)
 
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("Invalid token")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	return claims, nil
+}
 
-	if ok && token.Valid {
-		uid, err := strconv.ParseUint(fmt.Sprintf("%.0f", claims["user_id"]), 10, 32)
+// ExtractClaims return the claims of the token sent in the request
+func ExtractClaims(r *http.Request, keys *KeySet) (*Claims, error) {
+	return ParseToken(ExtractToken(r), keys)
+}
 
-		if err != nil {
-			return 0, err
-		}
+// ExtractTokenID return the id of the user that owns the request token
+func ExtractTokenID(r *http.Request, keys *KeySet) (primitive.ObjectID, error) {
+
+	claims, err := ExtractClaims(r, keys)
 
-		return uint32(uid), nil
+	if err != nil {
+		return primitive.NilObjectID, err
 	}
 
-	return 0, nil
+	return claims.UserID, nil
 }
 
 //Pretty display the claims licely in the terminal
@@ -92,47 +108,42 @@ func Pretty(data interface{}) error {
 	return nil
 }
 
-func CheckTokenStudent(r *http.Request, secret string) error {
+// CheckTokenStudent validates the request token of any user and rejects revoked tokens
+func CheckTokenStudent(r *http.Request, keys *KeySet, isRevoked RevokedFunc) (*Claims, error) {
 
-	tokenString := ExtractToken(r)
+	claims, err := ExtractClaims(r, keys)
 
-	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	if err != nil {
+		return nil, err
+	}
+
+	if isRevoked != nil {
+		revoked, err := isRevoked(claims)
+
+		if err != nil {
+			return nil, err
 		}
-		return []byte(secret), nil
-	})
 
-	if err != nil {
-		return err
+		if revoked {
+			return nil, errors.New("Token " + claims.Id + " was revoked")
+		}
 	}
 
-	return nil
+	return claims, nil
 }
 
-func CheckTokenProfessor(r *http.Request, secret string) error {
+// CheckTokenProfessor validates the request token and checks the professor role
+func CheckTokenProfessor(r *http.Request, keys *KeySet, isRevoked RevokedFunc) (*Claims, error) {
 
-	tokenString := ExtractToken(r)
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+	claims, err := CheckTokenStudent(r, keys, isRevoked)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		scope := fmt.Sprintf("%v", claims["scope"])
-		if strings.Contains(scope, "professor") {
-			return nil
-		} else {
-			return errors.New("User professor scope not found")
-		}
+	if claims.Role != RoleProfessor {
+		return nil, errors.New("User professor scope not found")
 	}
 
-	return errors.New("Internal Error")
-}
\ No newline at end of file
+	return claims, nil
+}
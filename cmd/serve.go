@@ -19,16 +19,36 @@ MAC && LINUX
   --port 8080 \
   --mongo-host localhost \
   --mongo-port 27017 \
-  --jwt-key SUPER_SECRET \
+  --jwt-key-dir ./keys \
   --codeforces-key f3d968eea83ad8d5f21cad0365edcc200439c6f0 \
   --codeforces-secret b30c206b689d5ba004534c6780aa7be8e234a7f3 \
   --log-level debug
 
 WINDOWS POWER SHELL
 
-./apc-api serve --port 8080 --mongo-host localhost --mongo-port 27017 --jwt-key SUPER_SECRET --codeforces-key f3d968eea83ad8d5f21cad0365edcc200439c6f0 --codeforces-secret b30c206b689d5ba004534c6780aa7be8e234a7f3 --log-level debug
+./apc-api serve --port 8080 --mongo-host localhost --mongo-port 27017 --jwt-key-dir ./keys --codeforces-key f3d968eea83ad8d5f21cad0365edcc200439c6f0 --codeforces-secret b30c206b689d5ba004534c6780aa7be8e234a7f3 --log-level debug
 
 
+Every <kid>.pem file inside --jwt-key-dir is a RSA or EC key. The private key with the greatest
+kid signs new tokens, older keys keep verifying live tokens until they are removed.
+Send SIGHUP to reload the directory after adding a new key. Without --jwt-key-dir the
+--jwt-key secret signs tokens with HS256.
+
+Wrong passwords lock the matricula, and the IP after many more tries, for a while. Behind nginx
+pass --trust-proxy so the client IP is read from X-Real-IP instead of the proxy address.
+
+The progress of the students is read from Codeforces in background every --codeforces-sync-interval
+(10m by default), one request each 2 seconds, and logins read the stored snapshots. With
+--codeforces-sync-interval 0 the servers never call Codeforces and the sync command does it.
+Codeforces standings are cached for --codeforces-cache-ttl (1m by default). The codeforces_cache_*
+metrics count hits and misses. Calls time out after --codeforces-timeout and stop for a while after
+--codeforces-breaker-failures failures in a row, logins then send the progress marked as stale.
+GET /health shows the state of the database and of Codeforces.
+Each class chooses its judge, Codeforces by default. --fake-judge lets classes choose a fake judge
+that never calls the network, only use it for development.
+--codeforces-mode record saves every answer of Codeforces in --codeforces-fixtures and
+--codeforces-mode replay answers from those files without network, no key or secret is needed then.
+
 All command line options can be provided via environment variables by adding the prefix "DRAGONT_" 
 and converting their names to upper case and replacing punctuation and hyphen with underscores. 
 For example,
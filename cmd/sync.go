@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/config"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Reads the progress of the students from Codeforces",
+	Example: ` 
+
+./apc-api sync \
+  --mongo-host localhost \
+  --mongo-port 27017 \
+  --codeforces-key f3d968eea83ad8d5f21cad0365edcc200439c6f0 \
+  --codeforces-secret b30c206b689d5ba004534c6780aa7be8e234a7f3 \
+  --codeforces-sync-interval 10m
+
+Reads the standings and the submissions of the contests of every class, one request each 2 seconds,
+and stores the result and the submissions of each student. Classes with a list curve get their list grades
+computed from that result, the grades overridden by hand are kept. Run it when the servers are started with
+--codeforces-sync-interval 0, so a single process calls Codeforces. With --codeforces-sync-interval 0 it syncs once and exits.
+--codeforces-mode and --codeforces-fixtures record or replay the answers of Codeforces, as in serve.
+	`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		// Bound here so the flags of serve keep their own values
+		return viper.GetViper().BindPFlags(cmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		webBuilder := new(config.WebBuilder).InitSyncFromViper(viper.GetViper())
+
+		if logLevel, err := logrus.ParseLevel(webBuilder.LogLevel); err == nil {
+			logrus.SetLevel(logLevel)
+		}
+
+		if err := progress.CreateIndexes(webBuilder.DataBase, "apc_database", "progress"); err != nil {
+			logrus.Errorf("Not able to create progress indexes: %s", err.Error())
+		}
+
+		if webBuilder.CodeforcesSync == 0 {
+			synced, err := progress.SyncAll(context.Background(), webBuilder.DataBase, webBuilder.Judges, "apc_database")
+			logrus.Infof("%d classes synced", synced)
+			if err != nil {
+				return err
+			}
+			computed, err := grade.SyncLists(webBuilder.DataBase, "apc_database")
+			logrus.Infof("List grades of %d classes computed", computed)
+			return err
+		}
+
+		go grade.RunLists(context.Background(), webBuilder.DataBase, webBuilder.CodeforcesSync, "apc_database")
+
+		progress.Run(context.Background(), webBuilder.DataBase, webBuilder.Judges, webBuilder.CodeforcesSync, "apc_database")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	config.AddSyncFlags(syncCmd.Flags())
+}
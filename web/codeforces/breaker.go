@@ -0,0 +1,225 @@
+package codeforces
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/metrics"
+	"github.com/togatoga/goforces"
+)
+
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half-open"
+)
+
+// ErrOpen is returned without calling Codeforces while the breaker is open
+var ErrOpen = errors.New("Codeforces is unavailable, try again later")
+
+// BreakerState is the state of the breaker shown by the health check
+type BreakerState struct {
+	State       string     `json:"state"`
+	Failures    int        `json:"failures"`
+	LastError   string     `json:"lasterror,omitempty"`
+	LastFailure *time.Time `json:"lastfailure,omitempty"`
+	LastSuccess *time.Time `json:"lastsuccess,omitempty"`
+	OpenUntil   *time.Time `json:"openuntil,omitempty"`
+}
+
+// Breaker stops calling Codeforces after too many failures in a row
+// After the cooldown a single call is let through, it closes the breaker when it succeeds
+// Every call is cancelled after the timeout
+type Breaker struct {
+	api       StandingsGetter
+	timeout   time.Duration
+	threshold int
+	cooldown  time.Duration
+	mutex     sync.Mutex
+	state     BreakerState
+	openUntil time.Time
+	probing   bool
+}
+
+// NewBreaker return a breaker in front of the api
+// @param	api				Codeforces client
+// @param	timeout			maximum time of a call, 0 has no timeout
+// @param	threshold		failures in a row that open the breaker
+// @param	cooldown		time the breaker stays open before trying again
+// @return 	*Breaker		the breaker
+func NewBreaker(api StandingsGetter, timeout time.Duration, threshold int, cooldown time.Duration) *Breaker {
+
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	return &Breaker{
+		api:       api,
+		timeout:   timeout,
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerState{State: Closed},
+	}
+}
+
+// GetContestStandings calls the api unless the breaker is open
+func (b *Breaker) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+
+	var standings *goforces.Standings
+
+	err := b.Call(ctx, func(ctx context.Context) (err error) {
+		standings, err = b.api.GetContestStandings(ctx, contestID, options)
+		return err
+	})
+
+	return standings, err
+}
+
+// Call runs a call to Codeforces unless the breaker is open, cancelling it after the timeout
+// Cancelled contexts don't count as failures of Codeforces, neither do requests it refused like an unknown handle
+// @param	ctx				context of the call
+// @param	call			the call, it must use the context it receives
+// @return 	error 			error of the call or ErrOpen
+func (b *Breaker) Call(ctx context.Context, call func(ctx context.Context) error) error {
+
+	if !b.allow() {
+		return ErrOpen
+	}
+
+	callCtx := ctx
+
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+
+	err := call(callCtx)
+
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return err
+	}
+
+	if err != nil && !Unavailable(err) {
+		// Codeforces answered, the request was wrong
+		b.done(nil)
+		return err
+	}
+
+	b.done(err)
+
+	return err
+}
+
+// Unavailable tells if the error means Codeforces isn't working: timeouts, network errors,
+// 5xx answers and the call limit. Requests refused with 4xx or FAILED, like an unknown handle, are answers
+func Unavailable(err error) bool {
+
+	message := err.Error()
+
+	if strings.Contains(strings.ToLower(message), "limit exceeded") {
+		return true
+	}
+
+	// goforces: "Request Error: FAILED Comment: ...", "Request Error: 400 Bad Request" and "Status Error: FAILED"
+	if strings.HasPrefix(message, "Request Error: ") {
+
+		status := strings.Fields(strings.TrimPrefix(message, "Request Error: "))
+
+		if len(status) == 0 {
+			return true
+		}
+
+		if code, convErr := strconv.Atoi(status[0]); convErr == nil {
+			return code >= 500 || code == http.StatusTooManyRequests
+		}
+
+		return status[0] != "FAILED"
+	}
+
+	return !strings.HasPrefix(message, "Status Error: FAILED")
+}
+
+// State return a copy of the current state
+func (b *Breaker) State() BreakerState {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	state := b.state
+
+	if state.State == Open && !time.Now().Before(b.openUntil) {
+		state.State = HalfOpen
+	}
+
+	return state
+}
+
+// allow tells if a call can be sent, only one call at a time is sent while half-open
+func (b *Breaker) allow() bool {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state.State {
+	case Closed:
+		return true
+	case Open:
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.state.State = HalfOpen
+	}
+
+	if b.probing {
+		return false
+	}
+
+	b.probing = true
+
+	return true
+}
+
+// release gives back the probe of a call that was cancelled
+func (b *Breaker) release() {
+	b.mutex.Lock()
+	b.probing = false
+	b.mutex.Unlock()
+}
+
+// done records the result of a call
+func (b *Breaker) done(err error) {
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.probing = false
+
+	if err == nil {
+		b.state.State = Closed
+		b.state.Failures = 0
+		b.state.LastSuccess = &now
+		b.state.OpenUntil = nil
+		metrics.CodeforcesBreakerOpen.Set(0)
+		return
+	}
+
+	b.state.Failures++
+	b.state.LastError = err.Error()
+	b.state.LastFailure = &now
+
+	if b.state.State == HalfOpen || b.state.Failures >= b.threshold {
+		openUntil := now.Add(b.cooldown)
+		b.openUntil = openUntil
+		b.state.State = Open
+		b.state.OpenUntil = &openUntil
+		metrics.CodeforcesBreakerOpen.Set(1)
+	}
+}
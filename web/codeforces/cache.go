@@ -0,0 +1,180 @@
+package codeforces
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/metrics"
+	"github.com/togatoga/goforces"
+	"golang.org/x/sync/singleflight"
+)
+
+// StandingsGetter returns the standings of a contest, like the Codeforces client does
+type StandingsGetter interface {
+	GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error)
+}
+
+// Cache keeps the standings of each contest and handles for a while, so logins don't call Codeforces every time
+// Identical requests sent at the same time wait for a single call to Codeforces
+// The standings returned are shared and must not be changed
+type Cache struct {
+	api     StandingsGetter
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	group   singleflight.Group
+}
+
+type cacheEntry struct {
+	standings *goforces.Standings
+	expires   time.Time
+}
+
+// Expired entries are only dropped when the cache grows past this size
+const maxCacheEntries = 10000
+
+// NewCache return a cache in front of the api
+// @param	api				Codeforces client
+// @param	ttl				how long standings are kept, 0 only coalesces identical requests
+// @return 	*Cache			the cache
+func NewCache(api StandingsGetter, ttl time.Duration) *Cache {
+	return &Cache{
+		api:     api,
+		ttl:     ttl,
+		entries: map[string]cacheEntry{},
+	}
+}
+
+// GetContestStandings return the standings from the cache, or from Codeforces when they are missing or too old
+// Standings of some handles are also read from the standings of the whole contest when it is cached
+// Errors are never cached
+func (c *Cache) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+
+	key := cacheKey(contestID, options)
+
+	if standings, ok := c.get(key); ok {
+		metrics.CodeforcesCacheHits.Inc()
+		return standings, nil
+	}
+
+	if options != nil && len(options.Handles) > 0 {
+
+		whole := *options
+		whole.Handles = nil
+
+		if standings, ok := c.get(cacheKey(contestID, &whole)); ok {
+			metrics.CodeforcesCacheHits.Inc()
+			return filterHandles(standings, options.Handles), nil
+		}
+	}
+
+	metrics.CodeforcesCacheMisses.Inc()
+
+	value, err, shared := c.group.Do(key, func() (interface{}, error) {
+
+		standings, err := c.api.GetContestStandings(ctx, contestID, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.set(key, standings)
+
+		return standings, nil
+	})
+
+	if shared {
+		metrics.CodeforcesCacheCoalesced.Inc()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*goforces.Standings), nil
+}
+
+func (c *Cache) get(key string) (*goforces.Standings, bool) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.standings, true
+}
+
+func (c *Cache) set(key string, standings *goforces.Standings) {
+
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+
+	if len(c.entries) >= maxCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+
+	c.entries[key] = cacheEntry{standings: standings, expires: now.Add(c.ttl)}
+}
+
+// cacheKey return the same key for the same contest and options, whatever the order of the handles
+func cacheKey(contestID int, options *goforces.ContestStatndingsOptions) string {
+
+	if options == nil {
+		options = &goforces.ContestStatndingsOptions{}
+	}
+
+	handles := make([]string, len(options.Handles))
+
+	for i, handle := range options.Handles {
+		handles[i] = strings.ToLower(handle)
+	}
+
+	sort.Strings(handles)
+
+	return fmt.Sprintf("%d/%d/%d/%d/%t/%s", contestID, options.From, options.Count, options.Room, options.ShowUnofficial, strings.Join(handles, ";"))
+}
+
+// filterHandles return the standings with only the rows of the handles
+func filterHandles(standings *goforces.Standings, handles []string) *goforces.Standings {
+
+	wanted := map[string]bool{}
+
+	for _, handle := range handles {
+		wanted[strings.ToLower(handle)] = true
+	}
+
+	filtered := &goforces.Standings{
+		Contest:  standings.Contest,
+		Problems: standings.Problems,
+		Rows:     []goforces.RanklistRow{},
+	}
+
+	for _, row := range standings.Rows {
+		for _, member := range row.Party.Members {
+			if wanted[strings.ToLower(member.Handle)] {
+				filtered.Rows = append(filtered.Rows, row)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
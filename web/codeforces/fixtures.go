@@ -0,0 +1,236 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/togatoga/goforces"
+)
+
+const (
+	Live   = "live"
+	Record = "record"
+	Replay = "replay"
+)
+
+// fixture is the file of a single call, the answer or the error of Codeforces
+type fixture struct {
+	Request string          `json:"request"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Recorder calls Codeforces and saves every answer, errors included, in the fixtures directory
+type Recorder struct {
+	api API
+	dir string
+}
+
+// NewRecorder return a client that records the answers of api in dir
+// @param	api				Codeforces client
+// @param	dir				fixtures directory, created if missing
+// @return 	*Recorder		the recorder
+// @return 	error 			directory can't be created
+func NewRecorder(api API, dir string) (*Recorder, error) {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &Recorder{api: api, dir: dir}, nil
+}
+
+// Replayer answers with the fixtures saved by a Recorder and never uses the network
+// Calls without fixture fail
+type Replayer struct {
+	dir string
+}
+
+// NewReplayer return a client that reads the answers from dir
+// @param	dir				fixtures directory
+// @return 	*Replayer		the replayer
+// @return 	error 			directory doesn't exist
+func NewReplayer(dir string) (*Replayer, error) {
+
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, errors.New(dir + " isn't a directory")
+	}
+
+	return &Replayer{dir: dir}, nil
+}
+
+// GetContestStandings records the standings
+func (r *Recorder) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+	standings, err := r.api.GetContestStandings(ctx, contestID, options)
+	return standings, r.save(standingsFixture(contestID, options), standings, err)
+}
+
+// GetUserInfo records the users
+func (r *Recorder) GetUserInfo(ctx context.Context, handles []string) ([]goforces.User, error) {
+	users, err := r.api.GetUserInfo(ctx, handles)
+	return users, r.save(userInfoFixture(handles), users, err)
+}
+
+// GetContestStatus records the submissions of the contest
+func (r *Recorder) GetContestStatus(ctx context.Context, contestID int, options *goforces.ContestStatusOptions) ([]goforces.Submission, error) {
+	submissions, err := r.api.GetContestStatus(ctx, contestID, options)
+	return submissions, r.save(contestStatusFixture(contestID, options), submissions, err)
+}
+
+// GetUserStatus records the submissions of the user
+func (r *Recorder) GetUserStatus(ctx context.Context, handle string, options *goforces.UserStatusOptions) ([]goforces.Submission, error) {
+	submissions, err := r.api.GetUserStatus(ctx, handle, options)
+	return submissions, r.save(userStatusFixture(handle, options), submissions, err)
+}
+
+// save writes the fixture of a call and return the error of the call
+// Cancelled calls aren't answers of Codeforces and aren't saved
+func (r *Recorder) save(request string, result interface{}, err error) error {
+
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+
+	saved := fixture{Request: request}
+
+	if err != nil {
+		saved.Error = err.Error()
+	} else if data, marshalErr := json.Marshal(result); marshalErr == nil {
+		saved.Result = data
+	} else {
+		return err
+	}
+
+	data, marshalErr := json.MarshalIndent(saved, "", "  ")
+
+	if marshalErr != nil {
+		return err
+	}
+
+	path := fixturePath(r.dir, request)
+	temp := path + ".tmp"
+
+	// Written aside and renamed so a replay never reads half a file
+	if writeErr := ioutil.WriteFile(temp, data, 0644); writeErr == nil {
+		os.Rename(temp, path)
+	}
+
+	return err
+}
+
+// GetContestStandings reads the recorded standings
+func (r *Replayer) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+	var standings *goforces.Standings
+	return standings, r.load(standingsFixture(contestID, options), &standings)
+}
+
+// GetUserInfo reads the recorded users
+func (r *Replayer) GetUserInfo(ctx context.Context, handles []string) ([]goforces.User, error) {
+	var users []goforces.User
+	return users, r.load(userInfoFixture(handles), &users)
+}
+
+// GetContestStatus reads the recorded submissions of the contest
+func (r *Replayer) GetContestStatus(ctx context.Context, contestID int, options *goforces.ContestStatusOptions) ([]goforces.Submission, error) {
+	var submissions []goforces.Submission
+	return submissions, r.load(contestStatusFixture(contestID, options), &submissions)
+}
+
+// GetUserStatus reads the recorded submissions of the user
+func (r *Replayer) GetUserStatus(ctx context.Context, handle string, options *goforces.UserStatusOptions) ([]goforces.Submission, error) {
+	var submissions []goforces.Submission
+	return submissions, r.load(userStatusFixture(handle, options), &submissions)
+}
+
+// load reads the fixture of a call into result, the recorded error is returned as it was
+func (r *Replayer) load(request string, result interface{}) error {
+
+	data, err := ioutil.ReadFile(fixturePath(r.dir, request))
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New("No fixture recorded for " + request)
+		}
+		return err
+	}
+
+	var saved fixture
+
+	if err = json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	if saved.Error != "" {
+		return errors.New(saved.Error)
+	}
+
+	return json.Unmarshal(saved.Result, result)
+}
+
+// fixturePath return the file of a request, named by the method and contest with a hash of the whole request
+func fixturePath(dir, request string) string {
+
+	hash := fnv.New32a()
+	hash.Write([]byte(request))
+
+	name := strings.SplitN(request, "?", 2)[0]
+	name = strings.NewReplacer("/", "-", ".", "-").Replace(name)
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%08x.json", name, hash.Sum32()))
+}
+
+func standingsFixture(contestID int, options *goforces.ContestStatndingsOptions) string {
+
+	if options == nil {
+		options = &goforces.ContestStatndingsOptions{}
+	}
+
+	return fmt.Sprintf("contest.standings/%d?from=%d&count=%d&room=%d&showUnofficial=%t&handles=%s",
+		contestID, options.From, options.Count, options.Room, options.ShowUnofficial, sortedHandles(options.Handles))
+}
+
+func userInfoFixture(handles []string) string {
+	return "user.info?handles=" + sortedHandles(handles)
+}
+
+func contestStatusFixture(contestID int, options *goforces.ContestStatusOptions) string {
+
+	if options == nil {
+		options = &goforces.ContestStatusOptions{}
+	}
+
+	return fmt.Sprintf("contest.status/%d?from=%d&count=%d&handle=%s", contestID, options.From, options.Count, strings.ToLower(options.Handle))
+}
+
+func userStatusFixture(handle string, options *goforces.UserStatusOptions) string {
+
+	if options == nil {
+		options = &goforces.UserStatusOptions{}
+	}
+
+	return fmt.Sprintf("user.status?from=%d&count=%d&handle=%s", options.From, options.Count, strings.ToLower(handle))
+}
+
+// sortedHandles joins the handles in lower case and sorted, the order of the call doesn't matter
+func sortedHandles(handles []string) string {
+
+	sorted := make([]string, len(handles))
+
+	for i, handle := range handles {
+		sorted[i] = strings.ToLower(handle)
+	}
+
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ";")
+}
@@ -0,0 +1,241 @@
+package codeforces
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/togatoga/goforces"
+)
+
+// Name is the name of the Codeforces judge
+const Name = "codeforces"
+
+// API is the part of the Codeforces client used by the judge
+type API interface {
+	StandingsGetter
+	GetUserInfo(ctx context.Context, handles []string) ([]goforces.User, error)
+	GetContestStatus(ctx context.Context, contestID int, options *goforces.ContestStatusOptions) ([]goforces.Submission, error)
+	GetUserStatus(ctx context.Context, handle string, options *goforces.UserStatusOptions) ([]goforces.Submission, error)
+}
+
+// Judge is the Codeforces judge.Provider
+// Every call goes through the rate limit and the breaker, standings also go through the cache
+type Judge struct {
+	api       API
+	breaker   *Breaker
+	limiter   *RateLimited
+	standings *Cache
+}
+
+// NewJudge return the Codeforces judge
+// @param	api				Codeforces client
+// @param	interval		minimum time between two calls, RateLimit for the real Codeforces
+// @param	timeout			maximum time of a call, 0 has no timeout
+// @param	failures		failures in a row that stop the calls
+// @param	cooldown		time the calls stay stopped
+// @param	ttl				how long standings are cached
+// @return 	*Judge			the judge
+func NewJudge(api API, interval, timeout time.Duration, failures int, cooldown, ttl time.Duration) *Judge {
+
+	breaker := NewBreaker(api, timeout, failures, cooldown)
+	limiter := NewRateLimited(breaker, interval)
+
+	return &Judge{
+		api:       api,
+		breaker:   breaker,
+		limiter:   limiter,
+		standings: NewCache(limiter, ttl),
+	}
+}
+
+// Name return "codeforces"
+func (j *Judge) Name() string {
+	return Name
+}
+
+// Breaker return the breaker of the calls, shown by the health check
+func (j *Judge) Breaker() *Breaker {
+	return j.breaker
+}
+
+// Available is false while the breaker is open
+func (j *Judge) Available() bool {
+	return j.breaker.State().State != Open
+}
+
+// GetUser return the handle and avatar of a user
+func (j *Judge) GetUser(ctx context.Context, handle string) (judge.User, error) {
+
+	var users []goforces.User
+
+	err := j.call(ctx, func(ctx context.Context) (err error) {
+		users, err = j.api.GetUserInfo(ctx, []string{handle})
+		return err
+	})
+
+	if err != nil {
+		return judge.User{}, err
+	}
+
+	if len(users) == 0 {
+		return judge.User{}, errors.New("handles: User with handle " + handle + " not found")
+	}
+
+	return User(users[0]), nil
+}
+
+// GetContestStandings return the standings of the handles, unofficial and practice rows included
+func (j *Judge) GetContestStandings(ctx context.Context, contestID int, handles []string) (judge.Standings, error) {
+
+	standings, err := j.standings.GetContestStandings(ctx, contestID, &goforces.ContestStatndingsOptions{
+		Handles:        handles,
+		ShowUnofficial: true,
+	})
+
+	if err != nil {
+		return judge.Standings{}, err
+	}
+
+	return Standings(contestID, standings), nil
+}
+
+// GetUserSubmissions return the submissions of the handle in the contest, or every submission when contestID is 0
+func (j *Judge) GetUserSubmissions(ctx context.Context, handle string, contestID int) ([]judge.Submission, error) {
+
+	var submissions []goforces.Submission
+
+	err := j.call(ctx, func(ctx context.Context) (err error) {
+		if contestID == 0 {
+			submissions, err = j.api.GetUserStatus(ctx, handle, nil)
+		} else {
+			submissions, err = j.api.GetContestStatus(ctx, contestID, &goforces.ContestStatusOptions{Handle: handle})
+		}
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]judge.Submission, 0, len(submissions))
+
+	for _, submission := range submissions {
+		converted = append(converted, Submission(submission))
+	}
+
+	return converted, nil
+}
+
+// GetContestSubmissions reads every submission of the contest in a single call and keeps the ones of the handles
+func (j *Judge) GetContestSubmissions(ctx context.Context, contestID int, handles []string) ([]judge.Submission, error) {
+
+	var submissions []goforces.Submission
+
+	err := j.call(ctx, func(ctx context.Context) (err error) {
+		submissions, err = j.api.GetContestStatus(ctx, contestID, nil)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+
+	for _, handle := range handles {
+		wanted[strings.ToLower(handle)] = true
+	}
+
+	converted := make([]judge.Submission, 0, len(submissions))
+
+	for _, submission := range submissions {
+
+		current := Submission(submission)
+
+		if len(wanted) == 0 || wanted[strings.ToLower(current.Handle)] {
+			converted = append(converted, current)
+		}
+	}
+
+	return converted, nil
+}
+
+// ContestURL return the page of the contest in the Codeforces group
+func (j *Judge) ContestURL(groupID string, contestID int) string {
+	return "https://codeforces.com/group/" + groupID + "/contest/" + strconv.Itoa(contestID)
+}
+
+// call waits the rate limit and runs the call through the breaker
+func (j *Judge) call(ctx context.Context, call func(ctx context.Context) error) error {
+
+	if err := j.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	return j.breaker.Call(ctx, call)
+}
+
+// User converts a Codeforces user, avatars come without scheme
+func User(user goforces.User) judge.User {
+
+	avatar := user.Avatar
+
+	if strings.HasPrefix(avatar, "//") {
+		avatar = "https:" + avatar
+	}
+
+	return judge.User{Handle: user.Handle, Avatar: avatar}
+}
+
+// Standings converts Codeforces standings
+func Standings(contestID int, standings *goforces.Standings) judge.Standings {
+
+	converted := judge.Standings{
+		ContestID: contestID,
+		Name:      standings.Contest.Name,
+	}
+
+	for _, problem := range standings.Problems {
+		converted.Problems = append(converted.Problems, judge.Problem{Index: problem.Index, Name: problem.Name})
+	}
+
+	for _, row := range standings.Rows {
+
+		var current judge.Row
+
+		for _, member := range row.Party.Members {
+			current.Handles = append(current.Handles, member.Handle)
+		}
+
+		for _, result := range row.ProblemResults {
+			current.Points = append(current.Points, result.Points)
+		}
+
+		converted.Rows = append(converted.Rows, current)
+	}
+
+	return converted
+}
+
+// Submission converts a Codeforces submission, the handle is the first author
+func Submission(submission goforces.Submission) judge.Submission {
+
+	converted := judge.Submission{
+		ID:           int64(submission.ID),
+		ContestID:    submission.ContestID,
+		ProblemIndex: submission.Problem.Index,
+		Verdict:      submission.Verdict,
+		Language:     submission.ProgrammingLanguage,
+		CreatedAT:    time.Unix(submission.CreationTimeSeconds, 0).UTC(),
+	}
+
+	if len(submission.Author.Members) > 0 {
+		converted.Handle = submission.Author.Members[0].Handle
+	}
+
+	return converted
+}
@@ -0,0 +1,67 @@
+package codeforces
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/togatoga/goforces"
+)
+
+// RateLimit is the interval Codeforces asks between two calls of the same client
+const RateLimit = 2 * time.Second
+
+// RateLimited sends the calls to the api one at a time, waiting the interval between them
+type RateLimited struct {
+	api      StandingsGetter
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+}
+
+// NewRateLimited return a client that never calls the api more than once per interval
+// @param	api				Codeforces client
+// @param	interval		minimum time between two calls
+// @return 	*RateLimited	the limited client
+func NewRateLimited(api StandingsGetter, interval time.Duration) *RateLimited {
+	return &RateLimited{api: api, interval: interval}
+}
+
+// GetContestStandings waits for the turn of the call and then calls the api
+// The wait is cancelled with the context
+func (l *RateLimited) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+
+	if err := l.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return l.api.GetContestStandings(ctx, contestID, options)
+}
+
+// Wait books the next free slot and sleeps until it arrives
+// Calls that don't go through GetContestStandings wait here before calling Codeforces
+func (l *RateLimited) Wait(ctx context.Context) error {
+
+	l.mutex.Lock()
+
+	now := time.Now()
+	slot := l.next
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	l.next = slot.Add(l.interval)
+
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,13 +3,16 @@ package admin
 import (
 	"context"
 	"errors"
-	"math/rand"
 	"strconv"
 	"strings"
 
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/components/student"
 	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/apc-unb/apc-api/web/roster"
 	"github.com/apc-unb/apc-api/web/utils"
-	"github.com/togatoga/goforces"
 
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/mongodb/mongo-go-driver/mongo"
@@ -21,15 +24,19 @@ import (
 // Checks if that list is not null (can't insert null list)
 // Insert each student individually in database
 // @param	db				pointer to database
+// @param	provider		judge, not used by admins
 // @param	students 		list of students
+// @param	policy			how the generated passwords look like
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
+// @return 	[]UserCredentials	plain passwords, they are not stored and can't be read again
 // @return 	error 			function error
 // TODO : Insert all students at the same time (if possible)
-func CreateAdmin(db *mongo.Client, api *goforces.Client, admins []AdminCreate, databaseName, collectionName string) ([]user.UserCredentials, error) {
+func CreateAdmin(db *mongo.Client, provider judge.Provider, admins []AdminCreate, policy user.PasswordPolicy, databaseName, collectionName string) ([]user.UserCredentials, error) {
 
 	var studentsReturn []user.UserCredentials
-	var singleAdmin user.UserCredentials
+	var login, plain user.UserCredentials
+	var id primitive.ObjectID
 	var mongoReturn *mongo.InsertOneResult
 	var err error
 
@@ -42,82 +49,77 @@ func CreateAdmin(db *mongo.Client, api *goforces.Client, admins []AdminCreate, d
 
 	for _, admin := range admins {
 
-		pwd := generateRandomPassword()
-
-		if singleAdmin.Password, err = utils.HashAndSalt([]byte(pwd)); err != nil {
-			return nil, err
-		}
-
 		if mongoReturn, err = collection.InsertOne(context.TODO(), admin); err != nil {
 			return studentsReturn, err
 		} else {
-			singleAdmin.ID = mongoReturn.InsertedID.(primitive.ObjectID)
+			id = mongoReturn.InsertedID.(primitive.ObjectID)
 		}
 
-		singleAdmin.Matricula = admin.Matricula
+		if login, plain, err = user.NewCredentials(id, admin.Matricula, policy); err != nil {
+			return nil, err
+		}
 
-		if _, err = collectionLogin.InsertOne(context.TODO(), singleAdmin); err != nil {
+		if _, err = collectionLogin.InsertOne(context.TODO(), login); err != nil {
 			return nil, err
 		}
 
-		studentsReturn = append(studentsReturn, singleAdmin)
+		studentsReturn = append(studentsReturn, plain)
 
 	}
 
 	return studentsReturn, nil
 }
 
-// CreateAdminFile receive a csv file in that current format : https://github.com/apc-unb/tree/master/components/student
-// calls getAdminFromFile() that return list of AdminCreate and insert into db
+// CreateAdminFile receive a parsed roster (CSV or XLSX) of admins
+// Rows whose matricula is already registered are marked as invalid
+// Nothing is written on dry run or if any row is invalid, the report tells what is wrong
 // @param	db				pointer to database
-// @param   request         all data to be parsed
+// @param   file            parsed roster
+// @param	classID			class of the admins, taken from the roster YEAR/SEASON/CLASS line if nil
+// @param	importOptions	only validate the roster on dry run
+// @param	policy			how the generated passwords look like
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
+// @return 	Report			row by row validation
+// @return 	[]UserCredentials	plain passwords, they are not stored and can't be read again
 // @return 	error 			function error
-func CreateAdminFile(db *mongo.Client, request, databaseName, collectionName string) ([]user.UserCredentials, error) {
+func CreateAdminFile(db *mongo.Client, file roster.Roster, classID primitive.ObjectID, importOptions roster.Options, policy user.PasswordPolicy, databaseName, collectionName string) (roster.Report, []user.UserCredentials, error) {
 
+	var adminsReturn []user.UserCredentials
 	var admins []AdminCreate
-	var studentsReturn []user.UserCredentials
-	var singleAdmin user.UserCredentials
-	var mongoReturn *mongo.InsertOneResult
 	var err error
 
-	if admins, err = getAdminFromFile(db, request); err != nil {
-		return nil, err
+	if classID.IsZero() {
+		if classID, err = getClassID(db, file.Class, databaseName, "schoolClass"); err != nil {
+			return file.Report(importOptions.DryRun), nil, err
+		}
 	}
 
-	if len(admins) == 0 {
-		return nil, nil
+	if err = markRegistered(db, &file, databaseName, collectionName); err != nil {
+		return file.Report(importOptions.DryRun), nil, err
 	}
 
-	collection := db.Database(databaseName).Collection(collectionName)
-	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
-
-	for _, admin := range admins {
-
-		pwd := generateRandomPassword()
-
-		if singleAdmin.Password, err = utils.HashAndSalt([]byte(pwd)); err != nil {
-			return nil, err
-		}
+	report := file.Report(importOptions.DryRun)
 
-		if mongoReturn, err = collection.InsertOne(context.TODO(), admin); err != nil {
-			return studentsReturn, err
-		} else {
-			singleAdmin.ID = mongoReturn.InsertedID.(primitive.ObjectID)
-		}
-
-		singleAdmin.Matricula = admin.Matricula
-
-		if _, err = collectionLogin.InsertOne(context.TODO(), singleAdmin); err != nil {
-			return nil, err
-		}
+	if importOptions.DryRun || report.Invalid > 0 {
+		return report, nil, nil
+	}
 
-		studentsReturn = append(studentsReturn, singleAdmin)
+	for _, row := range file.Rows {
+		admins = append(admins, AdminCreate{
+			ClassID:   classID,
+			FirstName: row.FirstName,
+			LastName:  row.LastName,
+			Matricula: row.Matricula,
+			Email:     row.Email,
+		})
+	}
 
+	if adminsReturn, err = CreateAdmin(db, nil, admins, policy, databaseName, collectionName); err != nil {
+		return report, adminsReturn, err
 	}
 
-	return studentsReturn, nil
+	return report, adminsReturn, nil
 }
 
 // GetAdmins return list of all students from Database
@@ -218,13 +220,14 @@ func GetAdminsClass(db *mongo.Client, classID primitive.ObjectID, databaseName,
 // UpdateAdmins receive admin (updated)
 // Checks if admin old password matches with db to update that admin password or email
 // @param	db				pointer to database (updated)
-// @param	api 			codeforces api
+// @param	provider		judge, not used by admins
 // @param	admin 			list of admins
+// @param	policy			password policy, a new password must follow it
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
 // @return 	error 			function error
 // TODO : Update all students at the same time (if possible)
-func UpdateAdmin(db *mongo.Client, api *goforces.Client, admin AdminUpdate, databaseName, collectionName string) error {
+func UpdateAdmin(db *mongo.Client, provider judge.Provider, admin AdminUpdate, policy user.PasswordPolicy, databaseName, collectionName string) error {
 
 	collection := db.Database(databaseName).Collection(collectionName)
 	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
@@ -253,11 +256,15 @@ func UpdateAdmin(db *mongo.Client, api *goforces.Client, admin AdminUpdate, data
 	}
 
 	if admin.NewPassword != "" {
+		if err = policy.Check(admin.NewPassword); err != nil {
+			return err
+		}
+
 		if admin.NewPassword, err = utils.HashAndSalt([]byte(admin.NewPassword)); err != nil {
 			return err
 		}
 
-		updateSet := bson.M{"$set": bson.M{"password": admin.NewPassword}}
+		updateSet := bson.M{"$set": bson.M{"password": admin.NewPassword, "mustchangepassword": false}}
 
 		if _, err := collectionLogin.UpdateOne(context.TODO(), filter, updateSet, nil); err != nil {
 			return err
@@ -304,13 +311,14 @@ func UpdateAdmin(db *mongo.Client, api *goforces.Client, admin AdminUpdate, data
 }
 
 // UpdateAdminStudent receive update stundets data, receive a student (updated)
+// Grades are also written in the enrollment of the class (classid or the current class of the student)
 // @param	db				pointer to database (updated)
-// @param	api				codeforces api
+// @param	provider		judge of the class, it gives the avatar of a new handle
 // @param	admin 			student to be updated
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
 // @return 	error 			function error
-func UpdateAdminStudent(db *mongo.Client, api *goforces.Client, admin AdminUpdateStudent, databaseName, studentCollectionName, adminLoginCollectionName string) error {
+func UpdateAdminStudent(db *mongo.Client, provider judge.Provider, admin AdminUpdateStudent, databaseName, studentCollectionName, adminLoginCollectionName string) error {
 
 	collection := db.Database(databaseName).Collection(adminLoginCollectionName)
 
@@ -381,12 +389,120 @@ func UpdateAdminStudent(db *mongo.Client, api *goforces.Client, admin AdminUpdat
 		update["grades.lists"] = admin.Grades.Lists
 	}
 
+	current := student.Student{}
+
+	if err := collection.FindOne(context.TODO(), filter, options.FindOne()).Decode(&current); err != nil {
+		return err
+	}
+
+	if admin.PhotoURL == "" && admin.Handles.Codeforces != "" && admin.Handles.Codeforces != current.Handles.Codeforces {
+		if avatar := student.GetAvatarURL(admin.Handles.Codeforces, provider); avatar != "" {
+			update["photourl"] = avatar
+		}
+	}
+
 	updateSet := bson.M{"$set": update}
 
 	if _, err := collection.UpdateOne(context.TODO(), filter, updateSet, nil); err != nil {
 		return err
 	}
 
+	return updateStudentEnrollment(db, current, admin, databaseName)
+}
+
+// updateStudentEnrollment writes the grades in the enrollment of the class being updated
+// The student is enrolled first when he is moved to a class he isn't enrolled in
+// The changes are recorded in the history before the grades, and removed if the grades aren't saved
+func updateStudentEnrollment(db *mongo.Client, current student.Student, admin AdminUpdateStudent, databaseName string) error {
+
+	classID := admin.ClassID
+
+	if classID.IsZero() {
+		classID = current.ClassID
+	}
+
+	if classID.IsZero() {
+		return nil
+	}
+
+	enrollments, err := enrollment.GetStudentEnrollments(db, current.ID, databaseName, "enrollment")
+
+	if err != nil {
+		return err
+	}
+
+	var classEnrollment enrollment.Enrollment
+
+	for _, elem := range enrollments {
+		if elem.ClassID == classID {
+			classEnrollment = elem
+		}
+	}
+
+	if classEnrollment.ID.IsZero() {
+
+		handles := enrollment.Handles(current.Handles)
+
+		if admin.Handles.Codeforces != "" {
+			handles.Codeforces = admin.Handles.Codeforces
+		}
+
+		if admin.Handles.Uri != "" {
+			handles.Uri = admin.Handles.Uri
+		}
+
+		if classEnrollment, err = enrollment.Enroll(db, enrollment.Enrollment{
+			StudentID: current.ID,
+			ClassID:   classID,
+			Matricula: current.Matricula,
+			Handles:   handles,
+		}, databaseName, "enrollment"); err != nil {
+			return err
+		}
+	}
+
+	grades := enrollment.Grades(admin.Grades)
+	after := classEnrollment.Grades
+
+	if len(grades.Exams) > 0 {
+		after.Exams = grades.Exams
+	}
+
+	if len(grades.Lists) > 0 {
+		after.Lists = grades.Lists
+	}
+
+	changes := gradeHistory.Diff(classEnrollment.Grades, after, gradeHistory.Change{
+		EnrollmentID: classEnrollment.ID,
+		StudentID:    current.ID,
+		ClassID:      classID,
+		ActorID:      admin.AdminID,
+		Role:         admin.Role,
+		Origin:       gradeHistory.OriginManual,
+		Reason:       admin.Reason,
+	})
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if err = gradeHistory.Record(db, changes, databaseName, "gradeHistory"); err != nil {
+		return err
+	}
+
+	ok, err := enrollment.UpdateGrades(db, classEnrollment.ID, classEnrollment.Grades, grades, databaseName, "enrollment")
+
+	if err == nil && !ok {
+		err = errors.New("Grades changed during the update, nothing was saved")
+	}
+
+	if err != nil {
+		if removeErr := gradeHistory.Remove(db, changes, databaseName, "gradeHistory"); removeErr != nil {
+			return errors.New(err.Error() + ", the grade history of the update couldn't be removed: " + removeErr.Error())
+		}
+		return err
+	}
+
 	return nil
 }
 
@@ -445,6 +561,8 @@ func AuthAdmin(db *mongo.Client, admin user.UserCredentials, databaseName, colle
 		return findAdmin, err
 	}
 
+	findAdmin.MustChangePassword = adminData.MustChangePassword
+
 	return findAdmin, nil
 }
 
@@ -494,67 +612,41 @@ func getClassID(db *mongo.Client, classData []string, databaseName, collectionNa
 	return classID.ID, nil
 }
 
-// generateRandomPassword generate a random password using Pimenta Judge style
-// @return 	string		random password
-func generateRandomPassword() string {
+// markRegistered marks the roster rows whose matricula is already in the collection
+func markRegistered(db *mongo.Client, file *roster.Roster, databaseName, collectionName string) error {
 
-	letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	numbers := "0123456789"
-	var password string
+	collection := db.Database(databaseName).Collection(collectionName)
 
-	for i := 0; i < 3; i++ {
-		password += string(letters[rand.Intn(25)])
-	}
-	for i := 0; i < 3; i++ {
-		password += string(numbers[rand.Intn(9)])
-	}
+	cursor, err := collection.Find(
+		context.TODO(),
+		bson.M{"matricula": bson.M{"$in": file.Matriculas()}},
+		options.Find().SetProjection(bson.M{"matricula": 1}),
+	)
 
-	return password
+	if err != nil {
+		return err
+	}
 
-}
+	existing := map[string]bool{}
 
-// getAdminFromFile receive a string and return list of admins data
-// @param	db				pointer to database
-// @param	request			all the csv file to be converted
-// @return 	AdminCreate		list of admins
-// @return 	error 			function error
-func getAdminFromFile(db *mongo.Client, request string) ([]AdminCreate, error) {
+	for cursor.Next(context.TODO()) {
 
-	var total []string
-	var students []AdminCreate
-	var classID primitive.ObjectID
-	var err error
+		var elem Admin
 
-	partial := strings.Split(request, ",")
-	total = append(total, strings.Split(partial[1], "\n")[1])
-	classData := strings.Split(strings.Split(partial[1], "\n")[0], "/")
+		if err := cursor.Decode(&elem); err != nil {
+			return err
+		}
 
-	if classID, err = getClassID(db, classData, "apc_database", "admin"); err != nil {
-		return students, err
+		existing[elem.Matricula] = true
 	}
 
-	for i := 2; i < len(partial); i++ {
-		aux := strings.Split(partial[i], "\n")
-		total = append(total, aux[0])
-		total = append(total, aux[1])
+	if err := cursor.Err(); err != nil {
+		return err
 	}
 
-	total = total[:len(total)-1]
-
-	for i := 0; i < len(total); i += 2 {
-
-		names := strings.SplitAfterN(total[i+1], " ", 2)
-
-		elem := AdminCreate{
-			FirstName: strings.Trim(names[0], "\""),
-			LastName:  strings.Trim(names[1], "\""),
-			Matricula: strings.Trim(total[i], "\""),
-			ClassID:   classID,
-			//Password:  generateRandomPassword(),
-		}
+	cursor.Close(context.TODO())
 
-		students = append(students, elem)
-	}
+	file.MarkExisting(existing, "Matricula already registered")
 
-	return students, nil
+	return nil
 }
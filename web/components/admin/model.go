@@ -41,6 +41,8 @@ type AdminInfo struct {
 	Email     string             `json:"email"`
 	Projects  int32              `json:"projects"`
 	Professor   bool               `json:"professor"`
+	// MustChangePassword comes from the login record and is only filled by AuthAdmin
+	MustChangePassword bool `bson:"-" json:"mustchangepassword,omitempty"`
 }
 
 // AdminUpdate contais all data that admin can update
@@ -70,4 +72,7 @@ type AdminUpdateStudent struct {
 	PhotoURL  			string                 `json:"photourl"`
 	Email     			string                 `json:"email"`
 	Grades    			student.StudentGrades  `json:"grades"`
+	Reason    			string                 `json:"reason"`
+	// Role of the token that sent the update, kept in the grade history
+	Role      			string                 `json:"-"`
 }
\ No newline at end of file
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// Entries returned by a query when no limit is asked, and the most a query can return
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// CreateIndexes creates the indexes used to filter the log by actor and by entity
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of audit collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{"actorid", 1}, {"createdat", -1}},
+		},
+		{
+			Keys: bson.D{{"entity", 1}, {"entityid", 1}, {"createdat", -1}},
+		},
+		{
+			Keys: bson.D{{"createdat", -1}},
+		},
+	})
+
+	return err
+}
+
+// Record appends an entry to the audit log, entries are never changed
+// @param	db				pointer to database
+// @param	entry			what was done and by who
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	ObjectID		id of the entry
+// @return 	error 			function error
+func Record(db *mongo.Client, entry Entry, databaseName, collectionName string) (primitive.ObjectID, error) {
+
+	entry.ID = primitive.NewObjectID()
+
+	if entry.CreatedAT.IsZero() {
+		entry.CreatedAT = time.Now()
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	if _, err := collection.InsertOne(context.TODO(), entry); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return entry.ID, nil
+}
+
+// Find return the entries of the log that match the filter, newest first
+// @param	db				pointer to database
+// @param	filter			actor, entity and time range
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	[]Entry			entries found, at most filter.Limit
+// @return 	error 			function error
+func Find(db *mongo.Client, filter Filter, databaseName, collectionName string) ([]Entry, error) {
+
+	entries := []Entry{}
+
+	query := bson.M{}
+
+	if !filter.ActorID.IsZero() {
+		query["actorid"] = filter.ActorID
+	}
+
+	if filter.Entity != "" {
+		query["entity"] = filter.Entity
+	}
+
+	if !filter.EntityID.IsZero() {
+		query["entityid"] = filter.EntityID
+	}
+
+	createdAt := bson.M{}
+
+	if !filter.From.IsZero() {
+		createdAt["$gte"] = filter.From
+	}
+
+	if !filter.To.IsZero() {
+		createdAt["$lt"] = filter.To
+	}
+
+	if len(createdAt) > 0 {
+		query["createdat"] = createdAt
+	}
+
+	limit := filter.Limit
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), query, options.Find().SetSort(bson.D{{"createdat", -1}}).SetLimit(limit))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Entry
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return entries, nil
+}
+
+// Snapshot return the stored documents, without passwords, to show how they were before or after a change
+// @param	db				pointer to database
+// @param	ids				ids of the documents
+// @param	databaseName	name of database
+// @param	collectionName	collection of the documents
+// @return 	map				documents by id, deleted documents are missing
+// @return 	error 			function error
+func Snapshot(db *mongo.Client, ids []primitive.ObjectID, databaseName, collectionName string) (map[primitive.ObjectID]bson.M, error) {
+
+	documents := map[primitive.ObjectID]bson.M{}
+
+	if len(ids) == 0 {
+		return documents, nil
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem bson.M
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		if id, ok := elem["_id"].(primitive.ObjectID); ok {
+			documents[id] = Redact(elem).(bson.M)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return documents, nil
+}
+
+// Redact removes every password field, at any depth, so the log never keeps a password or its hash
+func Redact(value interface{}) interface{} {
+
+	switch current := value.(type) {
+	case bson.M:
+		redacted := bson.M{}
+		for key, field := range current {
+			if !strings.Contains(strings.ToLower(key), "password") {
+				redacted[key] = Redact(field)
+			}
+		}
+		return redacted
+	case map[string]interface{}:
+		return map[string]interface{}(Redact(bson.M(current)).(bson.M))
+	case bson.D:
+		redacted := bson.D{}
+		for _, field := range current {
+			if !strings.Contains(strings.ToLower(field.Key), "password") {
+				redacted = append(redacted, bson.E{Key: field.Key, Value: Redact(field.Value)})
+			}
+		}
+		return redacted
+	case bson.A:
+		redacted := bson.A{}
+		for _, field := range current {
+			redacted = append(redacted, Redact(field))
+		}
+		return redacted
+	case []interface{}:
+		return []interface{}(Redact(bson.A(current)).(bson.A))
+	}
+
+	return value
+}
+
+// Snapshot return the stored documents of the collection, see Snapshot
+func (s Store) Snapshot(collectionName string, ids []primitive.ObjectID) (map[primitive.ObjectID]bson.M, error) {
+	return Snapshot(s.DataBase, ids, s.DatabaseName, collectionName)
+}
+
+// Record appends the entries to the audit log
+func (s Store) Record(entries []Entry) error {
+
+	for _, entry := range entries {
+		if _, err := Record(s.DataBase, entry, s.DatabaseName, s.CollectionName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
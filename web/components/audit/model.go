@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// Actions recorded in the audit log
+const (
+	Create      = "create"
+	Update      = "update"
+	Delete      = "delete"
+	GradeImport = "grade.import"
+	GradeLists  = "grade.lists"
+	// GradeImportFailed follows a grade.import entry whose grades couldn't be saved
+	GradeImportFailed = "grade.import.failed"
+)
+
+// Entry records who changed what and when
+// Before and After hold whatever the action needs to show what changed,
+// for API calls they are the stored document before and after the call
+type Entry struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ActorID   primitive.ObjectID `bson:"actorid,omitempty"`
+	Role      string             `json:"role"`
+	Action    string             `json:"action"`
+	Route     string             `json:"route,omitempty"`
+	Entity    string             `json:"entity"`
+	EntityID  primitive.ObjectID `bson:"entityid,omitempty"`
+	Before    interface{}        `json:"before,omitempty"`
+	After     interface{}        `json:"after,omitempty"`
+	CreatedAT time.Time          `json:"createdat"`
+}
+
+// Filter of the audit log query, empty fields match every entry
+type Filter struct {
+	ActorID  primitive.ObjectID
+	Entity   string
+	EntityID primitive.ObjectID
+	From     time.Time
+	To       time.Time
+	Limit    int64
+}
+
+// Store reads snapshots and writes entries for the audit middleware
+type Store struct {
+	DataBase       *mongo.Client
+	DatabaseName   string
+	CollectionName string
+}
@@ -0,0 +1,438 @@
+package contest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/apc-unb/apc-api/web/listgrade"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the index that keeps a single copy of each judge contest in a class
+// and the one used to list the contests of a class
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"classid", 1}, {"contestid", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{"classid", 1}, {"list", 1}},
+		},
+	})
+
+	return err
+}
+
+// Validate checks a contest before it is saved
+func (c Contest) Validate() error {
+
+	prefix := "Contest " + strconv.Itoa(c.ContestID) + ": "
+
+	if c.ClassID.IsZero() {
+		return errors.New(prefix + "classid can't be empty")
+	}
+
+	if c.ContestID <= 0 {
+		return errors.New(prefix + "contestid must be positive")
+	}
+
+	if c.List < 0 || c.Weight < 0 {
+		return errors.New(prefix + "list and weight can't be negative")
+	}
+
+	if !c.OpensAT.IsZero() && !c.ClosesAT.IsZero() && !c.ClosesAT.After(c.OpensAT) {
+		return errors.New(prefix + "closesat must be after opensat")
+	}
+
+	if c.Policy != "" && c.Policy != deadline.None && c.ClosesAT.IsZero() {
+		return errors.New(prefix + "closesat can't be empty with a late policy")
+	}
+
+	if err := c.Deadline().Validate(); err != nil {
+		return errors.New(prefix + err.Error())
+	}
+
+	if err := listgrade.ValidateWeights(c.ProblemWeights); err != nil {
+		return errors.New(prefix + err.Error())
+	}
+
+	return nil
+}
+
+// CreateContests adds contests to their classes, a contest without weight weighs 1
+// @param	db				pointer to database
+// @param	contests		contests to create
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	error 			function error
+func CreateContests(db *mongo.Client, contests []ContestCreate, databaseName, collectionName string) error {
+
+	if len(contests) == 0 {
+		return nil
+	}
+
+	for i, current := range contests {
+
+		if current.Weight == 0 {
+			contests[i].Weight = 1
+		}
+
+		if err := contests[i].Contest().Validate(); err != nil {
+			return err
+		}
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, current := range contests {
+		if _, err := collection.InsertOne(context.TODO(), current); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetContest return a single contest
+// @param	db				pointer to database
+// @param	contestID		id of the contest document
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	Contest			the contest
+// @return 	error 			function error
+func GetContest(db *mongo.Client, contestID primitive.ObjectID, databaseName, collectionName string) (Contest, error) {
+
+	var found Contest
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": contestID}).Decode(&found)
+
+	return found, err
+}
+
+// GetClassContests return the contests of the class, ordered by list
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	[]Contest		contests of the class
+// @return 	error 			function error
+func GetClassContests(db *mongo.Client, classID primitive.ObjectID, databaseName, collectionName string) ([]Contest, error) {
+
+	contests := []Contest{}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"classid": classID}, options.Find().SetSort(bson.D{{"list", 1}, {"contestid", 1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Contest
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		contests = append(contests, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return contests, nil
+}
+
+// UpdateContests changes the fields sent of each contest, the class of a contest doesn't change
+// Send policy none to remove the late policy
+// @param	db				pointer to database
+// @param	contests		contests with the fields to change
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	error 			function error
+func UpdateContests(db *mongo.Client, contests []Contest, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, current := range contests {
+
+		stored, err := GetContest(db, current.ID, databaseName, collectionName)
+
+		if err != nil {
+			return err
+		}
+
+		merged := Merge(stored, current)
+
+		if err := merged.Validate(); err != nil {
+			return err
+		}
+
+		update := bson.M{
+			"contestid":      merged.ContestID,
+			"name":           merged.Name,
+			"list":           merged.List,
+			"weight":         merged.Weight,
+			"opensat":        merged.OpensAT,
+			"closesat":       merged.ClosesAT,
+			"policy":         merged.Policy,
+			"until":          merged.Until,
+			"penalty":        merged.Penalty,
+			"problemweights": merged.ProblemWeights,
+		}
+
+		if _, err := collection.UpdateOne(context.TODO(), bson.M{"_id": current.ID}, bson.M{"$set": update}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Merge return the stored contest with the fields sent in update
+func Merge(stored, update Contest) Contest {
+
+	if update.ContestID != 0 {
+		stored.ContestID = update.ContestID
+	}
+
+	if update.Name != "" {
+		stored.Name = update.Name
+	}
+
+	if update.List != 0 {
+		stored.List = update.List
+	}
+
+	if update.Weight != 0 {
+		stored.Weight = update.Weight
+	}
+
+	if !update.OpensAT.IsZero() {
+		stored.OpensAT = update.OpensAT
+	}
+
+	if !update.ClosesAT.IsZero() {
+		stored.ClosesAT = update.ClosesAT
+	}
+
+	if update.Policy != "" {
+		stored.Policy = update.Policy
+	}
+
+	if !update.Until.IsZero() {
+		stored.Until = update.Until
+	}
+
+	if update.Penalty != 0 {
+		stored.Penalty = update.Penalty
+	}
+
+	if update.ProblemWeights != nil {
+		stored.ProblemWeights = update.ProblemWeights
+	}
+
+	return stored
+}
+
+// DeleteContests removes contests from their classes, the progress already synced is kept
+// @param	db				pointer to database
+// @param	contests		contests to delete
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	error 			function error
+func DeleteContests(db *mongo.Client, contests []Contest, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, current := range contests {
+		if _, err := collection.DeleteOne(context.TODO(), bson.M{"_id": current.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IDs return the judge contest ids of the contests
+func IDs(contests []Contest) []int {
+
+	ids := make([]int, 0, len(contests))
+
+	for _, current := range contests {
+		ids = append(ids, current.ContestID)
+	}
+
+	return ids
+}
+
+// MigrateClasses creates a contest for each id in the contestsids of the classes, numbered in that order
+// Deadlines saved in the class become the close date and policy of the contest
+// Each class migrated loses its contestsids, so contests deleted or renumbered later don't come back
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	int64			number of contests created
+// @return 	error 			function error
+func MigrateClasses(db *mongo.Client, databaseName, collectionName string) (int64, error) {
+
+	// Deadlines were kept in the class before contests existed
+	type legacyClass struct {
+		schoolClass.SchoolClass `bson:",inline"`
+		Deadlines               []deadline.Policy `json:"deadlines"`
+	}
+
+	var legacy []legacyClass
+
+	classes := db.Database(databaseName).Collection("schoolClass")
+
+	cursor, err := classes.Find(context.TODO(), bson.M{"contestsids.0": bson.M{"$exists": true}}, options.Find())
+
+	if err != nil {
+		return 0, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem legacyClass
+
+		if err := cursor.Decode(&elem); err != nil {
+			return 0, err
+		}
+
+		legacy = append(legacy, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	cursor.Close(context.TODO())
+
+	var created int64
+
+	for _, class := range legacy {
+
+		count, err := MigrateClass(db, class.SchoolClass, class.Deadlines, databaseName, collectionName)
+
+		created += count
+
+		if err != nil {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+// MigrateClass creates a contest for each id in the contestsids of a single class
+// Contests already created are left untouched, then contestsids and deadlines are removed from the class
+// @param	db				pointer to database
+// @param	class			class with its contestsids
+// @param	policies		deadlines of the contests
+// @param	databaseName	name of database
+// @param	collectionName	name of contest collection
+// @return 	int64			number of contests created
+// @return 	error 			function error
+func MigrateClass(db *mongo.Client, class schoolClass.SchoolClass, policies []deadline.Policy, databaseName, collectionName string) (int64, error) {
+
+	var created int64
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, current := range FromClass(class, policies) {
+
+		filter := bson.M{"classid": current.ClassID, "contestid": current.ContestID}
+
+		result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$setOnInsert": bson.M{
+			"name":     current.Name,
+			"list":     current.List,
+			"weight":   current.Weight,
+			"opensat":  current.OpensAT,
+			"closesat": current.ClosesAT,
+			"policy":   current.Policy,
+			"until":    current.Until,
+			"penalty":  current.Penalty,
+		}}, options.Update().SetUpsert(true))
+
+		if err != nil {
+			return created, err
+		}
+
+		if result.UpsertedID != nil {
+			created++
+		}
+	}
+
+	classes := db.Database(databaseName).Collection("schoolClass")
+
+	if _, err := classes.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": class.ID},
+		bson.M{"$unset": bson.M{"contestsids": "", "deadlines": ""}},
+	); err != nil {
+		return created, err
+	}
+
+	return created, nil
+}
+
+// FromClass return the contests of the contestsids of a class, numbered in that order and weighing 1
+// @param	class			class with its contestsids
+// @param	policies		deadlines of the contests
+// @return 	[]Contest		contests of the class
+func FromClass(class schoolClass.SchoolClass, policies []deadline.Policy) []Contest {
+
+	var contests []Contest
+
+	seen := map[int]bool{}
+
+	for _, contestID := range class.ContestsIDs {
+
+		if contestID <= 0 || seen[contestID] {
+			continue
+		}
+
+		seen[contestID] = true
+
+		current := Contest{
+			ClassID:   class.ID,
+			ContestID: contestID,
+			List:      len(contests) + 1,
+			Weight:    1,
+		}
+
+		if policy, ok := deadline.For(policies, contestID); ok {
+			current.ClosesAT = policy.Deadline
+			current.Policy = policy.Kind
+			current.Until = policy.Until
+			current.Penalty = policy.Penalty
+		}
+
+		contests = append(contests, current)
+	}
+
+	return contests
+}
@@ -0,0 +1,76 @@
+package contest
+
+import (
+	"time"
+
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// Contest is a list of the class, a contest of the judge with its name, weight and dates
+// The close date is the deadline, the policy tells what happens to problems solved after it
+type Contest struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ClassID   primitive.ObjectID `bson:"classid,omitempty"`
+	ContestID int                `json:"contestid"`
+	Name      string             `json:"name"`
+	List      int                `json:"list"`
+	Weight    float64            `json:"weight"`
+	OpensAT   time.Time          `json:"opensat"`
+	ClosesAT  time.Time          `json:"closesat"`
+	Policy    string             `json:"policy"`
+	Until     time.Time          `json:"until"`
+	Penalty   float64            `json:"penalty"`
+	// ProblemWeights is the weight of each problem in the list grade by index, problems missing weigh 1
+	ProblemWeights map[string]float64 `json:"problemweights"`
+}
+
+type ContestCreate struct {
+	ClassID   primitive.ObjectID `bson:"classid,omitempty"`
+	ContestID int                `json:"contestid"`
+	Name      string             `json:"name"`
+	List      int                `json:"list"`
+	Weight    float64            `json:"weight"`
+	OpensAT   time.Time          `json:"opensat"`
+	ClosesAT  time.Time          `json:"closesat"`
+	Policy    string             `json:"policy"`
+	Until     time.Time          `json:"until"`
+	Penalty   float64            `json:"penalty"`
+	// ProblemWeights is the weight of each problem in the list grade by index, problems missing weigh 1
+	ProblemWeights map[string]float64 `json:"problemweights"`
+}
+
+// Deadline return the late policy of the contest, contests that never close have no deadline
+func (c Contest) Deadline() deadline.Policy {
+
+	policy := deadline.Policy{
+		ContestID: c.ContestID,
+		Kind:      c.Policy,
+		Deadline:  c.ClosesAT,
+		Until:     c.Until,
+		Penalty:   c.Penalty,
+	}
+
+	if policy.Kind == "" {
+		policy.Kind = deadline.None
+	}
+
+	return policy
+}
+
+// Contest return the contest that will be created, without id
+func (c ContestCreate) Contest() Contest {
+	return Contest{
+		ClassID:        c.ClassID,
+		ContestID:      c.ContestID,
+		Name:           c.Name,
+		List:           c.List,
+		Weight:         c.Weight,
+		OpensAT:        c.OpensAT,
+		ClosesAT:       c.ClosesAT,
+		Policy:         c.Policy,
+		Until:          c.Until,
+		Penalty:        c.Penalty,
+		ProblemWeights: c.ProblemWeights,
+	}
+}
@@ -0,0 +1,264 @@
+package credential
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/mailer"
+	"github.com/apc-unb/apc-api/web/pdf"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// Slips layout, 2 columns and 5 rows on each A4 page
+const (
+	slipColumns = 2
+	slipRows    = 5
+	slipMargin  = 20.0
+)
+
+// GetRecipients return name and email of the users that got new credentials
+// @param	db				pointer to database
+// @param	credentials		credentials returned by the import
+// @param	databaseName	name of database
+// @param	collectionName	student or admin
+// @return 	map				recipients by user id
+// @return 	error 			function error
+func GetRecipients(db *mongo.Client, credentials []user.UserCredentials, databaseName, collectionName string) (map[primitive.ObjectID]Recipient, error) {
+
+	recipients := map[primitive.ObjectID]Recipient{}
+
+	if len(credentials) == 0 {
+		return recipients, nil
+	}
+
+	var ids []primitive.ObjectID
+	for _, c := range credentials {
+		ids = append(ids, c.ID)
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Recipient
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		recipients[elem.ID] = elem
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return recipients, nil
+}
+
+// BuildSlips joins the plain passwords with the name of each user
+// @param	credentials		credentials returned by the import
+// @param	recipients		recipients by user id
+// @return 	[]Slip			one slip per credential, in the import order
+func BuildSlips(credentials []user.UserCredentials, recipients map[primitive.ObjectID]Recipient) []Slip {
+
+	var slips []Slip
+
+	for _, c := range credentials {
+		recipient := recipients[c.ID]
+		slips = append(slips, Slip{
+			Name:      strings.TrimSpace(recipient.FirstName + " " + recipient.LastName),
+			Matricula: c.Matricula,
+			Password:  c.Password,
+		})
+	}
+
+	return slips
+}
+
+// SlipsPDF draws the slips with cut lines between them, so they can be handed out in class
+// @param	title			shown on top of every slip
+// @param	slips			credentials to be printed
+// @return 	[]byte			PDF file
+func SlipsPDF(title string, slips []Slip) []byte {
+
+	document := pdf.New()
+
+	width := (pdf.PageWidth - 2*slipMargin) / slipColumns
+	height := (pdf.PageHeight - 2*slipMargin) / slipRows
+	perPage := slipColumns * slipRows
+
+	for i, slip := range slips {
+
+		position := i % perPage
+
+		if position == 0 {
+			document.AddPage()
+			drawCutLines(document, width, height)
+		}
+
+		x := slipMargin + float64(position%slipColumns)*width + 16
+		top := pdf.PageHeight - slipMargin - float64(position/slipColumns)*height - 28
+
+		document.Text(x, top, 12, pdf.HelveticaBold, title)
+		document.Text(x, top-26, 10, pdf.Helvetica, "Nome: "+slip.Name)
+		document.Text(x, top-44, 10, pdf.Helvetica, "Matrícula: "+slip.Matricula)
+		document.Text(x, top-68, 10, pdf.Helvetica, "Senha temporária:")
+		document.Text(x, top-90, 16, pdf.CourierBold, slip.Password)
+		document.Text(x, top-114, 8, pdf.Helvetica, "Você deverá trocar a senha no primeiro acesso.")
+	}
+
+	return document.Bytes()
+}
+
+// QueueDeliveries records a queued email for each credential
+// Users without email are recorded as noemail and are not sent
+// @param	db				pointer to database
+// @param	credentials		credentials returned by the import
+// @param	recipients		recipients by user id
+// @param	userCollectionName	student or admin
+// @param	databaseName	name of database
+// @param	collectionName	name of deliveries collection
+// @return 	[]Delivery		one delivery per credential, all with the same batch id
+// @return 	error 			function error
+func QueueDeliveries(db *mongo.Client, credentials []user.UserCredentials, recipients map[primitive.ObjectID]Recipient, userCollectionName, databaseName, collectionName string) ([]Delivery, error) {
+
+	var deliveries []Delivery
+	var documents []interface{}
+
+	if len(credentials) == 0 {
+		return deliveries, nil
+	}
+
+	batchID := primitive.NewObjectID()
+	now := time.Now()
+
+	for _, c := range credentials {
+
+		delivery := Delivery{
+			ID:             primitive.NewObjectID(),
+			BatchID:        batchID,
+			UserID:         c.ID,
+			CollectionName: userCollectionName,
+			Matricula:      c.Matricula,
+			Email:          recipients[c.ID].Email,
+			Status:         StatusQueued,
+			CreatedAT:      now,
+		}
+
+		if delivery.Email == "" {
+			delivery.Status = StatusNoEmail
+		}
+
+		deliveries = append(deliveries, delivery)
+		documents = append(documents, delivery)
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	if _, err := collection.InsertMany(context.TODO(), documents); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// SendDeliveries emails the queued credentials and records the result of each one
+// It is slow with many students, so the routers call it in background
+// @param	db				pointer to database
+// @param	sender			mailer used to send the emails
+// @param	deliveries		deliveries created by QueueDeliveries
+// @param	messages		message of each user id
+// @param	databaseName	name of database
+// @param	collectionName	name of deliveries collection
+func SendDeliveries(db *mongo.Client, sender mailer.Mailer, deliveries []Delivery, messages map[primitive.ObjectID]mailer.Message, databaseName, collectionName string) {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, delivery := range deliveries {
+
+		if delivery.Status != StatusQueued {
+			continue
+		}
+
+		set := bson.M{"status": StatusSent, "sentat": time.Now()}
+
+		message := messages[delivery.UserID]
+		message.To = []string{delivery.Email}
+
+		if err := sender.Send(message); err != nil {
+			set = bson.M{"status": StatusFailed, "error": err.Error()}
+		}
+
+		collection.UpdateOne(context.TODO(), bson.M{"_id": delivery.ID}, bson.M{"$set": set})
+	}
+}
+
+// GetDeliveries return the delivery status of every user of an import
+// @param	db				pointer to database
+// @param	batchID			id returned by the import
+// @param	databaseName	name of database
+// @param	collectionName	name of deliveries collection
+// @return 	[]Delivery		deliveries of the batch
+// @return 	error 			function error
+func GetDeliveries(db *mongo.Client, batchID primitive.ObjectID, databaseName, collectionName string) ([]Delivery, error) {
+
+	deliveries := []Delivery{}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	var findOptions options.FindOptions
+	findOptions.SetSort(bson.D{{"matricula", 1}})
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"batchid": batchID}, &findOptions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Delivery
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return deliveries, nil
+}
+
+func drawCutLines(document *pdf.Document, width, height float64) {
+
+	for column := 1; column < slipColumns; column++ {
+		x := slipMargin + float64(column)*width
+		document.Line(x, slipMargin, x, pdf.PageHeight-slipMargin, true)
+	}
+
+	for row := 1; row < slipRows; row++ {
+		y := slipMargin + float64(row)*height
+		document.Line(slipMargin, y, pdf.PageWidth-slipMargin, y, true)
+	}
+}
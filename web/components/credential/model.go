@@ -0,0 +1,46 @@
+package credential
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// Delivery status of a credential email
+const (
+	StatusQueued  = "queued"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusNoEmail = "noemail"
+)
+
+// Recipient is the part of a student or admin needed to hand out its credentials
+type Recipient struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	FirstName string             `json:"firstname"`
+	LastName  string             `json:"lastname"`
+	Matricula string             `json:"matricula"`
+	Email     string             `json:"email"`
+}
+
+// Slip is a single credential printed in the PDF
+type Slip struct {
+	Name      string `json:"name"`
+	Matricula string `json:"matricula"`
+	Password  string `json:"password"`
+}
+
+// Delivery records if the credential email of an user was sent
+// The password is never stored, only who got it and when
+type Delivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	BatchID        primitive.ObjectID `bson:"batchid,omitempty"`
+	UserID         primitive.ObjectID `bson:"userid,omitempty"`
+	CollectionName string             `json:"collectionname"`
+	Matricula      string             `json:"matricula"`
+	Email          string             `json:"email"`
+	Status         string             `json:"status"`
+	Error          string             `json:"error"`
+	CreatedAT      time.Time          `json:"createdat"`
+	SentAT         time.Time          `json:"sentat"`
+}
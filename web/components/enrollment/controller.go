@@ -0,0 +1,532 @@
+package enrollment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the unique index of enrollments, a student is enrolled once per class
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"studentid", 1}, {"classid", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{"classid", 1}},
+		},
+	})
+
+	return err
+}
+
+// Enroll enrolls the student in the class, or enrolls again a dropped student
+// Grades are kept when the enrollment already exists
+// @param	db				pointer to database
+// @param	data			student, class, matricula and handles of the enrollment
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	Enrollment		enrollment as stored
+// @return 	error 			function error
+func Enroll(db *mongo.Client, data Enrollment, databaseName, collectionName string) (Enrollment, error) {
+
+	var enrollment Enrollment
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	now := time.Now()
+
+	filter := bson.M{
+		"studentid": data.StudentID,
+		"classid":   data.ClassID,
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"matricula": data.Matricula,
+			"handles":   data.Handles,
+			"status":    Active,
+			"updatedat": now,
+		},
+		"$setOnInsert": bson.M{
+			"grades":    data.Grades,
+			"createdat": now,
+		},
+	}
+
+	err := collection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&enrollment)
+
+	return enrollment, err
+}
+
+// Backfill creates the enrollments that don't exist yet and leaves the others untouched
+// Used to build the enrollments of students registered before enrollments existed
+// @param	db				pointer to database
+// @param	enrollments		enrollments to be created
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	int64			number of enrollments created
+// @return 	error 			function error
+func Backfill(db *mongo.Client, enrollments []Enrollment, databaseName, collectionName string) (int64, error) {
+
+	var created int64
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, data := range enrollments {
+
+		now := time.Now()
+
+		filter := bson.M{
+			"studentid": data.StudentID,
+			"classid":   data.ClassID,
+		}
+
+		update := bson.M{
+			"$setOnInsert": bson.M{
+				"matricula": data.Matricula,
+				"grades":    data.Grades,
+				"handles":   data.Handles,
+				"status":    data.Status,
+				"createdat": now,
+				"updatedat": now,
+			},
+		}
+
+		result, err := collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+
+		if err != nil {
+			return created, err
+		}
+
+		if result.UpsertedID != nil {
+			created++
+		}
+	}
+
+	return created, nil
+}
+
+// GetStudentEnrollments return every enrollment of a student, the most recent first
+// @param	db				pointer to database
+// @param	studentID		id of the student
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	[]Enrollment	enrollments of the student
+// @return 	error 			function error
+func GetStudentEnrollments(db *mongo.Client, studentID primitive.ObjectID, databaseName, collectionName string) ([]Enrollment, error) {
+	return find(db, bson.M{"studentid": studentID}, databaseName, collectionName)
+}
+
+// GetClassEnrollments return every enrollment of a class, dropped ones included
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	[]Enrollment	enrollments of the class
+// @return 	error 			function error
+func GetClassEnrollments(db *mongo.Client, classID primitive.ObjectID, databaseName, collectionName string) ([]Enrollment, error) {
+	return find(db, bson.M{"classid": classID}, databaseName, collectionName)
+}
+
+// GetEnrollment return a single enrollment
+// @param	db				pointer to database
+// @param	enrollmentID	id of the enrollment
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	Enrollment		the enrollment
+// @return 	error 			function error
+func GetEnrollment(db *mongo.Client, enrollmentID primitive.ObjectID, databaseName, collectionName string) (Enrollment, error) {
+
+	var enrollment Enrollment
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": enrollmentID}).Decode(&enrollment)
+
+	return enrollment, err
+}
+
+// UpdateStatus changes the status of an enrollment (active, dropped, approved or failed)
+// @param	db				pointer to database
+// @param	data			enrollment id and new status
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func UpdateStatus(db *mongo.Client, data StatusUpdate, databaseName, collectionName string) error {
+
+	if !ValidStatus(data.Status) {
+		return errors.New("Invalid enrollment status")
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	result, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": data.ID},
+		bson.M{"$set": bson.M{"status": data.Status, "updatedat": time.Now()}},
+	)
+
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("mongo: no documents in result")
+	}
+
+	return nil
+}
+
+// Drop sets the status of the enrollments to dropped
+// @param	db				pointer to database
+// @param	enrollmentIDs	enrollments to be dropped
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func Drop(db *mongo.Client, enrollmentIDs []primitive.ObjectID, databaseName, collectionName string) error {
+
+	if len(enrollmentIDs) == 0 {
+		return nil
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.UpdateMany(
+		context.TODO(),
+		bson.M{"_id": bson.M{"$in": enrollmentIDs}},
+		bson.M{"$set": bson.M{"status": Dropped, "updatedat": time.Now()}},
+	)
+
+	return err
+}
+
+// UpdateGrades replaces the exams and lists of an enrollment, only if they are still the ones read before
+// Empty lists are left untouched
+// @param	db				pointer to database
+// @param	enrollmentID	id of the enrollment
+// @param	old				grades read before
+// @param	grades			new grades
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	bool			false if the grades changed since they were read
+// @return 	error 			function error
+func UpdateGrades(db *mongo.Client, enrollmentID primitive.ObjectID, old, grades Grades, databaseName, collectionName string) (bool, error) {
+
+	filter := bson.M{"_id": enrollmentID}
+	update := bson.M{}
+
+	if len(grades.Exams) > 0 {
+		filter["grades.exams"] = old.Exams
+		update["grades.exams"] = grades.Exams
+	}
+
+	if len(grades.Lists) > 0 {
+		filter["grades.lists"] = old.Lists
+		update["grades.lists"] = grades.Lists
+	}
+
+	if len(update) == 0 {
+		return true, nil
+	}
+
+	update["updatedat"] = time.Now()
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	result, err := collection.UpdateOne(context.TODO(), filter, bson.M{"$set": update})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ReplaceGrades sets the exams or lists of an enrollment, only if they are still the ones read before
+// Used by changes computed from the current grades, so a concurrent change isn't lost
+// @param	db				pointer to database
+// @param	enrollmentID	id of the enrollment
+// @param	source			exams or lists
+// @param	old				grades read before
+// @param	grades			new grades
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	bool			false if the grades changed since they were read
+// @return 	error 			function error
+func ReplaceGrades(db *mongo.Client, enrollmentID primitive.ObjectID, source string, old, grades []float64, databaseName, collectionName string) (bool, error) {
+
+	if source != grading.Exams && source != grading.Lists {
+		return false, errors.New("Invalid source, use exams or lists")
+	}
+
+	field := "grades." + source
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	result, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": enrollmentID, field: old},
+		bson.M{"$set": bson.M{field: grades, "updatedat": time.Now()}},
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// WithGrade return a copy of the grades with the grade at index (1-based)
+// Exams or lists before it that the student doesn't have yet get 0
+func WithGrade(values []float64, index int, grade float64) []float64 {
+
+	size := len(values)
+
+	if index > size {
+		size = index
+	}
+
+	updated := make([]float64, size)
+
+	copy(updated, values)
+
+	updated[index-1] = grade
+
+	return updated
+}
+
+// SetResult stores the final grade computed from the grades of the enrollment
+// @param	db				pointer to database
+// @param	enrollmentID	id of the enrollment
+// @param	result			final grade and mention
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func SetResult(db *mongo.Client, enrollmentID primitive.ObjectID, result grading.Result, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": enrollmentID},
+		bson.M{"$set": bson.M{"result": result}},
+	)
+
+	return err
+}
+
+// UpdateHandles copies the new handles of the student to the active enrollments
+// Finished enrollments keep the handles used in that class
+// @param	db				pointer to database
+// @param	studentID		id of the student
+// @param	handles			handles to be set, empty handles are left untouched
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func UpdateHandles(db *mongo.Client, studentID primitive.ObjectID, handles Handles, databaseName, collectionName string) error {
+
+	update := bson.M{}
+
+	if handles.Codeforces != "" {
+		update["handles.codeforces"] = handles.Codeforces
+	}
+
+	if handles.Uri != "" {
+		update["handles.uri"] = handles.Uri
+	}
+
+	if len(update) == 0 {
+		return nil
+	}
+
+	update["updatedat"] = time.Now()
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.UpdateMany(
+		context.TODO(),
+		bson.M{"studentid": studentID, "status": Active},
+		bson.M{"$set": update},
+	)
+
+	return err
+}
+
+// DeleteStudentEnrollments deletes every enrollment of a student
+// @param	db				pointer to database
+// @param	studentID		id of the student
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func DeleteStudentEnrollments(db *mongo.Client, studentID primitive.ObjectID, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.DeleteMany(context.TODO(), bson.M{"studentid": studentID})
+
+	return err
+}
+
+// Move gives the enrollments of a student to another student, used to merge a person registered twice
+// Enrollments in a class the other student is already enrolled in are deleted, the other student keeps his
+// @param	db				pointer to database
+// @param	fromID			student that loses the enrollments
+// @param	toID			student that receives them
+// @param	databaseName	name of database
+// @param	collectionName	name of enrollments collection
+// @return 	error 			function error
+func Move(db *mongo.Client, fromID, toID primitive.ObjectID, databaseName, collectionName string) error {
+
+	from, err := find(db, bson.M{"studentid": fromID}, databaseName, collectionName)
+
+	if err != nil {
+		return err
+	}
+
+	to, err := find(db, bson.M{"studentid": toID}, databaseName, collectionName)
+
+	if err != nil {
+		return err
+	}
+
+	enrolled := map[primitive.ObjectID]bool{}
+
+	for _, current := range to {
+		enrolled[current.ClassID] = true
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, current := range from {
+
+		if enrolled[current.ClassID] {
+			if _, err = collection.DeleteOne(context.TODO(), bson.M{"_id": current.ID}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err = collection.UpdateOne(context.TODO(), bson.M{"_id": current.ID}, bson.M{"$set": bson.M{"studentid": toID}}); err != nil {
+			return err
+		}
+
+		enrolled[current.ClassID] = true
+	}
+
+	return nil
+}
+
+// Choose return the enrollment a student opens at login
+// Without an id the most recent active enrollment is opened, or the most recent finished one
+// Dropped enrollments can't be opened
+// @param	enrollments		enrollments of the student
+// @param	enrollmentID	enrollment asked by the student, may be nil
+// @return 	Enrollment		enrollment to be opened
+// @return 	error 			function error
+func Choose(enrollments []Enrollment, enrollmentID primitive.ObjectID) (Enrollment, error) {
+
+	var chosen Enrollment
+
+	if !enrollmentID.IsZero() {
+		for _, enrollment := range enrollments {
+			if enrollment.ID == enrollmentID {
+				if enrollment.Status == Dropped {
+					return chosen, errors.New("Enrollment was dropped")
+				}
+				return enrollment, nil
+			}
+		}
+		return chosen, errors.New("Enrollment not found")
+	}
+
+	found := false
+
+	for _, enrollment := range enrollments {
+
+		if enrollment.Status == Dropped {
+			continue
+		}
+
+		if !found || rank(enrollment) > rank(chosen) ||
+			(rank(enrollment) == rank(chosen) && enrollment.CreatedAT.After(chosen.CreatedAT)) {
+			chosen = enrollment
+			found = true
+		}
+	}
+
+	if !found {
+		return chosen, errors.New("Student has no open enrollment")
+	}
+
+	return chosen, nil
+}
+
+// ValidStatus tells if status is one of the enrollment statuses
+func ValidStatus(status string) bool {
+	switch status {
+	case Active, Dropped, Approved, Failed:
+		return true
+	}
+	return false
+}
+
+// rank puts active enrollments before finished ones
+func rank(enrollment Enrollment) int {
+	if enrollment.Status == Active {
+		return 1
+	}
+	return 0
+}
+
+// find return the enrollments that match filter, the most recent first
+func find(db *mongo.Client, filter bson.M, databaseName, collectionName string) ([]Enrollment, error) {
+
+	enrollments := []Enrollment{}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find().SetSort(bson.D{{"createdat", -1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Enrollment
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		enrollments = append(enrollments, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return enrollments, nil
+}
@@ -0,0 +1,66 @@
+package enrollment
+
+import (
+	"time"
+
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+const (
+	Active   = "active"
+	Dropped  = "dropped"
+	Approved = "approved"
+	Failed   = "failed"
+)
+
+// Enrollment links a student (the person, keyed by matricula) to a class
+// Grades and handles belong to the enrollment so past classes keep their own data
+type Enrollment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	StudentID primitive.ObjectID `bson:"studentid,omitempty"`
+	ClassID   primitive.ObjectID `bson:"classid,omitempty"`
+	Matricula string             `json:"matricula"`
+	Grades    Grades             `json:"grades"`
+	Handles   Handles            `json:"handles"`
+	Status    string             `json:"status"`
+	Result    grading.Result     `json:"result"`
+	CreatedAT time.Time          `json:"createdat"`
+	UpdatedAT time.Time          `json:"updatedat"`
+}
+
+// Grades of the student in the class
+type Grades struct {
+	Exams []float64 `json:"exams"`
+	Lists []float64 `json:"lists"`
+}
+
+// Handles of the student while enrolled in the class
+type Handles struct {
+	Codeforces string `json:"codeforces"`
+	Uri        string `json:"uri"`
+}
+
+// StatusUpdate is the body sent by an admin to change the status of an enrollment
+type StatusUpdate struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty"`
+	Status string             `json:"status"`
+}
+
+// Source return the exams or the lists
+func (g Grades) Source(source string) []float64 {
+	if source == grading.Lists {
+		return g.Lists
+	}
+	return g.Exams
+}
+
+// With return a copy of the grades with the exams or the lists replaced
+func (g Grades) With(source string, values []float64) Grades {
+	if source == grading.Lists {
+		g.Lists = values
+	} else {
+		g.Exams = values
+	}
+	return g
+}
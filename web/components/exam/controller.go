@@ -127,8 +127,26 @@ func GetExamsClass(db *mongo.Client, classID primitive.ObjectID, databaseName, c
 
 }
 
+// GetExam return the exam with the given id
+// @param	db				pointer to database
+// @param   examID			exam ID
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	Exam			the exam found
+// @return 	error 			function error
+func GetExam(db *mongo.Client, examID primitive.ObjectID, databaseName, collectionName string) (Exam, error) {
+
+	var found Exam
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": examID}).Decode(&found)
+
+	return found, err
+}
+
 // UpdateExams receive list of exams (updated)
-// Checks if exams old password matches with db to update that exams password or email
+// Updates the title of each exam, the class of an exam doesn't change
 // @param	db				pointer to database (updated)
 // @param	exams 			list of exams
 // @param	databaseName	name of database
@@ -154,10 +172,6 @@ func UpdateExams(db *mongo.Client, exams []Exam, databaseName, collectionName st
 			update["title"] = exam.Title
 		}
 
-		if !exam.ClassID.IsZero() {
-			update["classid"] = exam.ClassID
-		}
-
 		updateSet := bson.M{"$set": update}
 
 		if _, err := collection.UpdateOne(context.TODO(), filter, updateSet, nil); err != nil {
@@ -0,0 +1,1099 @@
+package grade
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/audit"
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/components/project"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/components/student"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/listgrade"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/apc-unb/apc-api/web/xlsx"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Highest exam or list a score file can fill
+const maxTargetIndex = 50
+
+// Actions of a list grade besides updated and unchanged
+const (
+	// ActionOverridden is a grade that didn't come from the judge, it is kept
+	ActionOverridden = "overridden"
+	// ActionConflict is a grade not written because the lists of the student changed meanwhile
+	ActionConflict = "conflict"
+)
+
+// listsReason is kept in the grade history of the list grades computed from the judge
+const listsReason = "Computed from the judge progress"
+
+// Input return the grades of a student by source, as read by the grading scheme
+// @param	grades			exams and lists of the enrollment
+// @param	projects		score of the confirmed projects
+// @return 	Input			grades by source
+func Input(grades enrollment.Grades, projects []float64) grading.Input {
+	return grading.Input{
+		grading.Exams:    grades.Exams,
+		grading.Lists:    grades.Lists,
+		grading.Projects: projects,
+	}
+}
+
+// Recompute computes again the final grade of a student in a class and stores it in the enrollment
+// Nothing is done if the student isn't enrolled in the class
+// @param	db				pointer to database
+// @param	studentID		id of the student
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @return 	Result			final grade and mention
+// @return 	error 			function error
+func Recompute(db *mongo.Client, studentID, classID primitive.ObjectID, databaseName string) (grading.Result, error) {
+
+	var result grading.Result
+
+	class, err := schoolClass.GetClass(db, classID, databaseName, "schoolClass")
+
+	if err != nil {
+		return result, err
+	}
+
+	enrollments, err := enrollment.GetStudentEnrollments(db, studentID, databaseName, "enrollment")
+
+	if err != nil {
+		return result, err
+	}
+
+	scores, err := GetProjectScores(db, classID, databaseName)
+
+	if err != nil {
+		return result, err
+	}
+
+	for _, current := range enrollments {
+
+		if current.ClassID != classID {
+			continue
+		}
+
+		result = class.Grading.Compute(Input(current.Grades, scores[studentID]))
+
+		if err := enrollment.SetResult(db, current.ID, result, databaseName, "enrollment"); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// RecomputeClass computes again the final grade of every student enrolled in the class
+// Used when the scheme of the class or the score of a project changes
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @return 	[]StudentGrade	grade sheet of the class
+// @return 	error 			function error
+func RecomputeClass(db *mongo.Client, classID primitive.ObjectID, databaseName string) ([]StudentGrade, error) {
+
+	class, err := schoolClass.GetClass(db, classID, databaseName, "schoolClass")
+
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := GetClassGrades(db, classID, databaseName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sheet {
+
+		sheet[i].Result = class.Grading.Compute(Input(sheet[i].Grades, sheet[i].Projects))
+
+		if err := enrollment.SetResult(db, sheet[i].EnrollmentID, sheet[i].Result, databaseName, "enrollment"); err != nil {
+			return nil, err
+		}
+	}
+
+	return sheet, nil
+}
+
+// Preview computes the grades of the class with a scheme that isn't saved yet
+// Nothing is stored, the professor sees the result before changing the scheme of the class
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	scheme			scheme to be tried
+// @param	databaseName	name of database
+// @return 	[]StudentGrade	grade sheet of the class with the scheme
+// @return 	error 			function error
+func Preview(db *mongo.Client, classID primitive.ObjectID, scheme grading.Scheme, databaseName string) ([]StudentGrade, error) {
+
+	sheet, err := GetClassGrades(db, classID, databaseName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sheet {
+		sheet[i].Result = scheme.Compute(Input(sheet[i].Grades, sheet[i].Projects))
+	}
+
+	return sheet, nil
+}
+
+// GetClassGrades return the grade sheet of the class, with the grades stored in the enrollments
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @return 	[]StudentGrade	grade sheet of the class, ordered by name
+// @return 	error 			function error
+func GetClassGrades(db *mongo.Client, classID primitive.ObjectID, databaseName string) ([]StudentGrade, error) {
+
+	sheet := []StudentGrade{}
+
+	enrollments, err := enrollment.GetClassEnrollments(db, classID, databaseName, "enrollment")
+
+	if err != nil {
+		return nil, err
+	}
+
+	students, err := student.GetStudentsClass(db, classID, databaseName, "student")
+
+	if err != nil {
+		return nil, err
+	}
+
+	scores, err := GetProjectScores(db, classID, databaseName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	enrolled := map[primitive.ObjectID]enrollment.Enrollment{}
+
+	for _, current := range enrollments {
+		enrolled[current.StudentID] = current
+	}
+
+	for _, current := range students {
+
+		classEnrollment := enrolled[current.ID]
+
+		projects := scores[current.ID]
+
+		if projects == nil {
+			projects = []float64{}
+		}
+
+		sheet = append(sheet, StudentGrade{
+			StudentID:    current.ID,
+			EnrollmentID: classEnrollment.ID,
+			Matricula:    current.Matricula,
+			FirstName:    current.FirstName,
+			LastName:     current.LastName,
+			Status:       classEnrollment.Status,
+			Grades:       classEnrollment.Grades,
+			Projects:     projects,
+			Result:       classEnrollment.Result,
+		})
+	}
+
+	return sheet, nil
+}
+
+// GetProjectScores return the score of the confirmed projects of each student of the class
+// A confirmed project is worth the score of its project type
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @return 	map				scores by student
+// @return 	error 			function error
+func GetProjectScores(db *mongo.Client, classID primitive.ObjectID, databaseName string) (map[primitive.ObjectID][]float64, error) {
+
+	scores := map[primitive.ObjectID][]float64{}
+	typeScores := map[primitive.ObjectID]float64{}
+
+	cursor, err := db.Database(databaseName).Collection("projectType").Find(
+		context.TODO(),
+		bson.M{"classid": classID},
+		options.Find(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem project.ProjectType
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		if elem.ID != nil {
+			typeScores[*elem.ID] = elem.Score
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	cursor, err = db.Database(databaseName).Collection("projects").Find(
+		context.TODO(),
+		bson.M{"classid": classID, "status": project.Confirmed},
+		options.Find(),
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem project.Project
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		if score, ok := typeScores[elem.ProjectTypeID]; ok {
+			scores[elem.StudentID] = append(scores[elem.StudentID], score)
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return scores, nil
+}
+
+// Validate checks the exam or list of a score import
+func (t Target) Validate() error {
+
+	if t.Source != grading.Exams && t.Source != grading.Lists {
+		return errors.New("Invalid source, use exams or lists")
+	}
+
+	if t.Index < 1 || t.Index > maxTargetIndex {
+		return errors.New("Index of the exam or list must be between 1 and " + strconv.Itoa(maxTargetIndex))
+	}
+
+	return nil
+}
+
+// Diff validates the score file against the grade sheet of the class and tells the grade each student gets
+// Rows of students that aren't enrolled or dropped the class are invalid
+// @param	sheet			parsed score file
+// @param	class			grade sheet of the class
+// @param	target			exam or list filled by the file
+// @param	max				highest score accepted, 0 for no limit
+// @return 	ImportReport	rows with their errors and the change of each valid row
+func Diff(sheet roster.ScoreSheet, class []StudentGrade, target Target, max float64) ImportReport {
+
+	report := ImportReport{
+		Encoding: sheet.Encoding,
+		Target:   target,
+		Total:    len(sheet.Rows),
+		Changes:  []Change{},
+	}
+
+	students := map[string]StudentGrade{}
+
+	for _, line := range class {
+		students[line.Matricula] = line
+	}
+
+	var changes []Change
+
+	// The rows get the errors of the class, the file parsed stays as it was
+	sheet.Rows = append([]roster.ScoreRow(nil), sheet.Rows...)
+
+	for i := range sheet.Rows {
+
+		row := &sheet.Rows[i]
+		line, ok := students[row.Matricula]
+
+		switch {
+		case row.Matricula == "":
+		case !ok:
+			row.Errors = append(row.Errors, "Student not enrolled in the class")
+		case line.Status == enrollment.Dropped:
+			row.Errors = append(row.Errors, "Student dropped the class")
+		}
+
+		if max > 0 && row.Score > max {
+			row.Errors = append(row.Errors, "Score above the maximum of "+strconv.FormatFloat(max, 'f', -1, 64))
+		}
+
+		if len(row.Errors) > 0 {
+			continue
+		}
+
+		change := Change{
+			StudentID:    line.StudentID,
+			EnrollmentID: line.EnrollmentID,
+			Line:         row.Line,
+			Matricula:    line.Matricula,
+			FirstName:    line.FirstName,
+			LastName:     line.LastName,
+			New:          row.Score,
+			Action:       roster.ActionUpdated,
+		}
+
+		if current := line.Grades.Source(target.Source); target.Index <= len(current) {
+			old := current[target.Index-1]
+			change.Old = &old
+			if old == row.Score {
+				change.Action = roster.ActionUnchanged
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	sheet.UpdateStatus()
+
+	report.Rows = sheet.Rows
+
+	for _, row := range sheet.Rows {
+		if row.Status == roster.StatusValid {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+
+	for _, change := range changes {
+		if change.Action == roster.ActionUpdated {
+			report.Updated++
+		} else {
+			report.Unchanged++
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	return report
+}
+
+// ErrConflict is returned when the grades of a student changed during an import, nothing is saved
+var ErrConflict = errors.New("Grades changed during the import, nothing was applied")
+
+// ImportScores sets the score of a single exam or list for the students of the file
+// Every row is validated first, nothing is applied while any row is invalid or on dry run
+// If the grades of a student change during the import the applied changes are undone
+// A single audit entry records the whole import and every grade changed goes to the grade history,
+// both are written before the grades. When the grades fail the history of the grades restored is removed
+// and a grade.import.failed entry is audited, grades that couldn't be restored make the report partial
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	sheet			parsed score file
+// @param	target			exam or list filled by the file
+// @param	max				highest score accepted, 0 for no limit
+// @param	dryRun			only report the changes
+// @param	actor			who imports, only ActorID and Role are read
+// @param	reason			why the grades changed, kept in the grade history
+// @param	databaseName	name of database
+// @return 	ImportReport	rows with their errors and the change of each valid row
+// @return 	error 			function error
+func ImportScores(db *mongo.Client, classID primitive.ObjectID, sheet roster.ScoreSheet, target Target, max float64, dryRun bool, actor audit.Entry, reason, databaseName string) (ImportReport, error) {
+
+	var report ImportReport
+
+	if err := target.Validate(); err != nil {
+		return report, err
+	}
+
+	class, err := GetClassGrades(db, classID, databaseName)
+
+	if err != nil {
+		return report, err
+	}
+
+	report = Diff(sheet, class, target, max)
+	report.DryRun = dryRun
+
+	if dryRun || report.Invalid > 0 {
+		return report, nil
+	}
+
+	current := map[primitive.ObjectID][]float64{}
+
+	for _, line := range class {
+		current[line.EnrollmentID] = line.Grades.Source(target.Source)
+	}
+
+	var history []gradeHistory.Change
+
+	for _, change := range report.Changes {
+
+		if change.Action != roster.ActionUpdated {
+			continue
+		}
+
+		old := current[change.EnrollmentID]
+
+		history = append(history, gradeHistory.Diff(
+			enrollment.Grades{}.With(target.Source, old),
+			enrollment.Grades{}.With(target.Source, enrollment.WithGrade(old, target.Index, change.New)),
+			gradeHistory.Change{
+				EnrollmentID: change.EnrollmentID,
+				StudentID:    change.StudentID,
+				ClassID:      classID,
+				ActorID:      actor.ActorID,
+				Role:         actor.Role,
+				Origin:       gradeHistory.OriginImport,
+				Reason:       reason,
+			},
+		)...)
+	}
+
+	// History and audit go first, so no grade is saved without them
+	if err := gradeHistory.Record(db, history, databaseName, "gradeHistory"); err != nil {
+		return report, err
+	}
+
+	entry := actor
+	entry.Action = audit.GradeImport
+	entry.Entity = "schoolClass"
+	entry.EntityID = classID
+	entry.After = map[string]interface{}{
+		"target":  target,
+		"changes": report.Changes,
+	}
+
+	if report.AuditID, err = audit.Record(db, entry, databaseName, "audit"); err != nil {
+		if removeErr := gradeHistory.Remove(db, history, databaseName, "gradeHistory"); removeErr != nil {
+			return report, errors.New(err.Error() + ", the grade history of the import couldn't be removed: " + removeErr.Error())
+		}
+		return report, err
+	}
+
+	unrestored, err := applyScores(db, report.Changes, current, target, databaseName)
+
+	if err == nil {
+		return report, nil
+	}
+
+	report.Partial = len(unrestored) > 0
+	report.Unrestored = unrestored
+
+	// Only the grades still changed keep their history
+	kept := map[primitive.ObjectID]bool{}
+
+	for _, change := range unrestored {
+		kept[change.EnrollmentID] = true
+	}
+
+	var removed []gradeHistory.Change
+
+	for _, change := range history {
+		if !kept[change.EnrollmentID] {
+			removed = append(removed, change)
+		}
+	}
+
+	failed := err.Error()
+
+	if removeErr := gradeHistory.Remove(db, removed, databaseName, "gradeHistory"); removeErr != nil {
+		failed += ", the grade history of the import couldn't be removed: " + removeErr.Error()
+	}
+
+	entry = actor
+	entry.Action = audit.GradeImportFailed
+	entry.Entity = "schoolClass"
+	entry.EntityID = classID
+	entry.After = map[string]interface{}{
+		"target":     target,
+		"error":      err.Error(),
+		"import":     report.AuditID,
+		"unrestored": unrestored,
+	}
+
+	if _, auditErr := audit.Record(db, entry, databaseName, "audit"); auditErr != nil {
+		failed += ", the failure couldn't be audited: " + auditErr.Error()
+	}
+
+	if !report.Partial && failed == err.Error() {
+		return report, err
+	}
+
+	return report, errors.New(failed)
+}
+
+// applyScores saves the changes of the import, on failure the changes already saved are undone
+// @return 	[]Change		changes still saved because undoing them failed too
+// @return 	error 			function error, ErrConflict when a student changed and everything was undone
+func applyScores(db *mongo.Client, changes []Change, current map[primitive.ObjectID][]float64, target Target, databaseName string) ([]Change, error) {
+
+	var applied []Change
+
+	for _, change := range changes {
+
+		if change.Action != roster.ActionUpdated {
+			continue
+		}
+
+		old := current[change.EnrollmentID]
+
+		ok, err := enrollment.ReplaceGrades(db, change.EnrollmentID, target.Source, old, enrollment.WithGrade(old, target.Index, change.New), databaseName, "enrollment")
+
+		if err == nil && !ok {
+			err = ErrConflict
+		}
+
+		if err == nil {
+			applied = append(applied, change)
+			continue
+		}
+
+		var unrestored []Change
+		var undoErrors []string
+
+		for _, done := range applied {
+
+			old := current[done.EnrollmentID]
+
+			restored, undoErr := enrollment.ReplaceGrades(db, done.EnrollmentID, target.Source, enrollment.WithGrade(old, target.Index, done.New), old, databaseName, "enrollment")
+
+			if undoErr == nil && !restored {
+				undoErr = errors.New("grades changed again")
+			}
+
+			if undoErr != nil {
+				unrestored = append(unrestored, done)
+				undoErrors = append(undoErrors, done.Matricula+": "+undoErr.Error())
+			}
+		}
+
+		if len(unrestored) > 0 {
+			return unrestored, errors.New("Import partially applied, " + strconv.Itoa(len(unrestored)) + " grades couldn't be restored after: " + err.Error() + " (" + strings.Join(undoErrors, "; ") + ")")
+		}
+
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// ListGrades computes the grade of every active student in every list of the class from the judge progress
+// Contests sharing a list number make a single list, contests without list number or not opened yet are left out
+// Students without a result in the contests of a list don't get that list
+// A stored grade that didn't come from the judge is an override and is kept, unless force
+// @param	sheet			grade sheet of the class
+// @param	contests		contests of the class
+// @param	snapshots		results of the students in the contests
+// @param	curve			curve of the class, linear up to 10 when empty
+// @param	origins			origin of the list grades stored
+// @param	force			replaces the overrides
+// @param	now				contests opening after it are left out
+// @return 	[]ListGrade		grade of each student in each list
+func ListGrades(sheet []StudentGrade, contests []contest.Contest, snapshots []progress.Snapshot, curve listgrade.Curve, origins map[gradeHistory.Grade]string, force bool, now time.Time) []ListGrade {
+
+	results := map[primitive.ObjectID]map[int]progress.Snapshot{}
+
+	for _, snapshot := range snapshots {
+		if results[snapshot.StudentID] == nil {
+			results[snapshot.StudentID] = map[int]progress.Snapshot{}
+		}
+		results[snapshot.StudentID][snapshot.ContestID] = snapshot
+	}
+
+	lists := map[int][]contest.Contest{}
+	var numbers []int
+
+	for _, current := range contests {
+
+		if current.List <= 0 || current.OpensAT.After(now) {
+			continue
+		}
+
+		if lists[current.List] == nil {
+			numbers = append(numbers, current.List)
+		}
+
+		lists[current.List] = append(lists[current.List], current)
+	}
+
+	sort.Ints(numbers)
+
+	grades := []ListGrade{}
+
+	for _, line := range sheet {
+
+		if line.Status != enrollment.Active || line.EnrollmentID.IsZero() {
+			continue
+		}
+
+		for _, number := range numbers {
+
+			result := ListGrade{
+				StudentID:    line.StudentID,
+				EnrollmentID: line.EnrollmentID,
+				Matricula:    line.Matricula,
+				FirstName:    line.FirstName,
+				LastName:     line.LastName,
+				List:         number,
+				Action:       roster.ActionUpdated,
+			}
+
+			for _, current := range lists[number] {
+
+				snapshot, ok := results[line.StudentID][current.ContestID]
+
+				if !ok {
+					continue
+				}
+
+				credit, total := listgrade.Score(snapshot.Problems, snapshot.Solved, current.ProblemWeights)
+
+				// Snapshots synced before the problems were kept only have the count
+				if len(snapshot.Problems) == 0 {
+					credit, total = snapshot.Credit, float64(snapshot.Total)
+				}
+
+				result.ContestIDs = append(result.ContestIDs, current.ContestID)
+				result.Credit += credit
+				result.Total += total
+			}
+
+			if result.Total <= 0 {
+				continue
+			}
+
+			result.Grade = curve.Grade(result.Credit / result.Total)
+
+			if stored := line.Grades.Lists; number <= len(stored) {
+
+				old := stored[number-1]
+				result.Old = &old
+				result.Origin = origins[gradeHistory.Grade{EnrollmentID: line.EnrollmentID, Source: grading.Lists, Index: number}]
+
+				switch {
+				case result.Origin != gradeHistory.OriginJudge && !force:
+					result.Action = ActionOverridden
+				case old == result.Grade:
+					result.Action = roster.ActionUnchanged
+				}
+			}
+
+			grades = append(grades, result)
+		}
+	}
+
+	return grades
+}
+
+// ComputeLists computes the list grades of the class from the judge progress and writes them in the grades of the students
+// Grades overridden by hand are kept and reported, force replaces them
+// Each student is written only if his lists are still the ones read, otherwise his grades are a conflict and left as they are
+// Every grade changed goes to the grade history with the judge origin and a single audit entry records the whole computation
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	force			replaces the grades overridden by hand
+// @param	dryRun			only report the grades
+// @param	actor			who asked, only ActorID and Role are read, empty for the sync
+// @param	databaseName	name of database
+// @return 	ListReport		grade of each student in each list and what was done with it
+// @return 	error 			function error
+func ComputeLists(db *mongo.Client, classID primitive.ObjectID, force, dryRun bool, actor audit.Entry, databaseName string) (ListReport, error) {
+
+	report := ListReport{DryRun: dryRun, Force: force}
+
+	class, err := schoolClass.GetClass(db, classID, databaseName, "schoolClass")
+
+	if err != nil {
+		return report, err
+	}
+
+	report.Curve = class.ListCurve
+
+	contests, err := contest.GetClassContests(db, classID, databaseName, "contest")
+
+	if err != nil {
+		return report, err
+	}
+
+	sheet, err := GetClassGrades(db, classID, databaseName)
+
+	if err != nil {
+		return report, err
+	}
+
+	snapshots, err := progress.GetClassSnapshots(db, classID, databaseName, "progress")
+
+	if err != nil {
+		return report, err
+	}
+
+	changes, err := gradeHistory.GetClassChanges(db, classID, grading.Lists, databaseName, "gradeHistory")
+
+	if err != nil {
+		return report, err
+	}
+
+	report.Grades = ListGrades(sheet, contests, snapshots, class.ListCurve, gradeHistory.Origins(changes), force, time.Now())
+
+	if !dryRun {
+		if err := applyLists(db, sheet, report.Grades, classID, actor, databaseName); err != nil {
+			return report, err
+		}
+	}
+
+	var written []ListGrade
+
+	for _, current := range report.Grades {
+		switch current.Action {
+		case roster.ActionUpdated:
+			report.Updated++
+			written = append(written, current)
+		case roster.ActionUnchanged:
+			report.Unchanged++
+		case ActionOverridden:
+			report.Overridden++
+		case ActionConflict:
+			report.Conflicts++
+		}
+	}
+
+	if dryRun || len(written) == 0 {
+		return report, nil
+	}
+
+	actor.Action = audit.GradeLists
+	actor.Entity = "schoolClass"
+	actor.EntityID = classID
+	actor.After = map[string]interface{}{
+		"force":  force,
+		"grades": written,
+	}
+
+	report.AuditID, err = audit.Record(db, actor, databaseName, "audit")
+
+	return report, err
+}
+
+// applyLists writes the updated list grades of each student and records them in the grade history
+// A student whose lists changed since they were read isn't written, his grades become conflicts
+func applyLists(db *mongo.Client, sheet []StudentGrade, grades []ListGrade, classID primitive.ObjectID, actor audit.Entry, databaseName string) error {
+
+	var history []gradeHistory.Change
+
+	for _, line := range sheet {
+
+		var updates []int
+
+		old := line.Grades.Lists
+		lists := old
+
+		for i, current := range grades {
+			if current.EnrollmentID == line.EnrollmentID && current.Action == roster.ActionUpdated {
+				lists = enrollment.WithGrade(lists, current.List, current.Grade)
+				updates = append(updates, i)
+			}
+		}
+
+		if len(updates) == 0 {
+			continue
+		}
+
+		ok, err := enrollment.ReplaceGrades(db, line.EnrollmentID, grading.Lists, old, lists, databaseName, "enrollment")
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			for _, i := range updates {
+				grades[i].Action = ActionConflict
+			}
+			continue
+		}
+
+		history = append(history, gradeHistory.Diff(
+			enrollment.Grades{Lists: old},
+			enrollment.Grades{Lists: lists},
+			gradeHistory.Change{
+				EnrollmentID: line.EnrollmentID,
+				StudentID:    line.StudentID,
+				ClassID:      classID,
+				ActorID:      actor.ActorID,
+				Role:         actor.Role,
+				Origin:       gradeHistory.OriginJudge,
+				Reason:       listsReason,
+			},
+		)...)
+	}
+
+	return gradeHistory.Record(db, history, databaseName, "gradeHistory")
+}
+
+// SyncLists computes the list grades of every class with a list curve, the grades overridden by hand are kept
+// The final grades of a class are computed again when a list grade changes
+// A class that fails doesn't stop the others, the last error is returned
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @return 	int				number of classes computed
+// @return 	error 			function error
+func SyncLists(db *mongo.Client, databaseName string) (int, error) {
+
+	classes, err := schoolClass.GetClasses(db, databaseName, "schoolClass")
+
+	if err != nil {
+		return 0, err
+	}
+
+	var failed error
+
+	computed := 0
+
+	for _, class := range classes {
+
+		if class.ListCurve.Empty() {
+			continue
+		}
+
+		report, err := ComputeLists(db, class.ID, false, false, audit.Entry{}, databaseName)
+
+		if err == nil && report.Updated > 0 {
+			_, err = RecomputeClass(db, class.ID, databaseName)
+		}
+
+		if err != nil {
+			logrus.Errorf("Not able to compute the list grades of class %s: %s", class.ID.Hex(), err.Error())
+			failed = err
+			continue
+		}
+
+		if report.Overridden > 0 || report.Conflicts > 0 {
+			logrus.Infof("Class %s: %d list grades kept as overridden, %d in conflict", class.ID.Hex(), report.Overridden, report.Conflicts)
+		}
+
+		computed++
+	}
+
+	return computed, failed
+}
+
+// RunLists computes the list grades of the classes right away and then once per interval, until ctx is cancelled
+// The grades come from the progress of the last judge sync, see progress.Run
+// @param	ctx				stops the worker
+// @param	db				pointer to database
+// @param	interval		time between two rounds
+// @param	databaseName	name of database
+func RunLists(ctx context.Context, db *mongo.Client, interval time.Duration, databaseName string) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		if computed, err := SyncLists(db, databaseName); err != nil {
+			logrus.Errorf("List grades finished with errors, %d classes computed: %s", computed, err.Error())
+		} else {
+			logrus.Infof("List grades of %d classes computed", computed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Gradebook return the grade sheet as a table, one row per student with every exam, list and project
+// Grades the student doesn't have yet are empty cells
+// @param	sheet			grade sheet of the class
+// @return 	[][]interface{}	header and one row per student, cells are strings or float64
+func Gradebook(sheet []StudentGrade) [][]interface{} {
+
+	var exams, lists, projects int
+
+	for _, line := range sheet {
+		exams = maxInt(exams, len(line.Grades.Exams))
+		lists = maxInt(lists, len(line.Grades.Lists))
+		projects = maxInt(projects, len(line.Projects))
+	}
+
+	header := []interface{}{"Matrícula", "Nome", "Situação"}
+
+	header = append(header, numbered("Prova", exams)...)
+	header = append(header, numbered("Lista", lists)...)
+	header = append(header, numbered("Projeto", projects)...)
+	header = append(header, "Nota final", "Menção")
+
+	table := [][]interface{}{header}
+
+	for _, line := range sheet {
+
+		row := []interface{}{line.Matricula, strings.TrimSpace(line.FirstName + " " + line.LastName), line.Status}
+
+		row = append(row, grades(line.Grades.Exams, exams)...)
+		row = append(row, grades(line.Grades.Lists, lists)...)
+		row = append(row, grades(line.Projects, projects)...)
+		row = append(row, line.Result.Final, line.Result.Mention)
+
+		table = append(table, row)
+	}
+
+	return table
+}
+
+// GradebookCSV return the gradebook as an UTF-8 CSV file
+// @param	sheet			grade sheet of the class
+// @return 	[]byte			the CSV file
+// @return 	error 			function error
+func GradebookCSV(sheet []StudentGrade) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+
+	for _, row := range Gradebook(sheet) {
+		if err := writer.Write(records(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+
+	return buffer.Bytes(), writer.Error()
+}
+
+// GradebookXLSX return the gradebook as an XLSX file, grades are number cells
+// @param	sheet			grade sheet of the class
+// @param	title			name of the sheet
+// @return 	[]byte			the XLSX file
+// @return 	error 			function error
+func GradebookXLSX(sheet []StudentGrade, title string) ([]byte, error) {
+	return xlsx.Write(title, Gradebook(sheet))
+}
+
+// GradebookSIGAA return the final grades in the layout of the SIGAA grade upload
+// The first line is the class (YEAR/SEASON/CLASS), like the SIGAA roster export,
+// then Matrícula;Nome;Nota;Menção with decimal comma, encoded in Windows-1252
+// Dropped students are not in the class anymore and are left out
+// @param	sheet			grade sheet of the class
+// @param	class			the class
+// @return 	[]byte			the CSV file
+// @return 	error 			function error
+func GradebookSIGAA(sheet []StudentGrade, class schoolClass.SchoolClass) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	writer.Comma = ';'
+	writer.UseCRLF = true
+
+	lines := [][]interface{}{
+		{strconv.Itoa(class.Year) + "/" + strconv.Itoa(class.Season) + "/" + class.ClassName},
+		{"Matrícula", "Nome", "Nota", "Menção"},
+	}
+
+	for _, line := range sheet {
+
+		if line.Status == enrollment.Dropped {
+			continue
+		}
+
+		lines = append(lines, []interface{}{
+			line.Matricula,
+			strings.TrimSpace(line.FirstName + " " + line.LastName),
+			strings.Replace(strconv.FormatFloat(line.Result.Final, 'f', 1, 64), ".", ",", 1),
+			line.Result.Mention,
+		})
+	}
+
+	for _, line := range lines {
+		if err := writer.Write(records(line)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+
+	// Names with letters out of Windows-1252 get ? instead of failing the export
+	return encoding.ReplaceUnsupported(charmap.Windows1252.NewEncoder()).Bytes(buffer.Bytes())
+}
+
+// records return the cells of a row as text
+func records(row []interface{}) []string {
+
+	record := make([]string, len(row))
+
+	for i, cell := range row {
+		switch value := cell.(type) {
+		case string:
+			record[i] = value
+		case float64:
+			record[i] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+
+	return record
+}
+
+// numbered return the headers Name 1, Name 2... Name count
+func numbered(name string, count int) []interface{} {
+
+	headers := []interface{}{}
+
+	for i := 1; i <= count; i++ {
+		headers = append(headers, name+" "+strconv.Itoa(i))
+	}
+
+	return headers
+}
+
+// grades return count cells, the grades missing are left empty
+func grades(values []float64, count int) []interface{} {
+
+	cells := make([]interface{}, count)
+
+	for i := range cells {
+		if i < len(values) {
+			cells[i] = values[i]
+		} else {
+			cells[i] = ""
+		}
+	}
+
+	return cells
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
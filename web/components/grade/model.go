@@ -0,0 +1,93 @@
+package grade
+
+import (
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/listgrade"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// StudentGrade is a line of the class grade sheet
+type StudentGrade struct {
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	EnrollmentID primitive.ObjectID `bson:"enrollmentid,omitempty"`
+	Matricula    string             `json:"matricula"`
+	FirstName    string             `json:"firstname"`
+	LastName     string             `json:"lastname"`
+	Status       string             `json:"status"`
+	Grades       enrollment.Grades  `json:"grades"`
+	Projects     []float64          `json:"projects"`
+	Result       grading.Result     `json:"result"`
+}
+
+// Target is the exam or list a score file fills, Index starts at 1
+type Target struct {
+	Source string `json:"source"`
+	Index  int    `json:"index"`
+}
+
+// Change is the grade of a student before and after an import
+// Old is nil when the student had no grade for the exam or list yet
+type Change struct {
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	EnrollmentID primitive.ObjectID `bson:"enrollmentid,omitempty"`
+	Line         int                `json:"line"`
+	Matricula    string             `json:"matricula"`
+	FirstName    string             `json:"firstname"`
+	LastName     string             `json:"lastname"`
+	Old          *float64           `json:"old"`
+	New          float64            `json:"new"`
+	Action       string             `json:"action"`
+}
+
+// ImportReport is returned to the professor before (dry run) or after a score import
+// Nothing is applied while any row is invalid
+type ImportReport struct {
+	DryRun    bool               `json:"dryrun"`
+	Encoding  string             `json:"encoding"`
+	Target    Target             `json:"target"`
+	Total     int                `json:"total"`
+	Valid     int                `json:"valid"`
+	Invalid   int                `json:"invalid"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Rows      []roster.ScoreRow  `json:"rows"`
+	Changes   []Change           `json:"changes"`
+	AuditID   primitive.ObjectID `bson:"auditid,omitempty"`
+	// Partial is set when the import failed and Unrestored changes are still saved
+	Partial    bool     `json:"partial,omitempty"`
+	Unrestored []Change `json:"unrestored,omitempty"`
+}
+
+// ListGrade is the grade of a student in a list, computed from the problems solved in the contests of the list
+// Credit and Total are the weighted problems solved and the weighted problems of the contests
+// Old is the grade stored before and Origin where it came from, a grade typed before the history has no origin
+type ListGrade struct {
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	EnrollmentID primitive.ObjectID `bson:"enrollmentid,omitempty"`
+	Matricula    string             `json:"matricula"`
+	FirstName    string             `json:"firstname"`
+	LastName     string             `json:"lastname"`
+	List         int                `json:"list"`
+	ContestIDs   []int              `json:"contestids"`
+	Credit       float64            `json:"credit"`
+	Total        float64            `json:"total"`
+	Grade        float64            `json:"grade"`
+	Old          *float64           `json:"old"`
+	Origin       string             `json:"origin"`
+	Action       string             `json:"action"`
+}
+
+// ListReport is returned after (or before, on dry run) the list grades of a class are computed
+type ListReport struct {
+	DryRun     bool               `json:"dryrun"`
+	Force      bool               `json:"force"`
+	Curve      listgrade.Curve    `json:"curve"`
+	Updated    int                `json:"updated"`
+	Unchanged  int                `json:"unchanged"`
+	Overridden int                `json:"overridden"`
+	Conflicts  int                `json:"conflicts"`
+	Grades     []ListGrade        `json:"grades"`
+	AuditID    primitive.ObjectID `bson:"auditid,omitempty"`
+}
@@ -0,0 +1,350 @@
+package gradeHistory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/grading"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the index used by the timeline of a student
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{{"studentid", 1}, {"classid", 1}, {"createdat", 1}},
+	})
+
+	return err
+}
+
+// Diff return a change for every grade that is different in after, with the fields of base
+// Exams and lists are compared position by position
+// @param	before			grades before the change
+// @param	after			grades after the change
+// @param	base			enrollment, actor, origin and reason of the changes
+// @return 	[]Change		one change per grade
+func Diff(before, after enrollment.Grades, base Change) []Change {
+
+	var changes []Change
+
+	for _, source := range []string{grading.Exams, grading.Lists} {
+
+		old, current := before.Source(source), after.Source(source)
+
+		for i := 0; i < len(old) || i < len(current); i++ {
+
+			change := base
+			change.Source = source
+			change.Index = i + 1
+
+			if i < len(old) {
+				change.Old = &old[i]
+			}
+
+			if i < len(current) {
+				change.New = &current[i]
+			}
+
+			if change.Old != nil && change.New != nil && *change.Old == *change.New {
+				continue
+			}
+
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// Record appends the changes to the history
+// @param	db				pointer to database
+// @param	changes			grades that changed
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	error 			function error
+func Record(db *mongo.Client, changes []Change, databaseName, collectionName string) error {
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	var documents []interface{}
+
+	for i := range changes {
+		changes[i].ID = primitive.NewObjectID()
+		changes[i].CreatedAT = now
+		documents = append(documents, changes[i])
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.InsertMany(context.TODO(), documents)
+
+	return err
+}
+
+// Remove deletes changes recorded for grades that were then not saved
+// @param	db				pointer to database
+// @param	changes			changes returned by Record, with their ids
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	error 			function error
+func Remove(db *mongo.Client, changes []Change, databaseName, collectionName string) error {
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var ids []primitive.ObjectID
+
+	for _, change := range changes {
+		ids = append(ids, change.ID)
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.DeleteMany(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+
+	return err
+}
+
+// GetTimeline return every grade change of the student, oldest first
+// @param	db				pointer to database
+// @param	studentID		id of the student
+// @param	classID			id of the class, zero for every class
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	[]Change		grade changes of the student
+// @return 	error 			function error
+func GetTimeline(db *mongo.Client, studentID, classID primitive.ObjectID, databaseName, collectionName string) ([]Change, error) {
+
+	timeline := []Change{}
+
+	filter := bson.M{"studentid": studentID}
+
+	if !classID.IsZero() {
+		filter["classid"] = classID
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find().SetSort(bson.M{"createdat": 1}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Change
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		timeline = append(timeline, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return timeline, nil
+}
+
+// GetClassChanges return every change of the exams or lists of the class, oldest first
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	source			exams or lists
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	[]Change		grade changes of the class
+// @return 	error 			function error
+func GetClassChanges(db *mongo.Client, classID primitive.ObjectID, source, databaseName, collectionName string) ([]Change, error) {
+
+	changes := []Change{}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	// Changes recorded together share the time, the id keeps the order they were inserted
+	cursor, err := collection.Find(context.TODO(), bson.M{"classid": classID, "source": source}, options.Find().SetSort(bson.D{{"createdat", 1}, {"_id", 1}}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Change
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return changes, nil
+}
+
+// Origins return where the current value of each grade came from (manual, import or judge)
+// A revert gives back the value of the change before the one reverted, so it gets the origin of that change
+// Grades without history and grades removed aren't in the map
+// @param	changes			changes of the grades, oldest first
+// @return 	map				origin by grade
+func Origins(changes []Change) map[Grade]string {
+
+	byGrade := map[Grade][]Change{}
+
+	for _, change := range changes {
+		grade := Grade{EnrollmentID: change.EnrollmentID, Source: change.Source, Index: change.Index}
+		byGrade[grade] = append(byGrade[grade], change)
+	}
+
+	origins := map[Grade]string{}
+
+	for grade, timeline := range byGrade {
+		if last := len(timeline) - 1; timeline[last].New != nil {
+			if origin := originOf(timeline, last); origin != "" {
+				origins[grade] = origin
+			}
+		}
+	}
+
+	return origins
+}
+
+// originOf return the origin of the value set by the change at position i of the timeline of a grade
+func originOf(timeline []Change, i int) string {
+
+	// Each step goes back in the timeline, so it always ends
+	for i >= 0 && timeline[i].Origin == OriginRevert {
+
+		reverted := -1
+
+		for j := i - 1; j >= 0; j-- {
+			if timeline[j].ID == timeline[i].RevertOf {
+				reverted = j
+				break
+			}
+		}
+
+		i = reverted - 1
+	}
+
+	if i < 0 {
+		return ""
+	}
+
+	return timeline[i].Origin
+}
+
+// GetChange return a single change of the history
+// @param	db				pointer to database
+// @param	changeID		id of the change
+// @param	databaseName	name of database
+// @param	collectionName	name of grade history collection
+// @return 	Change			the change
+// @return 	error 			function error
+func GetChange(db *mongo.Client, changeID primitive.ObjectID, databaseName, collectionName string) (Change, error) {
+
+	var change Change
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": changeID}, options.FindOne()).Decode(&change)
+
+	return change, err
+}
+
+// Revert puts back the grade a change replaced, the revert is recorded as new changes
+// Only a grade still holding the value of the change can be reverted
+// A grade that didn't exist before can only be removed if it's the last exam or list
+// @param	db				pointer to database
+// @param	change			change to be reverted
+// @param	base			actor and reason of the revert
+// @param	databaseName	name of database
+// @return 	[]Change		changes recorded by the revert
+// @return 	error 			function error
+func Revert(db *mongo.Client, change Change, base Change, databaseName string) ([]Change, error) {
+
+	current, err := enrollment.GetEnrollment(db, change.EnrollmentID, databaseName, "enrollment")
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := current.Grades.Source(change.Source)
+
+	var now *float64
+
+	if change.Index <= len(values) {
+		now = &values[change.Index-1]
+	}
+
+	if (now == nil) != (change.New == nil) || (now != nil && *now != *change.New) {
+		return nil, errors.New("Grade changed after this change, revert the latest change first")
+	}
+
+	var reverted []float64
+
+	switch {
+	case change.Old != nil:
+		reverted = enrollment.WithGrade(values, change.Index, *change.Old)
+	case change.Index == len(values):
+		reverted = values[:change.Index-1]
+	default:
+		return nil, errors.New("Only the last exam or list can be removed")
+	}
+
+	base.EnrollmentID = current.ID
+	base.StudentID = current.StudentID
+	base.ClassID = current.ClassID
+	base.Origin = OriginRevert
+	base.RevertOf = change.ID
+
+	changes := Diff(current.Grades, current.Grades.With(change.Source, reverted), base)
+
+	// The history is written first, so a grade is never changed without it
+	if err = Record(db, changes, databaseName, "gradeHistory"); err != nil {
+		return nil, err
+	}
+
+	ok, err := enrollment.ReplaceGrades(db, current.ID, change.Source, values, reverted, databaseName, "enrollment")
+
+	if err == nil && !ok {
+		err = errors.New("Grade changed after this change, revert the latest change first")
+	}
+
+	if err != nil {
+		if removeErr := Remove(db, changes, databaseName, "gradeHistory"); removeErr != nil {
+			return nil, errors.New(err.Error() + ", the grade history of the revert couldn't be removed: " + removeErr.Error())
+		}
+		return nil, err
+	}
+
+	return changes, nil
+}
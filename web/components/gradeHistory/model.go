@@ -0,0 +1,47 @@
+package gradeHistory
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// Origin of a grade change
+const (
+	OriginManual = "manual"
+	OriginImport = "import"
+	OriginRevert = "revert"
+	OriginJudge  = "judge"
+)
+
+// Change is a single grade of a student that changed, entries are never updated or deleted
+// Old is nil when the grade didn't exist and New is nil when it was removed
+type Change struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	EnrollmentID primitive.ObjectID `bson:"enrollmentid,omitempty"`
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	ClassID      primitive.ObjectID `bson:"classid,omitempty"`
+	Source       string             `json:"source"`
+	Index        int                `json:"index"`
+	Old          *float64           `json:"old"`
+	New          *float64           `json:"new"`
+	ActorID      primitive.ObjectID `bson:"actorid,omitempty"`
+	Role         string             `json:"role"`
+	Origin       string             `json:"origin"`
+	Reason       string             `json:"reason"`
+	RevertOf     primitive.ObjectID `bson:"revertof,omitempty"`
+	CreatedAT    time.Time          `json:"createdat"`
+}
+
+// Grade is a single exam or list of an enrollment, Index starts at 1
+type Grade struct {
+	EnrollmentID primitive.ObjectID
+	Source       string
+	Index        int
+}
+
+// RevertRequest asks to undo a change, the reason is required
+type RevertRequest struct {
+	ChangeID primitive.ObjectID `bson:"changeid,omitempty"`
+	Reason   string             `json:"reason"`
+}
@@ -0,0 +1,244 @@
+package loginAttempt
+
+import (
+	"context"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the unique index of attempts and lets mongo delete old attempts
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of attempts collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"kind", 1}, {"key", 1}, {"collectionname", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{"expiresat", 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+
+	return err
+}
+
+// RetryAfter return how long the matricula and the ip must wait to try again
+// @param	db					pointer to database
+// @param	matricula			matricula being used to login
+// @param	ip					ip of the request
+// @param	loginCollectionName	student or admin
+// @param	databaseName		name of database
+// @param	collectionName		name of attempts collection
+// @return 	Duration			zero when login is allowed
+// @return 	error 				function error
+func RetryAfter(db *mongo.Client, matricula, ip, loginCollectionName, databaseName, collectionName string) (time.Duration, error) {
+
+	var retryAfter time.Duration
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	filter := bson.M{
+		"collectionname": loginCollectionName,
+		"lockeduntil":    bson.M{"$gt": time.Now()},
+		"$or": []bson.M{
+			{"kind": Matricula, "key": matricula},
+			{"kind": IP, "key": ip},
+		},
+	}
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find())
+
+	if err != nil {
+		return 0, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem LoginAttempt
+
+		if err := cursor.Decode(&elem); err != nil {
+			return 0, err
+		}
+
+		if wait := time.Until(elem.LockedUntil); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return retryAfter, nil
+}
+
+// RegisterFailure counts a wrong password for the matricula and the ip
+// @param	db					pointer to database
+// @param	matricula			matricula used to login
+// @param	ip					ip of the request
+// @param	loginCollectionName	student or admin
+// @param	databaseName		name of database
+// @param	collectionName		name of attempts collection
+// @return 	Duration			lockout applied after this failure, zero if none
+// @return 	error 				function error
+func RegisterFailure(db *mongo.Client, matricula, ip, loginCollectionName, databaseName, collectionName string) (time.Duration, error) {
+
+	var retryAfter time.Duration
+
+	attempts := []struct {
+		kind   string
+		key    string
+		policy Policy
+	}{
+		{Matricula, matricula, MatriculaPolicy},
+		{IP, ip, IPPolicy},
+	}
+
+	for _, attempt := range attempts {
+
+		lock, err := registerFailure(db, attempt.kind, attempt.key, attempt.policy, loginCollectionName, databaseName, collectionName)
+
+		if err != nil {
+			return 0, err
+		}
+
+		if lock > retryAfter {
+			retryAfter = lock
+		}
+	}
+
+	return retryAfter, nil
+}
+
+// RegisterSuccess forgets the failures of the matricula after a right password
+// @param	db					pointer to database
+// @param	matricula			matricula used to login
+// @param	loginCollectionName	student or admin
+// @param	databaseName		name of database
+// @param	collectionName		name of attempts collection
+// @return 	error 				function error
+func RegisterSuccess(db *mongo.Client, matricula, loginCollectionName, databaseName, collectionName string) error {
+	_, err := Unlock(db, UnlockRequest{Matricula: matricula, CollectionName: loginCollectionName}, databaseName, collectionName)
+	return err
+}
+
+// Unlock removes the lockout and the failures of an account
+// @param	db				pointer to database
+// @param	request			matricula and student or admin
+// @param	databaseName	name of database
+// @param	collectionName	name of attempts collection
+// @return 	bool			true if the account had failures
+// @return 	error 			function error
+func Unlock(db *mongo.Client, request UnlockRequest, databaseName, collectionName string) (bool, error) {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	filter := bson.M{
+		"kind":           Matricula,
+		"key":            request.Matricula,
+		"collectionname": request.CollectionName,
+	}
+
+	result, err := collection.DeleteOne(context.TODO(), filter)
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// LockDuration return the lockout after that amount of failures
+func (p Policy) LockDuration(failures int) time.Duration {
+
+	if failures < p.MaxFailures {
+		return 0
+	}
+
+	lock := p.BaseLock
+
+	for i := p.MaxFailures; i < failures && lock < p.MaxLock; i++ {
+		lock *= 2
+	}
+
+	if lock > p.MaxLock {
+		lock = p.MaxLock
+	}
+
+	return lock
+}
+
+func registerFailure(db *mongo.Client, kind, key string, policy Policy, loginCollectionName, databaseName, collectionName string) (time.Duration, error) {
+
+	var attempt LoginAttempt
+
+	collection := db.Database(databaseName).Collection(collectionName)
+	now := time.Now()
+
+	filter := bson.M{
+		"kind":           kind,
+		"key":            key,
+		"collectionname": loginCollectionName,
+	}
+
+	// Failures older than the window don't count anymore
+	expired := bson.M{
+		"kind":           kind,
+		"key":            key,
+		"collectionname": loginCollectionName,
+		"expiresat":      bson.M{"$lte": now},
+	}
+
+	if _, err := collection.DeleteOne(context.TODO(), expired); err != nil {
+		return 0, err
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"failures": 1},
+		"$set": bson.M{"lastfailure": now, "expiresat": now.Add(policy.Window)},
+	}
+
+	if err := collection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt); err != nil {
+		return 0, err
+	}
+
+	lock := policy.LockDuration(attempt.Failures)
+
+	if lock == 0 {
+		return 0, nil
+	}
+
+	lockedUntil := now.Add(lock)
+	expiresAT := now.Add(policy.Window)
+
+	if lockedUntil.After(expiresAT) {
+		expiresAT = lockedUntil
+	}
+
+	if _, err := collection.UpdateOne(
+		context.TODO(),
+		filter,
+		bson.M{"$set": bson.M{"lockeduntil": lockedUntil, "expiresat": expiresAT}},
+	); err != nil {
+		return 0, err
+	}
+
+	return lock, nil
+}
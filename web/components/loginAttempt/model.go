@@ -0,0 +1,57 @@
+package loginAttempt
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+const (
+	Matricula = "matricula"
+	IP        = "ip"
+)
+
+// LoginAttempt counts the failed logins of a matricula or of an IP
+type LoginAttempt struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	Kind           string             `json:"kind"`
+	Key            string             `json:"key"`
+	CollectionName string             `json:"collectionname"`
+	Failures       int                `json:"failures"`
+	LastFailure    time.Time          `json:"lastfailure"`
+	LockedUntil    time.Time          `json:"lockeduntil"`
+	ExpiresAT      time.Time          `json:"expiresat"`
+}
+
+// Policy tells how many failures are free and how long the lockout lasts after them
+// Every extra failure doubles the lockout until MaxLock
+type Policy struct {
+	MaxFailures int
+	BaseLock    time.Duration
+	MaxLock     time.Duration
+	Window      time.Duration
+}
+
+// UnlockRequest is the body sent by a professor to unlock an account
+type UnlockRequest struct {
+	Matricula      string `json:"matricula"`
+	CollectionName string `json:"collectionname"`
+}
+
+var (
+	// MatriculaPolicy locks a single account after a few wrong passwords
+	MatriculaPolicy = Policy{
+		MaxFailures: 5,
+		BaseLock:    30 * time.Second,
+		MaxLock:     time.Hour,
+		Window:      time.Hour,
+	}
+
+	// IPPolicy is looser because a whole lab logs in behind the same IP
+	IPPolicy = Policy{
+		MaxFailures: 50,
+		BaseLock:    time.Minute,
+		MaxLock:     time.Hour,
+		Window:      time.Hour,
+	}
+)
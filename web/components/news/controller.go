@@ -123,6 +123,19 @@ func CreateNews(db *mongo.Client, singleNews NewsCreate, databaseName, collectio
 
 }
 
+// GetSingleNews return the news with the given id
+func GetSingleNews(db *mongo.Client, newsID primitive.ObjectID, databaseName, collectionName string) (News, error) {
+
+	var found News
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": newsID}).Decode(&found)
+
+	return found, err
+}
+
+// UpdateNews updates the fields sent, the class of the news doesn't change
 func UpdateNews(db *mongo.Client, singleNews News, databaseName, collectionName string) error {
 
 	collection := db.Database(databaseName).Collection(collectionName)
@@ -134,10 +147,6 @@ func UpdateNews(db *mongo.Client, singleNews News, databaseName, collectionName
 
 	update := bson.M{}
 
-	if !singleNews.ClassID.IsZero() {
-		update["classid"] = singleNews.ClassID
-	}
-
 	if singleNews.Title != "" {
 		update["title"] = singleNews.Title
 	}
@@ -0,0 +1,184 @@
+package passwordReset
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/utils"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the index to find tokens and lets mongo delete expired tokens
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of reset tokens collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"token", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{"expiresat", 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+
+	return err
+}
+
+// CreateResetToken creates a reset token for the user with that matricula
+// Every older token of that user stops working
+// @param	db					pointer to database
+// @param	matricula			user matricula
+// @param	ttl					how long the token lives
+// @param	databaseName		name of database
+// @param	userCollectionName	name of user collection (student or admin)
+// @param	collectionName		name of reset tokens collection
+// @return 	Recipient			user that must receive the token
+// @return 	string				plain token, only its hash is stored
+// @return 	error 				function error
+func CreateResetToken(db *mongo.Client, matricula string, ttl time.Duration, databaseName, userCollectionName, collectionName string) (Recipient, string, error) {
+
+	var recipient Recipient
+	var credentials user.UserCredentials
+	var token string
+	var err error
+
+	collection := db.Database(databaseName).Collection(collectionName)
+	collectionUser := db.Database(databaseName).Collection(userCollectionName)
+	collectionLogin := db.Database(databaseName).Collection(userCollectionName + "_login")
+
+	if err = collectionLogin.FindOne(
+		context.TODO(),
+		bson.M{"matricula": matricula},
+		options.FindOne(),
+	).Decode(&credentials); err != nil {
+		return recipient, "", err
+	}
+
+	if err = collectionUser.FindOne(
+		context.TODO(),
+		bson.M{"_id": credentials.ID},
+		options.FindOne().SetProjection(bson.M{"_id": 1, "firstname": 1, "email": 1}),
+	).Decode(&recipient); err != nil {
+		return recipient, "", err
+	}
+
+	if recipient.Email == "" {
+		return recipient, "", errors.New("User without email")
+	}
+
+	if token, err = generateToken(); err != nil {
+		return recipient, "", err
+	}
+
+	if _, err = collection.UpdateMany(
+		context.TODO(),
+		bson.M{"userid": credentials.ID, "collectionname": userCollectionName, "used": false},
+		bson.M{"$set": bson.M{"used": true}},
+	); err != nil {
+		return recipient, "", err
+	}
+
+	resetToken := ResetToken{
+		UserID:         credentials.ID,
+		CollectionName: userCollectionName,
+		Token:          hashToken(token),
+		CreatedAT:      time.Now(),
+		ExpiresAT:      time.Now().Add(ttl),
+		Used:           false,
+	}
+
+	if _, err = collection.InsertOne(context.TODO(), resetToken); err != nil {
+		return recipient, "", err
+	}
+
+	return recipient, token, nil
+}
+
+// ResetPassword consumes a reset token and stores the new password
+// @param	db				pointer to database
+// @param	request			token and new password
+// @param	policy			password policy, the new password must follow it
+// @param	databaseName	name of database
+// @param	collectionName	name of reset tokens collection
+// @return 	ResetToken		consumed token, tells which user was updated
+// @return 	error 			function error
+func ResetPassword(db *mongo.Client, request ResetRequest, policy user.PasswordPolicy, databaseName, collectionName string) (ResetToken, error) {
+
+	var resetToken ResetToken
+	var password string
+	var err error
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	if request.Token == "" {
+		return resetToken, errors.New("Token can't be empty")
+	}
+
+	if err = policy.Check(request.NewPassword); err != nil {
+		return resetToken, err
+	}
+
+	if password, err = utils.HashAndSalt([]byte(request.NewPassword)); err != nil {
+		return resetToken, err
+	}
+
+	filter := bson.M{
+		"token":     hashToken(request.Token),
+		"used":      false,
+		"expiresat": bson.M{"$gt": time.Now()},
+	}
+
+	if err = collection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		bson.M{"$set": bson.M{"used": true}},
+	).Decode(&resetToken); err != nil {
+		return resetToken, err
+	}
+
+	collectionLogin := db.Database(databaseName).Collection(resetToken.CollectionName + "_login")
+
+	if _, err = collectionLogin.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": resetToken.UserID},
+		bson.M{"$set": bson.M{"password": password, "mustchangepassword": false}},
+	); err != nil {
+		return resetToken, err
+	}
+
+	return resetToken, nil
+}
+
+// generateToken return a random url safe token
+func generateToken() (string, error) {
+
+	buffer := make([]byte, 32)
+
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// hashToken return the sha256 of the token, so the database never keeps plain tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
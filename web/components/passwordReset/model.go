@@ -0,0 +1,36 @@
+package passwordReset
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// ResetToken is a single use token that allows to change a forgotten password
+type ResetToken struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `bson:"userid,omitempty"`
+	CollectionName string             `json:"collectionname"`
+	Token          string             `json:"-"`
+	CreatedAT      time.Time          `json:"createdat"`
+	ExpiresAT      time.Time          `json:"expiresat"`
+	Used           bool               `json:"used"`
+}
+
+// ForgotRequest is the body sent by who forgot the password
+type ForgotRequest struct {
+	Matricula string `json:"matricula"`
+}
+
+// ResetRequest is the body sent with the token received by email
+type ResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newpassword"`
+}
+
+// Recipient is who receives the reset email
+type Recipient struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	FirstName string             `json:"firstname"`
+	Email     string             `json:"email"`
+}
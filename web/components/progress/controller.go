@@ -0,0 +1,730 @@
+package progress
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/components/submission"
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/apc-unb/apc-api/web/judge"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateIndexes creates the index that keeps a single snapshot per student and contest of a class
+// and the one used to find the last sync
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of progress collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"classid", 1}, {"studentid", 1}, {"contestid", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{"syncedat", -1}},
+		},
+	})
+
+	return err
+}
+
+// Run syncs every class right away and then once per interval, until ctx is cancelled
+// Errors are logged and the next round tries again
+// @param	ctx				stops the worker
+// @param	db				pointer to database
+// @param	judges			judges of the classes, they must respect their rate limits
+// @param	interval		time between two rounds
+// @param	databaseName	name of database
+func Run(ctx context.Context, db *mongo.Client, judges *judge.Registry, interval time.Duration, databaseName string) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+
+		start := time.Now()
+
+		if synced, err := SyncAll(ctx, db, judges, databaseName); err != nil {
+			logrus.Errorf("Judge sync finished with errors, %d classes synced: %s", synced, err.Error())
+		} else {
+			logrus.Infof("Judge sync of %d classes took %s", synced, time.Since(start))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll syncs the contests of every class with the judge of the class, the submissions and then the standings
+// A class that fails doesn't stop the others, the last error is returned
+// @param	ctx				cancels the sync
+// @param	db				pointer to database
+// @param	judges			judges of the classes
+// @param	databaseName	name of database
+// @return 	int				number of classes synced
+// @return 	error 			function error
+func SyncAll(ctx context.Context, db *mongo.Client, judges *judge.Registry, databaseName string) (int, error) {
+
+	classes, err := schoolClass.GetClasses(db, databaseName, "schoolClass")
+
+	if err != nil {
+		return 0, err
+	}
+
+	var failed error
+
+	synced := 0
+
+	for _, class := range classes {
+
+		if ctx.Err() != nil {
+			return synced, ctx.Err()
+		}
+
+		contests, err := contest.GetClassContests(db, class.ID, databaseName, "contest")
+
+		if err == nil && len(contests) == 0 {
+			continue
+		}
+
+		var provider judge.Provider
+
+		if err == nil {
+			provider, err = judges.For(class.Judge)
+		}
+
+		// Submissions first, the deadlines of the contests are checked with them
+		// When they fail the standings are still read, with the submissions read before
+		if err == nil {
+			if _, submissionErr := submission.SyncClass(ctx, db, provider, class.ID, contest.IDs(contests), databaseName); submissionErr != nil {
+				logrus.Warnf("Not able to sync the submissions of class %s: %s", class.ID.Hex(), submissionErr.Error())
+			}
+		}
+
+		if err == nil {
+			err = SyncClass(ctx, db, provider, class, contests, databaseName)
+		}
+
+		if err != nil {
+			logrus.Errorf("Not able to sync class %s: %s", class.ID.Hex(), err.Error())
+			failed = err
+			continue
+		}
+
+		synced++
+	}
+
+	return synced, failed
+}
+
+// SyncClass reads the standings of every contest of the class and stores the result of each active student with a handle
+// Contests with a deadline only count what their late policy allows, the submissions must be synced before
+// @param	ctx				cancels the sync
+// @param	db				pointer to database
+// @param	provider		judge of the class
+// @param	class			the class
+// @param	contests		contests of the class
+// @param	databaseName	name of database
+// @return 	error 			function error
+func SyncClass(ctx context.Context, db *mongo.Client, provider judge.Provider, class schoolClass.SchoolClass, contests []contest.Contest, databaseName string) error {
+
+	enrollments, err := enrollment.GetClassEnrollments(db, class.ID, databaseName, "enrollment")
+
+	if err != nil {
+		return err
+	}
+
+	handles := Handles(enrollments)
+
+	if len(handles) == 0 {
+		return nil
+	}
+
+	for _, current := range contests {
+
+		standings, err := provider.GetContestStandings(ctx, current.ContestID, handles)
+
+		if err != nil {
+			return err
+		}
+
+		snapshots := Snapshots(class.ID, current.ContestID, enrollments, standings, time.Now())
+
+		// With a deadline the problems are counted from the stored submissions, that have the time they were solved
+		if policy := current.Deadline(); !policy.Deadline.IsZero() {
+
+			solved, err := submission.FirstAccepted(db, class.ID, current.ContestID, databaseName, "submission")
+
+			if err != nil {
+				return err
+			}
+
+			snapshots = ApplyPolicy(snapshots, policy, solved)
+		}
+
+		if err = Save(db, snapshots, databaseName, "progress"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handles return the judge handles of the active enrollments, without repetition
+// @param	enrollments		enrollments of the class
+// @return 	[]string		handles
+func Handles(enrollments []enrollment.Enrollment) []string {
+
+	var handles []string
+
+	seen := map[string]bool{}
+
+	for _, current := range enrollments {
+
+		handle := strings.ToLower(current.Handles.Codeforces)
+
+		if current.Status != enrollment.Active || handle == "" || seen[handle] {
+			continue
+		}
+
+		seen[handle] = true
+		handles = append(handles, current.Handles.Codeforces)
+	}
+
+	return handles
+}
+
+// Solved return how many problems of the contest each handle solved, handles in lower case
+// A problem counts once even if solved in more than one row (official, unofficial, practice)
+// @param	standings		standings of the contest
+// @return 	map[string]int	problems solved by handle
+func Solved(standings judge.Standings) map[string]int {
+
+	solved := map[string]int{}
+
+	for handle, problems := range SolvedProblems(standings) {
+		solved[handle] = len(problems)
+	}
+
+	return solved
+}
+
+// SolvedProblems return the indexes of the problems of the contest each handle solved, handles in lower case
+// @param	standings		standings of the contest
+// @return 	map				problems solved by handle
+func SolvedProblems(standings judge.Standings) map[string][]string {
+
+	indexes := Problems(standings)
+	tasks := map[string][]bool{}
+	solved := map[string][]string{}
+
+	for _, row := range standings.Rows {
+		for _, member := range row.Handles {
+
+			handle := strings.ToLower(member)
+
+			if tasks[handle] == nil {
+				tasks[handle] = make([]bool, len(standings.Problems))
+			}
+
+			for k, points := range row.Points {
+				if k < len(tasks[handle]) && points > 0 && !tasks[handle][k] {
+					tasks[handle][k] = true
+					solved[handle] = append(solved[handle], indexes[k])
+				}
+			}
+		}
+	}
+
+	return solved
+}
+
+// Problems return the indexes of the problems of the contest in upper case
+// Problems the judge didn't index are lettered by their position
+// @param	standings		standings of the contest
+// @return 	[]string		indexes of the problems
+func Problems(standings judge.Standings) []string {
+
+	indexes := make([]string, len(standings.Problems))
+
+	for k, problem := range standings.Problems {
+		if problem.Index != "" {
+			indexes[k] = strings.ToUpper(problem.Index)
+		} else {
+			indexes[k] = string(rune('A' + k))
+		}
+	}
+
+	return indexes
+}
+
+// Snapshots return the result of every active student with a handle in the contest
+// Students missing from the standings didn't solve anything
+// @param	classID			id of the class
+// @param	contestID		id of the contest
+// @param	enrollments		enrollments of the class
+// @param	standings		standings of the contest
+// @param	syncedAT		time the standings were read
+// @return 	[]Snapshot		one snapshot per student
+func Snapshots(classID primitive.ObjectID, contestID int, enrollments []enrollment.Enrollment, standings judge.Standings, syncedAT time.Time) []Snapshot {
+
+	var snapshots []Snapshot
+
+	problems := Problems(standings)
+	solved := SolvedProblems(standings)
+
+	for _, current := range enrollments {
+
+		if current.Status != enrollment.Active || current.Handles.Codeforces == "" {
+			continue
+		}
+
+		credits := map[string]float64{}
+
+		for _, problem := range solved[strings.ToLower(current.Handles.Codeforces)] {
+			credits[problem] = 1
+		}
+
+		done := len(credits)
+
+		snapshots = append(snapshots, Snapshot{
+			ClassID:     classID,
+			StudentID:   current.StudentID,
+			ContestID:   contestID,
+			ContestName: standings.Name,
+			Handle:      current.Handles.Codeforces,
+			Done:        done,
+			Total:       len(standings.Problems),
+			OnTime:      done,
+			Credit:      float64(done),
+			Problems:    problems,
+			Solved:      credits,
+			SyncedAT:    syncedAT,
+		})
+	}
+
+	return snapshots
+}
+
+// ApplyPolicy counts the problems of each snapshot from the time they were first solved
+// Done are the problems still worth something, credit is what they are worth after the penalties
+// Solved keeps the credit of each problem still worth something
+// @param	snapshots		results of the students in the contest
+// @param	policy			deadline of the contest
+// @param	solved			time of the first accepted submission by student and problem
+// @return 	[]Snapshot		the snapshots with the problems counted by the policy
+func ApplyPolicy(snapshots []Snapshot, policy deadline.Policy, solved map[primitive.ObjectID]map[string]time.Time) []Snapshot {
+
+	for i, snapshot := range snapshots {
+
+		result := policy.Apply(solved[snapshot.StudentID])
+
+		snapshots[i].Done = result.Counted
+		snapshots[i].OnTime = result.OnTime
+		snapshots[i].Late = result.Late
+		snapshots[i].Credit = result.Credit
+		snapshots[i].Solved = map[string]float64{}
+
+		for problem, solvedAT := range solved[snapshot.StudentID] {
+			if credit := policy.Credit(solvedAT); credit > 0 {
+				snapshots[i].Solved[problem] = credit
+			}
+		}
+	}
+
+	return snapshots
+}
+
+// Save replaces the snapshots of the same student and contest
+// @param	db				pointer to database
+// @param	snapshots		results read from Codeforces
+// @param	databaseName	name of database
+// @param	collectionName	name of progress collection
+// @return 	error 			function error
+func Save(db *mongo.Client, snapshots []Snapshot, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, snapshot := range snapshots {
+
+		filter := bson.M{
+			"classid":   snapshot.ClassID,
+			"studentid": snapshot.StudentID,
+			"contestid": snapshot.ContestID,
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"contestname": snapshot.ContestName,
+				"handle":      snapshot.Handle,
+				"done":        snapshot.Done,
+				"total":       snapshot.Total,
+				"ontime":      snapshot.OnTime,
+				"late":        snapshot.Late,
+				"credit":      snapshot.Credit,
+				"problems":    snapshot.Problems,
+				"solved":      snapshot.Solved,
+				"syncedat":    snapshot.SyncedAT,
+			},
+		}
+
+		if _, err := collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserProgress return how many problems of the class contests the student solved, from the last sync
+// @param	db				pointer to database
+// @param	provider		judge of the class
+// @param	class			class with its contests
+// @param	studentID		id of the student
+// @param	handle			current Codeforces handle of the student
+// @param	databaseName	name of database
+// @return 	Summary			problems solved and total, with the time of the oldest snapshot
+// @return 	error 			function error
+func GetUserProgress(db *mongo.Client, provider judge.Provider, class schoolClass.SchoolClass, studentID primitive.ObjectID, handle, databaseName string) (Summary, error) {
+
+	contests, err := contest.GetClassContests(db, class.ID, databaseName, "contest")
+
+	if err != nil {
+		return Summary{}, err
+	}
+
+	snapshots, err := find(db, bson.M{"classid": class.ID, "studentid": studentID}, databaseName, "progress")
+
+	if err != nil {
+		return Summary{}, err
+	}
+
+	return Summarize(Contests(class, contests, provider, handle, snapshots)), nil
+}
+
+// GetIndividualUserProgress return the progress of the student in each contest of the class, from the last sync
+// @param	db				pointer to database
+// @param	provider		judge of the class
+// @param	class			class with its contests
+// @param	studentID		id of the student
+// @param	handle			current Codeforces handle of the student
+// @param	databaseName	name of database
+// @return 	[]Contest		progress by contest, in the order of the class
+// @return 	error 			function error
+func GetIndividualUserProgress(db *mongo.Client, provider judge.Provider, class schoolClass.SchoolClass, studentID primitive.ObjectID, handle, databaseName string) ([]Contest, error) {
+
+	contests, err := contest.GetClassContests(db, class.ID, databaseName, "contest")
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := find(db, bson.M{"classid": class.ID, "studentid": studentID}, databaseName, "progress")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Contests(class, contests, provider, handle, snapshots), nil
+}
+
+// GetClassProgress return the progress of every active student of the class, from the last sync
+// @param	db				pointer to database
+// @param	provider		judge of the class
+// @param	class			class with its contests
+// @param	databaseName	name of database
+// @return 	[]Report		progress by student, with the solves on time and late
+// @return 	error 			function error
+func GetClassProgress(db *mongo.Client, provider judge.Provider, class schoolClass.SchoolClass, databaseName string) ([]Report, error) {
+
+	enrollments, err := enrollment.GetClassEnrollments(db, class.ID, databaseName, "enrollment")
+
+	if err != nil {
+		return nil, err
+	}
+
+	contests, err := contest.GetClassContests(db, class.ID, databaseName, "contest")
+
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := GetClassSnapshots(db, class.ID, databaseName, "progress")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return Reports(class, contests, provider, enrollments, snapshots), nil
+}
+
+// GetClassSnapshots return the result of every student of the class in every contest, from the last sync
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	databaseName	name of database
+// @param	collectionName	name of progress collection
+// @return 	[]Snapshot		results of the students
+// @return 	error 			function error
+func GetClassSnapshots(db *mongo.Client, classID primitive.ObjectID, databaseName, collectionName string) ([]Snapshot, error) {
+	return find(db, bson.M{"classid": classID}, databaseName, collectionName)
+}
+
+// Reports return the progress of each active student of the class
+// @param	class			the class
+// @param	contests		contests of the class
+// @param	provider		judge of the class, it gives the url of the contests
+// @param	enrollments		enrollments of the class
+// @param	snapshots		snapshots of the class
+// @return 	[]Report		progress by student
+func Reports(class schoolClass.SchoolClass, contests []contest.Contest, provider judge.Provider, enrollments []enrollment.Enrollment, snapshots []Snapshot) []Report {
+
+	byStudent := map[primitive.ObjectID][]Snapshot{}
+
+	for _, snapshot := range snapshots {
+		byStudent[snapshot.StudentID] = append(byStudent[snapshot.StudentID], snapshot)
+	}
+
+	reports := []Report{}
+
+	for _, current := range enrollments {
+
+		if current.Status != enrollment.Active {
+			continue
+		}
+
+		progress := Contests(class, contests, provider, current.Handles.Codeforces, byStudent[current.StudentID])
+
+		reports = append(reports, Report{
+			StudentID: current.StudentID,
+			Matricula: current.Matricula,
+			Handle:    current.Handles.Codeforces,
+			Summary:   Summarize(progress),
+			Contests:  progress,
+		})
+	}
+
+	return reports
+}
+
+// Contests return the progress in each contest of the class, in the order of the lists
+// Snapshots of another handle are ignored, the student changed it after the last sync
+// Contests not synced yet have nothing done and no sync time
+// @param	class			the class
+// @param	contests		contests of the class
+// @param	provider		judge of the class, it gives the url of the contests
+// @param	handle			current Codeforces handle of the student
+// @param	snapshots		snapshots of the student
+// @return 	[]Contest		progress by contest
+func Contests(class schoolClass.SchoolClass, contests []contest.Contest, provider judge.Provider, handle string, snapshots []Snapshot) []Contest {
+
+	byContest := map[int]Snapshot{}
+
+	for _, snapshot := range snapshots {
+		if strings.EqualFold(snapshot.Handle, handle) {
+			byContest[snapshot.ContestID] = snapshot
+		}
+	}
+
+	progress := []Contest{}
+
+	for _, current := range contests {
+
+		policy := current.Deadline()
+
+		result := Contest{
+			ContestID: current.ContestID,
+			Name:      current.Name,
+			List:      current.List,
+			Weight:    current.Weight,
+			URL:       provider.ContestURL(class.GroupID, current.ContestID),
+			Policy:    policy.Kind,
+		}
+
+		if !current.OpensAT.IsZero() {
+			opensAT := current.OpensAT
+			result.OpensAT = &opensAT
+		}
+
+		if !policy.Deadline.IsZero() {
+			due := policy.Deadline
+			result.Deadline = &due
+		}
+
+		if snapshot, ok := byContest[current.ContestID]; ok {
+			syncedAT := snapshot.SyncedAT
+			if result.Name == "" {
+				result.Name = snapshot.ContestName
+			}
+			result.Done = snapshot.Done
+			result.Total = snapshot.Total
+			result.OnTime = snapshot.OnTime
+			result.Late = snapshot.Late
+			result.Credit = snapshot.Credit
+			result.SyncedAT = &syncedAT
+		}
+
+		progress = append(progress, result)
+	}
+
+	return progress
+}
+
+// Summarize adds the progress of the contests
+// The sync time is the oldest one, every contest is at least that recent
+// @param	contests		progress by contest
+// @return 	Summary			problems solved and total
+func Summarize(contests []Contest) Summary {
+
+	var done, total, onTime, late int
+	var syncedAT *time.Time
+
+	for _, contest := range contests {
+
+		done += contest.Done
+		total += contest.Total
+		onTime += contest.OnTime
+		late += contest.Late
+
+		if contest.SyncedAT != nil && (syncedAT == nil || contest.SyncedAT.Before(*syncedAT)) {
+			syncedAT = contest.SyncedAT
+		}
+	}
+
+	return Summary{
+		Done:     strconv.Itoa(done),
+		Total:    strconv.Itoa(total),
+		OnTime:   onTime,
+		Late:     late,
+		SyncedAT: syncedAT,
+	}
+}
+
+// Mark sets the status of a summary that doesn't have one yet
+// Progress never synced is unavailable, progress older than staleAfter or while the judge is failing is stale
+// @param	summary			progress of the student
+// @param	staleAfter		age after which the progress is stale, 0 never gets old
+// @param	judgeUp			tells if the judge of the class is answering
+// @param	now				current time
+// @return 	Summary			the summary with its status
+func Mark(summary Summary, staleAfter time.Duration, judgeUp bool, now time.Time) Summary {
+
+	if summary.Status != "" {
+		return summary
+	}
+
+	switch {
+	case summary.SyncedAT == nil:
+		summary.Status = Unavailable
+	case staleAfter > 0 && now.Sub(*summary.SyncedAT) > staleAfter:
+		summary.Status = Stale
+	case !judgeUp:
+		summary.Status = Stale
+	default:
+		summary.Status = Fresh
+	}
+
+	return summary
+}
+
+// LastSync return the time of the most recent snapshot, nil before the first sync
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of progress collection
+// @return 	*time.Time		time of the last sync
+// @return 	error 			function error
+func LastSync(db *mongo.Client, databaseName, collectionName string) (*time.Time, error) {
+
+	var snapshot Snapshot
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{}, options.FindOne().SetSort(bson.D{{"syncedat", -1}})).Decode(&snapshot)
+
+	if err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &snapshot.SyncedAT, nil
+}
+
+// NewMemory return an empty memory
+func NewMemory() *Memory {
+	return &Memory{values: map[string]Summary{}}
+}
+
+// Remember keeps the summary of the student in the class
+func (m *Memory) Remember(classID, studentID primitive.ObjectID, summary Summary) {
+	m.mutex.Lock()
+	m.values[classID.Hex()+studentID.Hex()] = summary
+	m.mutex.Unlock()
+}
+
+// Recall return the last summary of the student in the class marked as stale, or an unavailable one
+func (m *Memory) Recall(classID, studentID primitive.ObjectID) Summary {
+
+	m.mutex.Lock()
+	summary, ok := m.values[classID.Hex()+studentID.Hex()]
+	m.mutex.Unlock()
+
+	if !ok {
+		return Summary{Done: "0", Total: "0", Status: Unavailable}
+	}
+
+	summary.Status = Stale
+
+	return summary
+}
+
+// find return the snapshots that match the filter
+func find(db *mongo.Client, filter bson.M, databaseName, collectionName string) ([]Snapshot, error) {
+
+	var snapshots []Snapshot
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Snapshot
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return snapshots, nil
+}
@@ -0,0 +1,77 @@
+package progress
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+const (
+	Fresh       = "fresh"
+	Stale       = "stale"
+	Unavailable = "unavailable"
+)
+
+// Snapshot is the result of a student in a contest of the class, read from Codeforces by the sync
+type Snapshot struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	ClassID     primitive.ObjectID `bson:"classid,omitempty"`
+	StudentID   primitive.ObjectID `bson:"studentid,omitempty"`
+	ContestID   int                `json:"contestid"`
+	ContestName string             `json:"contestname"`
+	Handle      string             `json:"handle"`
+	Done        int                `json:"done"`
+	Total       int                `json:"total"`
+	OnTime      int                `json:"ontime"`
+	Late        int                `json:"late"`
+	Credit      float64            `json:"credit"`
+	// Problems are the indexes of the problems of the contest, Solved the credit of each problem solved
+	Problems []string           `json:"problems"`
+	Solved   map[string]float64 `json:"solved"`
+	SyncedAT time.Time          `json:"syncedat"`
+}
+
+// Summary is the progress of a student in every contest of the class, sent at login
+// Done and total are strings like they always were for the front-end
+type Summary struct {
+	Done     string     `json:"done"`
+	Total    string     `json:"total"`
+	OnTime   int        `json:"ontime"`
+	Late     int        `json:"late"`
+	SyncedAT *time.Time `json:"syncedat"`
+	Status   string     `json:"status"`
+}
+
+// Memory keeps the last summary read of each student, served when the snapshots can't be read
+type Memory struct {
+	mutex  sync.Mutex
+	values map[string]Summary
+}
+
+// Contest is the progress of a student in a single contest
+type Contest struct {
+	ContestID int        `json:"contestid"`
+	Name      string     `json:"name"`
+	List      int        `json:"list"`
+	Weight    float64    `json:"weight"`
+	URL       string     `json:"url"`
+	Done      int        `json:"done"`
+	Total     int        `json:"total"`
+	OnTime    int        `json:"ontime"`
+	Late      int        `json:"late"`
+	Credit    float64    `json:"credit"`
+	OpensAT   *time.Time `json:"opensat"`
+	Deadline  *time.Time `json:"deadline"`
+	Policy    string     `json:"policy"`
+	SyncedAT  *time.Time `json:"syncedat"`
+}
+
+// Report is the progress of a student of the class, shown to the professor
+type Report struct {
+	StudentID primitive.ObjectID `bson:"studentid,omitempty"`
+	Matricula string             `json:"matricula"`
+	Handle    string             `json:"handle"`
+	Summary   Summary            `json:"summary"`
+	Contests  []Contest          `json:"contests"`
+}
@@ -58,6 +58,26 @@ func GetProjects(db *mongo.Client, studentID primitive.ObjectID, databaseName, c
 	return studentProjects, nil
 }
 
+func GetProject(db *mongo.Client, projectID primitive.ObjectID, databaseName, collectionName string) (Project, error) {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	projectDAO := Project{}
+
+	filter := bson.M{
+		"_id": projectID,
+	}
+
+	if err := collection.FindOne(
+		context.TODO(),
+		filter,
+	).Decode(&projectDAO); err != nil {
+		return projectDAO, err
+	}
+
+	return projectDAO, nil
+}
+
 func CreateProject(db *mongo.Client, projectInfo Project, databaseName string) (interface{}, error) {
 
 	//////////////////////////////////////////
@@ -261,6 +281,22 @@ func GetProjectsType (db *mongo.Client, databaseName, collectionName string) ([]
 	return types, nil
 }
 
+func GetProjectType(db *mongo.Client, projectTypeID primitive.ObjectID, databaseName, collectionName string) (ProjectType, error) {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	projectTypeDAO := ProjectType{}
+
+	if err := collection.FindOne(
+		context.TODO(),
+		bson.M{"_id": projectTypeID},
+	).Decode(&projectTypeDAO); err != nil {
+		return projectTypeDAO, err
+	}
+
+	return projectTypeDAO, nil
+}
+
 func CreateProjectType(db *mongo.Client, projectTypeDAO ProjectType, databaseName, collectionName string) error {
 
 	collection := db.Database(databaseName).Collection(collectionName)
@@ -8,21 +8,36 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
-func CreateClasses(db *mongo.Client, schoolClass []SchoolClassCreate, database_name, collection_name string) error {
+func CreateClasses(db *mongo.Client, schoolClass []SchoolClassCreate, database_name, collection_name string) ([]primitive.ObjectID, error) {
+
+	var ids []primitive.ObjectID
 
 	if len(schoolClass) == 0 {
-		return nil
+		return ids, nil
+	}
+
+	for _, class := range schoolClass {
+		if err := class.Grading.Validate(); err != nil {
+			return ids, err
+		}
+		if err := class.ListCurve.Validate(); err != nil {
+			return ids, err
+		}
 	}
 
 	collection := db.Database(database_name).Collection(collection_name)
 
 	for _, class := range schoolClass {
-		if _, err := collection.InsertOne(context.TODO(), class); err != nil {
-			return err
+		result, err := collection.InsertOne(context.TODO(), class)
+
+		if err != nil {
+			return ids, err
 		}
+
+		ids = append(ids, result.InsertedID.(primitive.ObjectID))
 	}
 
-	return nil
+	return ids, nil
 
 }
 
@@ -174,6 +189,10 @@ func UpdateClass(db *mongo.Client, classDAO SchoolClass, database_name, collecti
 		update["groupid"] = classDAO.GroupID
 	}
 
+	if classDAO.Judge != "" {
+		update["judge"] = classDAO.Judge
+	}
+
 	if classDAO.Address != "" {
 		update["address"] = classDAO.Address
 	}
@@ -186,6 +205,20 @@ func UpdateClass(db *mongo.Client, classDAO SchoolClass, database_name, collecti
 		update["season"] = classDAO.Season
 	}
 
+	if !classDAO.Grading.Empty() {
+		if err := classDAO.Grading.Validate(); err != nil {
+			return err
+		}
+		update["grading"] = classDAO.Grading
+	}
+
+	if !classDAO.ListCurve.Empty() {
+		if err := classDAO.ListCurve.Validate(); err != nil {
+			return err
+		}
+		update["listcurve"] = classDAO.ListCurve
+	}
+
 	updateSet := bson.M{"$set": update}
 
 	if _, err := collection.UpdateOne(context.TODO(), filter, updateSet, nil); err != nil {
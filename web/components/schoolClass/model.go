@@ -1,6 +1,8 @@
 package schoolClass
 
 import (
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/listgrade"
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
@@ -15,6 +17,9 @@ type SchoolClass struct {
 	Season             int                `json:"season"`
 	ContestsIDs        []int 			  `json:"contestsids"`
 	GroupID 		   string   	      `json:"groupid"`
+	Judge              string             `json:"judge"`
+	Grading            grading.Scheme     `json:"grading"`
+	ListCurve          listgrade.Curve    `json:"listcurve"`
 }
 
 type SchoolClassCreate struct {
@@ -27,4 +32,7 @@ type SchoolClassCreate struct {
 	Season           		int 		   `json:"season"`
 	ContestsIDs        		[]int		   `json:"contestsids"`
 	GroupID 		  		string   	   `json:"groupid"`
+	Judge                   string         `json:"judge"`
+	Grading                 grading.Scheme `json:"grading"`
+	ListCurve               listgrade.Curve `json:"listcurve"`
 }
\ No newline at end of file
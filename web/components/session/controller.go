@@ -0,0 +1,301 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// CreateIndexes creates the indexes used to find sessions by refresh token
+// and to let mongo delete expired sessions and revoked tokens by itself
+// @param	db						pointer to database
+// @param	databaseName			name of database
+// @param	collectionName			name of sessions collection
+// @param	revokedCollectionName	name of revoked tokens collection
+// @return 	error 					function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName, revokedCollectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+	collectionRevoked := db.Database(databaseName).Collection(revokedCollectionName)
+
+	if _, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"refreshtoken", 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{"userid", 1}},
+		},
+		{
+			Keys:    bson.D{{"expiresat", 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}); err != nil {
+		return err
+	}
+
+	if _, err := collectionRevoked.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{"expiresat", 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateSession stores a new session of an authenticated user
+// @param	db				pointer to database
+// @param	sessionDAO		user data that will be kept in the session
+// @param	ttl				how long the refresh token lives
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	Session			created session
+// @return 	string			plain refresh token, only the hash is stored
+// @return 	error 			function error
+func CreateSession(db *mongo.Client, sessionDAO Session, ttl time.Duration, databaseName, collectionName string) (Session, string, error) {
+
+	var refreshToken string
+	var err error
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	if refreshToken, err = generateRefreshToken(); err != nil {
+		return sessionDAO, "", err
+	}
+
+	sessionDAO.ID = primitive.NewObjectID()
+	sessionDAO.RefreshToken = hashToken(refreshToken)
+	sessionDAO.CreatedAT = time.Now()
+	sessionDAO.RefreshedAT = sessionDAO.CreatedAT
+	sessionDAO.ExpiresAT = sessionDAO.CreatedAT.Add(ttl)
+	sessionDAO.Revoked = false
+
+	if _, err = collection.InsertOne(context.TODO(), sessionDAO); err != nil {
+		return sessionDAO, "", err
+	}
+
+	return sessionDAO, refreshToken, nil
+}
+
+// RefreshSession exchanges a valid refresh token for a new one
+// The old refresh token can't be used again
+// @param	db				pointer to database
+// @param	refreshToken	plain refresh token sent by the user
+// @param	ttl				how long the new refresh token lives
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	Session			refreshed session
+// @return 	string			new plain refresh token
+// @return 	error 			function error
+func RefreshSession(db *mongo.Client, refreshToken string, ttl time.Duration, databaseName, collectionName string) (Session, string, error) {
+
+	var sessionDAO Session
+	var newRefreshToken string
+	var err error
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	if refreshToken == "" {
+		return sessionDAO, "", errors.New("Refresh token can't be empty")
+	}
+
+	if newRefreshToken, err = generateRefreshToken(); err != nil {
+		return sessionDAO, "", err
+	}
+
+	now := time.Now()
+
+	filter := bson.M{
+		"refreshtoken": hashToken(refreshToken),
+		"revoked":      false,
+		"expiresat":    bson.M{"$gt": now},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"refreshtoken": hashToken(newRefreshToken),
+			"refreshedat":  now,
+			"expiresat":    now.Add(ttl),
+		},
+	}
+
+	if err = collection.FindOneAndUpdate(
+		context.TODO(),
+		filter,
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&sessionDAO); err != nil {
+		return sessionDAO, "", err
+	}
+
+	return sessionDAO, newRefreshToken, nil
+}
+
+// RevokeSession logs out a single session and its current access token
+// @param	db						pointer to database
+// @param	userID					owner of the session
+// @param	sessionID				session to be revoked
+// @param	tokenID					jti of the access token used to logout
+// @param	tokenExpiresAT			expiration of that access token
+// @param	databaseName			name of database
+// @param	collectionName			name of sessions collection
+// @param	revokedCollectionName	name of revoked tokens collection
+// @return 	error 					function error
+func RevokeSession(db *mongo.Client, userID, sessionID primitive.ObjectID, tokenID string, tokenExpiresAT time.Time, databaseName, collectionName, revokedCollectionName string) error {
+
+	var sessionDAO Session
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	filter := bson.M{
+		"_id":    sessionID,
+		"userid": userID,
+	}
+
+	update := bson.M{"$set": bson.M{"revoked": true}}
+
+	if err := collection.FindOneAndUpdate(context.TODO(), filter, update).Decode(&sessionDAO); err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	revoked := []RevokedToken{
+		{ID: tokenID, UserID: userID, ExpiresAT: tokenExpiresAT},
+	}
+
+	if !sessionDAO.ID.IsZero() {
+		revoked = append(revoked, RevokedToken{ID: sessionDAO.ID.Hex(), UserID: userID, ExpiresAT: sessionDAO.ExpiresAT})
+	}
+
+	return revokeTokens(db, revoked, databaseName, revokedCollectionName)
+}
+
+// RevokeUserSessions logs out every session of a user
+// @param	db						pointer to database
+// @param	userID					owner of the sessions
+// @param	databaseName			name of database
+// @param	collectionName			name of sessions collection
+// @param	revokedCollectionName	name of revoked tokens collection
+// @return 	int						amount of sessions revoked
+// @return 	error 					function error
+func RevokeUserSessions(db *mongo.Client, userID primitive.ObjectID, databaseName, collectionName, revokedCollectionName string) (int, error) {
+
+	var revoked []RevokedToken
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	filter := bson.M{
+		"userid":    userID,
+		"revoked":   false,
+		"expiresat": bson.M{"$gt": time.Now()},
+	}
+
+	cursor, err := collection.Find(context.TODO(), filter, options.Find())
+
+	if err != nil {
+		return 0, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Session
+
+		if err := cursor.Decode(&elem); err != nil {
+			return 0, err
+		}
+
+		revoked = append(revoked, RevokedToken{ID: elem.ID.Hex(), UserID: userID, ExpiresAT: elem.ExpiresAT})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	cursor.Close(context.TODO())
+
+	if _, err := collection.UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{"revoked": true}}); err != nil {
+		return 0, err
+	}
+
+	if err := revokeTokens(db, revoked, databaseName, revokedCollectionName); err != nil {
+		return 0, err
+	}
+
+	return len(revoked), nil
+}
+
+// IsRevoked return true if the token id or its session were revoked
+// @param	db						pointer to database
+// @param	tokenID					jti of the access token
+// @param	sessionID				session that issued the token
+// @param	databaseName			name of database
+// @param	revokedCollectionName	name of revoked tokens collection
+// @return 	bool					revoked veredict
+// @return 	error 					function error
+func IsRevoked(db *mongo.Client, tokenID, sessionID string, databaseName, revokedCollectionName string) (bool, error) {
+
+	collection := db.Database(databaseName).Collection(revokedCollectionName)
+
+	ids := []string{tokenID}
+
+	if sessionID != "" {
+		ids = append(ids, sessionID)
+	}
+
+	count, err := collection.CountDocuments(context.TODO(), bson.M{"_id": bson.M{"$in": ids}})
+
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// revokeTokens inserts ids in the revoked collection ignoring the ones already there
+func revokeTokens(db *mongo.Client, revoked []RevokedToken, databaseName, revokedCollectionName string) error {
+
+	collection := db.Database(databaseName).Collection(revokedCollectionName)
+
+	for _, token := range revoked {
+
+		if token.ID == "" {
+			continue
+		}
+
+		filter := bson.M{"_id": token.ID}
+		update := bson.M{"$set": bson.M{"userid": token.UserID, "expiresat": token.ExpiresAT}}
+
+		if _, err := collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateRefreshToken return a random url safe token
+func generateRefreshToken() (string, error) {
+
+	buffer := make([]byte, 32)
+
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+// hashToken return the sha256 of the token, so the database never keeps plain tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+// Session is created on every login and keeps the refresh token of that device
+type Session struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	UserID       primitive.ObjectID `bson:"userid,omitempty"`
+	ClassID      primitive.ObjectID `bson:"classid,omitempty"`
+	Role         string             `json:"role"`
+	Matricula    string             `json:"matricula"`
+	RefreshToken string             `json:"-"`
+	CreatedAT    time.Time          `json:"createdat"`
+	RefreshedAT  time.Time          `json:"refreshedat"`
+	ExpiresAT    time.Time          `json:"expiresat"`
+	Revoked      bool               `json:"revoked"`
+}
+
+// RevokedToken is a token id (jti) or a session id that can't be used anymore
+type RevokedToken struct {
+	ID        string             `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userid,omitempty"`
+	ExpiresAT time.Time          `json:"expiresat"`
+}
+
+// RefreshRequest is the body sent to get a new pair of tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshtoken"`
+}
+
+// TokenPair is returned after login and refresh
+type TokenPair struct {
+	Jwt          string `json:"jwt"`
+	RefreshToken string `json:"refreshtoken"`
+}
@@ -3,14 +3,16 @@ package student
 import (
 	"context"
 	"errors"
-	"math/rand"
 	"strconv"
 	"strings"
 
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/session"
 	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/roster"
 	"github.com/apc-unb/apc-api/web/utils"
 
-	"github.com/togatoga/goforces"
+	"github.com/apc-unb/apc-api/web/judge"
 
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/mongodb/mongo-go-driver/mongo"
@@ -20,18 +22,23 @@ import (
 
 // CreateStudents recieve a list of students
 // Checks if that list is not null (can't insert null list)
-// Insert each student individually in database
+// A matricula is a single person with a single login, so matriculas already registered are only
+// enrolled in the class and keep their login, new matriculas are inserted and enrolled in their class
 // @param	db				pointer to database
+// @param	provider		judge of the class
 // @param	students 		list of students
+// @param	policy			how the generated passwords look like
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
+// @return 	[]UserCredentials	plain passwords of the new matriculas, they are not stored and can't be read again
 // @return 	error 			function error
 // TODO : Insert all students at the same time (if possible)
-func CreateStudents(db *mongo.Client, api *goforces.Client, students []StudentCreate, databaseName, collectionName string) ([]user.UserCredentials, error) {
+func CreateStudents(db *mongo.Client, provider judge.Provider, students []StudentCreate, policy user.PasswordPolicy, databaseName, collectionName string) ([]user.UserCredentials, error) {
 
 	var studentsReturn []user.UserCredentials
-	var singlestudent user.UserCredentials
-	var pwd string
+	var registered []Student
+	var login, plain user.UserCredentials
+	var id primitive.ObjectID
 	var err error
 	var mongoReturn *mongo.InsertOneResult
 
@@ -42,88 +49,549 @@ func CreateStudents(db *mongo.Client, api *goforces.Client, students []StudentCr
 	collection := db.Database(databaseName).Collection(collectionName)
 	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
 
+	matriculas := make([]string, 0, len(students))
+
 	for _, student := range students {
+		matriculas = append(matriculas, student.Matricula)
+	}
+
+	if registered, err = findStudents(db, bson.M{"matricula": bson.M{"$in": matriculas}}, databaseName, collectionName); err != nil {
+		return nil, err
+	}
 
-		pwd = generateRandomPassword()
+	byMatricula := map[string]Student{}
 
-		if singlestudent.Password, err = utils.HashAndSalt([]byte(pwd)); err != nil {
-			return nil, err
+	for _, current := range registered {
+		byMatricula[current.Matricula] = current
+	}
+
+	for _, student := range students {
+
+		if current, ok := byMatricula[student.Matricula]; ok {
+
+			if student.ClassID.IsZero() {
+				continue
+			}
+
+			handles := enrollment.Handles(current.Handles)
+
+			if student.Handles.Codeforces != "" {
+				handles.Codeforces = student.Handles.Codeforces
+			}
+
+			if student.Handles.Uri != "" {
+				handles.Uri = student.Handles.Uri
+			}
+
+			if _, err = enrollment.Enroll(db, enrollment.Enrollment{
+				StudentID: current.ID,
+				ClassID:   student.ClassID,
+				Matricula: current.Matricula,
+				Grades:    enrollment.Grades(student.Grades),
+				Handles:   handles,
+			}, databaseName, "enrollment"); err != nil {
+				return studentsReturn, err
+			}
+
+			if _, err = collection.UpdateOne(context.TODO(), bson.M{"_id": current.ID}, bson.M{"$set": bson.M{"classid": student.ClassID}}); err != nil {
+				return studentsReturn, err
+			}
+
+			continue
 		}
 
 		if mongoReturn, err = collection.InsertOne(context.TODO(), student); err != nil {
 			return studentsReturn, err
 		} else {
-			singlestudent.ID = mongoReturn.InsertedID.(primitive.ObjectID)
+			id = mongoReturn.InsertedID.(primitive.ObjectID)
 		}
 
-		singlestudent.Matricula = student.Matricula
+		if login, plain, err = user.NewCredentials(id, student.Matricula, policy); err == nil {
+			_, err = collectionLogin.InsertOne(context.TODO(), login)
+		}
 
-		if _, err = collectionLogin.InsertOne(context.TODO(), singlestudent); err != nil {
-			return nil, err
+		// A student without login can't be used and would block the matricula
+		if err != nil {
+			collection.DeleteOne(context.TODO(), bson.M{"_id": id})
+			return studentsReturn, err
 		}
 
-		studentsReturn = append(studentsReturn, singlestudent)
+		byMatricula[student.Matricula] = Student{ID: id, Matricula: student.Matricula, Handles: student.Handles}
+
+		if !student.ClassID.IsZero() {
+			if _, err = enrollment.Enroll(db, enrollment.Enrollment{
+				StudentID: id,
+				ClassID:   student.ClassID,
+				Matricula: student.Matricula,
+				Grades:    enrollment.Grades(student.Grades),
+				Handles:   enrollment.Handles(student.Handles),
+			}, databaseName, "enrollment"); err != nil {
+				return studentsReturn, err
+			}
+		}
+
+		studentsReturn = append(studentsReturn, plain)
 
 	}
 
 	return studentsReturn, nil
 }
 
-// CreateStudentsFile recieve csv file of students
-// Call function that parse that file and return list o students
-// Checks if that list is not null (can't insert null list)
-// Insert each student individually in database
+// CreateIndexes creates the unique indexes that keep roster imports idempotent
+// A matricula is a single person with a single login, enrolled in many classes
 // @param	db				pointer to database
-// @param	request 		byte array file
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
 // @return 	error 			function error
-// TODO : Insert all students at the same time (if possible)
-func CreateStudentsFile(db *mongo.Client, request string, databaseName, collectionName string) ([]user.UserCredentials, error) {
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
+
+	if _, err := collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{"matricula", 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+
+	_, err := collectionLogin.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.D{{"matricula", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+
+	return err
+}
+
+// CreateStudentsFile syncs the class with a parsed roster (CSV or XLSX) of students
+// New matriculas are created, registered people are enrolled in the class or updated
+// and the enrolled students missing from the roster are reported as removed
+// Uploading the same roster again changes nothing
+// Nothing is written on dry run or if any row is invalid, the report tells what is wrong
+// @param	db				pointer to database
+// @param	file 			parsed roster
+// @param	classID			class of the students, taken from the roster YEAR/SEASON/CLASS line if nil
+// @param	importOptions	dry run and drop of missing students
+// @param	policy			how the generated passwords look like
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	Report			row by row validation and action
+// @return 	[]UserCredentials	plain passwords of created students, they are not stored and can't be read again
+// @return 	error 			function error
+func CreateStudentsFile(db *mongo.Client, file roster.Roster, classID primitive.ObjectID, importOptions roster.Options, policy user.PasswordPolicy, databaseName, collectionName string) (roster.Report, []user.UserCredentials, error) {
 
 	var studentsReturn []user.UserCredentials
-	var singlestudent user.UserCredentials
-	var pwd string
-	var err error
-	var mongoReturn *mongo.InsertOneResult
 	var students []StudentCreate
+	var enrollments []enrollment.Enrollment
+	var registered, enrolledStudents []Student
+	var classEnrollments []enrollment.Enrollment
+	var err error
 
-	if students, err = getStudentsFromFile(db, request); err != nil {
-		return nil, err
+	if classID.IsZero() {
+		if classID, err = getClassID(db, file.Class, databaseName, "schoolClass"); err != nil {
+			return file.Report(importOptions.DryRun), nil, err
+		}
 	}
 
-	if len(students) == 0 {
-		return nil, nil
+	report := file.Report(importOptions.DryRun)
+
+	if report.Invalid > 0 {
+		return report, nil, nil
+	}
+
+	if registered, err = findStudents(db, bson.M{"matricula": bson.M{"$in": file.Matriculas()}}, databaseName, collectionName); err != nil {
+		return report, nil, err
+	}
+
+	if classEnrollments, err = enrollment.GetClassEnrollments(db, classID, databaseName, "enrollment"); err != nil {
+		return report, nil, err
+	}
+
+	byMatricula := map[string]Student{}
+	enrolled := map[primitive.ObjectID]enrollment.Enrollment{}
+
+	for _, current := range registered {
+		byMatricula[current.Matricula] = current
+	}
+
+	for _, current := range classEnrollments {
+		enrolled[current.StudentID] = current
+	}
+
+	updates := map[primitive.ObjectID]bson.M{}
+	inRoster := map[primitive.ObjectID]bool{}
+
+	for i, row := range report.Rows {
+
+		current, ok := byMatricula[row.Matricula]
+
+		if !ok {
+			report.Rows[i].Action = roster.ActionCreated
+			students = append(students, StudentCreate{
+				ClassID:   classID,
+				FirstName: row.FirstName,
+				LastName:  row.LastName,
+				Matricula: row.Matricula,
+				Email:     row.Email,
+				Handles:   StudentHandles{Codeforces: row.Codeforces},
+			})
+			continue
+		}
+
+		report.Rows[i].ID = current.ID
+		inRoster[current.ID] = true
+
+		set := rosterChanges(current, row)
+
+		if classEnrollment, ok := enrolled[current.ID]; !ok || classEnrollment.Status == enrollment.Dropped {
+
+			report.Rows[i].Action = roster.ActionEnrolled
+
+			handles := enrollment.Handles(current.Handles)
+
+			if row.Codeforces != "" {
+				handles.Codeforces = row.Codeforces
+			}
+
+			enrollments = append(enrollments, enrollment.Enrollment{
+				StudentID: current.ID,
+				ClassID:   classID,
+				Matricula: current.Matricula,
+				Handles:   handles,
+			})
+
+			set["classid"] = classID
+
+		} else if len(set) > 0 {
+			report.Rows[i].Action = roster.ActionUpdated
+		} else {
+			report.Rows[i].Action = roster.ActionUnchanged
+		}
+
+		if len(set) > 0 {
+			updates[current.ID] = set
+		}
+	}
+
+	var removed, removedStudents []primitive.ObjectID
+
+	for _, current := range classEnrollments {
+		if current.Status == enrollment.Active && !inRoster[current.StudentID] {
+			removed = append(removed, current.ID)
+			removedStudents = append(removedStudents, current.StudentID)
+		}
+	}
+
+	if len(removedStudents) > 0 {
+		if enrolledStudents, err = findStudents(db, bson.M{"_id": bson.M{"$in": removedStudents}}, databaseName, collectionName); err != nil {
+			return report, nil, err
+		}
+	}
+
+	for _, current := range enrolledStudents {
+		report.Removed = append(report.Removed, roster.Row{
+			ID:        current.ID,
+			Matricula: current.Matricula,
+			FirstName: current.FirstName,
+			LastName:  current.LastName,
+			Email:     current.Email,
+			Action:    roster.ActionRemoved,
+		})
+	}
+
+	report.CountActions()
+	report.Deactivated = importOptions.DeactivateMissing && len(removed) > 0
+
+	if importOptions.DryRun {
+		return report, nil, nil
 	}
 
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for id, set := range updates {
+
+		if _, err = collection.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+			return report, nil, err
+		}
+
+		if handle, ok := set["handles.codeforces"].(string); ok {
+			if err = enrollment.UpdateHandles(db, id, enrollment.Handles{Codeforces: handle}, databaseName, "enrollment"); err != nil {
+				return report, nil, err
+			}
+		}
+	}
+
+	for _, data := range enrollments {
+		if _, err = enrollment.Enroll(db, data, databaseName, "enrollment"); err != nil {
+			return report, nil, err
+		}
+	}
+
+	if report.Deactivated {
+		if err = enrollment.Drop(db, removed, databaseName, "enrollment"); err != nil {
+			return report, nil, err
+		}
+	}
+
+	if studentsReturn, err = CreateStudents(db, nil, students, policy, databaseName, collectionName); err != nil {
+		return report, studentsReturn, err
+	}
+
+	for i := range report.Rows {
+		for _, created := range studentsReturn {
+			if report.Rows[i].Matricula == created.Matricula {
+				report.Rows[i].ID = created.ID
+			}
+		}
+	}
+
+	return report, studentsReturn, nil
+}
+
+// MigrateEnrollments enrolls the students registered before enrollments existed in their class
+// Students already enrolled are left untouched, so it is safe to run on every start
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	int64			number of enrollments created
+// @return 	error 			function error
+func MigrateEnrollments(db *mongo.Client, databaseName, collectionName string) (int64, error) {
+
+	// Inactive was how a student left a class before enrollments
+	type legacyStudent struct {
+		Student  `bson:",inline"`
+		Inactive bool `json:"inactive"`
+	}
+
+	var enrollments []enrollment.Enrollment
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), bson.M{"classid": bson.M{"$exists": true}}, options.Find())
+
+	if err != nil {
+		return 0, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem legacyStudent
+
+		if err := cursor.Decode(&elem); err != nil {
+			return 0, err
+		}
+
+		if elem.ClassID.IsZero() {
+			continue
+		}
+
+		status := enrollment.Active
+
+		if elem.Inactive {
+			status = enrollment.Dropped
+		}
+
+		enrollments = append(enrollments, enrollment.Enrollment{
+			StudentID: elem.ID,
+			ClassID:   elem.ClassID,
+			Matricula: elem.Matricula,
+			Grades:    enrollment.Grades(elem.Grades),
+			Handles:   enrollment.Handles(elem.Handles),
+			Status:    status,
+		})
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return enrollment.Backfill(db, enrollments, databaseName, "enrollment")
+}
+
+// MergeDuplicates merges the students registered more than once with the same matricula, one per class
+// before enrollments existed, so each matricula is a single person. It must run before CreateIndexes
+// Enrollments, login, projects and submissions of the duplicates move to the kept student and the
+// empty email, photo and handles of the kept student are filled. Progress is read again by the next sync
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of collection
+// @return 	int				number of duplicates merged
+// @return 	error 			function error
+func MergeDuplicates(db *mongo.Client, databaseName, collectionName string) (int, error) {
+
 	collection := db.Database(databaseName).Collection(collectionName)
 	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
 
-	for _, student := range students {
+	students, err := findStudents(db, bson.M{}, databaseName, collectionName)
 
-		pwd = generateRandomPassword()
+	if err != nil {
+		return 0, err
+	}
 
-		if singlestudent.Password, err = utils.HashAndSalt([]byte(pwd)); err != nil {
-			return nil, err
+	logins := map[primitive.ObjectID]user.UserCredentials{}
+	changed := map[primitive.ObjectID]bool{}
+
+	cursor, err := collectionLogin.Find(context.TODO(), bson.M{}, options.Find())
+
+	if err != nil {
+		return 0, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem user.UserCredentials
+
+		if err := cursor.Decode(&elem); err != nil {
+			return 0, err
 		}
 
-		if mongoReturn, err = collection.InsertOne(context.TODO(), student); err != nil {
-			return studentsReturn, err
-		} else {
-			singlestudent.ID = mongoReturn.InsertedID.(primitive.ObjectID)
+		logins[elem.ID] = elem
+		changed[elem.ID] = !elem.MustChangePassword
+	}
+
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	cursor.Close(context.TODO())
+
+	byID := map[primitive.ObjectID]Student{}
+
+	for _, current := range students {
+		byID[current.ID] = current
+	}
+
+	keepers := Duplicates(students, changed)
+
+	merged := 0
+
+	for _, duplicate := range students {
+
+		keeperID, ok := keepers[duplicate.ID]
+
+		if !ok {
+			continue
 		}
 
-		singlestudent.Matricula = student.Matricula
+		if err = enrollment.Move(db, duplicate.ID, keeperID, databaseName, "enrollment"); err != nil {
+			return merged, err
+		}
 
-		if _, err = collectionLogin.InsertOne(context.TODO(), singlestudent); err != nil {
-			return nil, err
+		// The kept student keeps his password, or gets the one of the duplicate when he has none
+		if login, found := logins[duplicate.ID]; found {
+
+			if _, err = collectionLogin.DeleteOne(context.TODO(), bson.M{"_id": duplicate.ID}); err != nil {
+				return merged, err
+			}
+
+			if _, kept := logins[keeperID]; !kept {
+
+				login.ID = keeperID
+
+				if _, err = collectionLogin.InsertOne(context.TODO(), login); err != nil {
+					return merged, err
+				}
+
+				logins[keeperID] = login
+			}
+		}
+
+		keeper := byID[keeperID]
+		update := bson.M{}
+
+		if keeper.Email == "" && duplicate.Email != "" {
+			keeper.Email = duplicate.Email
+			update["email"] = duplicate.Email
+		}
+
+		if keeper.PhotoURL == "" && duplicate.PhotoURL != "" {
+			keeper.PhotoURL = duplicate.PhotoURL
+			update["photourl"] = duplicate.PhotoURL
+		}
+
+		if keeper.Handles.Codeforces == "" && duplicate.Handles.Codeforces != "" {
+			keeper.Handles.Codeforces = duplicate.Handles.Codeforces
+			update["handles.codeforces"] = duplicate.Handles.Codeforces
+		}
+
+		if keeper.Handles.Uri == "" && duplicate.Handles.Uri != "" {
+			keeper.Handles.Uri = duplicate.Handles.Uri
+			update["handles.uri"] = duplicate.Handles.Uri
+		}
+
+		byID[keeperID] = keeper
+
+		if len(update) > 0 {
+			if _, err = collection.UpdateOne(context.TODO(), bson.M{"_id": keeperID}, bson.M{"$set": update}); err != nil {
+				return merged, err
+			}
+		}
+
+		moved := bson.M{"$set": bson.M{"studentid": keeperID}}
+
+		if _, err = db.Database(databaseName).Collection("projects").UpdateMany(context.TODO(), bson.M{"studentid": duplicate.ID}, moved); err != nil {
+			return merged, err
+		}
+
+		if _, err = db.Database(databaseName).Collection("submission").UpdateMany(context.TODO(), bson.M{"studentid": duplicate.ID}, moved); err != nil {
+			return merged, err
+		}
+
+		if _, err = db.Database(databaseName).Collection("progress").DeleteMany(context.TODO(), bson.M{"studentid": duplicate.ID}); err != nil {
+			return merged, err
 		}
 
-		studentsReturn = append(studentsReturn, singlestudent)
+		if _, err = collection.DeleteOne(context.TODO(), bson.M{"_id": duplicate.ID}); err != nil {
+			return merged, err
+		}
 
+		merged++
 	}
 
-	return studentsReturn, nil
+	return merged, nil
+}
+
+// Duplicates picks the student kept for each matricula registered more than once
+// The one that already changed the password is kept, the oldest otherwise
+// @param	students		registered students
+// @param	changed			students whose login already changed the password
+// @return 	map				kept student of each duplicate, students not duplicated are left out
+func Duplicates(students []Student, changed map[primitive.ObjectID]bool) map[primitive.ObjectID]primitive.ObjectID {
+
+	kept := map[string]Student{}
+
+	for _, current := range students {
+
+		best, found := kept[current.Matricula]
+
+		if !found {
+			kept[current.Matricula] = current
+			continue
+		}
+
+		if changed[current.ID] != changed[best.ID] {
+			if changed[current.ID] {
+				kept[current.Matricula] = current
+			}
+			continue
+		}
+
+		if current.ID.Hex() < best.ID.Hex() {
+			kept[current.Matricula] = current
+		}
+	}
+
+	keepers := map[primitive.ObjectID]primitive.ObjectID{}
+
+	for _, current := range students {
+		if keeper := kept[current.Matricula]; keeper.ID != current.ID {
+			keepers[current.ID] = keeper.ID
+		}
+	}
+
+	return keepers
 }
 
 // GetStudents return list of all students from Database
@@ -178,9 +646,8 @@ func GetStudents(db *mongo.Client, databaseName, collectionName string) ([]Stude
 	return students, nil
 }
 
-// GetStudentsClass return list of all students from Database thata matchs with a certain class
-// Get all students at the same time and store inside cursor
-// Decode each student inside student class and append into students array
+// GetStudentsClass return list of all students enrolled in a certain class
+// Grades and status come from the enrollment in that class
 // @param	db				pointer to database
 // @param   classID         ID of the current class
 // @param	databaseName	name of database
@@ -189,50 +656,38 @@ func GetStudents(db *mongo.Client, databaseName, collectionName string) ([]Stude
 // @return 	error 			function error
 func GetStudentsClass(db *mongo.Client, classID primitive.ObjectID, databaseName, collectionName string) ([]Student, error) {
 
-	collection := db.Database(databaseName).Collection(collectionName)
-
-	// Here's an array in which you can store the decoded documents
-	students := []Student{}
-
-	var options options.FindOptions
-
-	options.SetSort(bson.D{{"firstname", 1}, {"lastname", 1}})
-
-	// Passing bson.D{{}} as the filter matches all documents in the collection
-	cursor, err := collection.Find(
-		context.TODO(),
-		bson.M{
-			"classid": classID,
-		},
-		&options,
-	)
+	classEnrollments, err := enrollment.GetClassEnrollments(db, classID, databaseName, "enrollment")
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Finding multiple documents returns a cursor
-	// Iterating through the cursor allows us to decode documents one at a time
-	for cursor.Next(context.TODO()) {
-
-		// create a value into which the single document can be decoded
-		var elem Student
+	studentIDs := []primitive.ObjectID{}
+	enrolled := map[primitive.ObjectID]enrollment.Enrollment{}
 
-		// Checks if decoding method didn't return any errors
-		if err := cursor.Decode(&elem); err != nil {
-			return nil, err
-		}
+	for _, current := range classEnrollments {
+		studentIDs = append(studentIDs, current.StudentID)
+		enrolled[current.StudentID] = current
+	}
 
-		// Push school class inside student array
-		students = append(students, elem)
+	if len(studentIDs) == 0 {
+		return []Student{}, nil
 	}
 
-	if err := cursor.Err(); err != nil {
+	students, err := findStudents(db, bson.M{"_id": bson.M{"$in": studentIDs}}, databaseName, collectionName)
+
+	if err != nil {
 		return nil, err
 	}
 
-	// Close the cursor once finished
-	cursor.Close(context.TODO())
+	for i := range students {
+		current := enrolled[students[i].ID]
+		students[i].ClassID = classID
+		students[i].Grades = StudentGrades(current.Grades)
+		students[i].Status = current.Status
+		students[i].Final = current.Result.Final
+		students[i].Mention = current.Result.Mention
+	}
 
 	return students, nil
 
@@ -261,13 +716,15 @@ func GetStudent(db *mongo.Client, studentID primitive.ObjectID, databaseName, co
 // UpdateStudents recieve student (updated)
 // Checks if student old password matches with db to update that student password or email
 // @param	db				pointer to database (updated)
+// @param	provider		judge of the class, it gives the avatar of a new handle
 // @param	students 		list of students
+// @param	policy			password policy, a new password must follow it
 // @param	databaseName	name of database
 // @param	collectionName	name of collection
 // @return 	StudentUpdate	student new data
 // @return 	error 			function error
 // TODO : Update all students at the same time (if possible)
-func UpdateStudents(db *mongo.Client, api *goforces.Client, student StudentUpdate, databaseName, collectionName string) error {
+func UpdateStudents(db *mongo.Client, provider judge.Provider, student StudentUpdate, policy user.PasswordPolicy, databaseName, collectionName string) error {
 
 	var err error
 	collection := db.Database(databaseName).Collection(collectionName)
@@ -294,11 +751,15 @@ func UpdateStudents(db *mongo.Client, api *goforces.Client, student StudentUpdat
 	}
 
 	if student.NewPassword != "" {
+		if err = policy.Check(student.NewPassword); err != nil {
+			return err
+		}
+
 		if student.NewPassword, err = utils.HashAndSalt([]byte(student.NewPassword)); err != nil {
 			return err
 		}
 
-		updateSet := bson.M{"$set": bson.M{"password": student.NewPassword}}
+		updateSet := bson.M{"$set": bson.M{"password": student.NewPassword, "mustchangepassword": false}}
 
 		if _, err := collectionLogin.UpdateOne(context.TODO(), filter, updateSet, nil); err != nil {
 			return err
@@ -328,7 +789,7 @@ func UpdateStudents(db *mongo.Client, api *goforces.Client, student StudentUpdat
 		if currentStudent.Handles.Codeforces != "" {
 			return errors.New("Trying to update handle that already exist")
 		} else {
-			update["photourl"] = getCodeforcesAvatarURL(student.Handles.Codeforces, api)
+			update["photourl"] = GetAvatarURL(student.Handles.Codeforces, provider)
 			update["handles.codeforces"] = student.Handles.Codeforces
 		}
 	}
@@ -347,12 +808,13 @@ func UpdateStudents(db *mongo.Client, api *goforces.Client, student StudentUpdat
 		return err
 	}
 
-	return nil
+	return enrollment.UpdateHandles(db, student.ID, enrollment.Handles(student.Handles), databaseName, "enrollment")
 }
 
 // DeleteStudents recieve a list of students (to be deleted)
 // Checks if that list is not null (can't delete null list)
-// Delete each student individually
+// Delete each student individually with his login and enrollments and logs out his sessions
+// so the matricula can be registered again and old refresh tokens stop working
 // @param	db				pointer to database (to be deleted)
 // @param	students 		list of students
 // @param	databaseName	name of database
@@ -367,12 +829,22 @@ func DeleteStudents(db *mongo.Client, students []Student, databaseName, collecti
 	}
 
 	collection := db.Database(databaseName).Collection(collectionName)
+	collectionLogin := db.Database(databaseName).Collection(collectionName + "_login")
 
 	for _, student := range students {
 		filter := bson.M{"_id": student.ID}
 		if _, err := collection.DeleteOne(context.TODO(), filter); err != nil {
 			return err
 		}
+		if _, err := collectionLogin.DeleteOne(context.TODO(), filter); err != nil {
+			return err
+		}
+		if err := enrollment.DeleteStudentEnrollments(db, student.ID, databaseName, "enrollment"); err != nil {
+			return err
+		}
+		if _, err := session.RevokeUserSessions(db, student.ID, databaseName, "session", "revoked_token"); err != nil {
+			return err
+		}
 	}
 	return nil
 
@@ -417,22 +889,24 @@ func AuthStudent(db *mongo.Client, student user.UserCredentials, databaseName, c
 		return findStudent, err
 	}
 
+	findStudent.MustChangePassword = studentData.MustChangePassword
+
 	return findStudent, nil
 }
 
-// getCodeforcesAvatarURL recieve handle string
+// GetAvatarURL recieve handle string
 // Return handle avatar url if exist
-// @param	handle			student codeforces handle
-// @param	api				pointer to goforces client
+// @param	handle			student handle in the judge
+// @param	provider		judge of the class
 // @return 	string 			avatar url
-func getCodeforcesAvatarURL(handle string, api *goforces.Client) string {
+func GetAvatarURL(handle string, provider judge.Provider) string {
 
 	ctx := context.Background()
 
 	var userAvatarURL string
 
-	if handlesArray, err := api.GetUserInfo(ctx, []string{handle}); err == nil {
-		userAvatarURL = "https:" + handlesArray[0].Avatar
+	if user, err := provider.GetUser(ctx, handle); err == nil {
+		userAvatarURL = user.Avatar
 	}
 
 	return userAvatarURL
@@ -485,150 +959,64 @@ func getClassID(db *mongo.Client, classData []string, databaseName, collectionNa
 	return classID.ID, nil
 }
 
-// generateRandomPassword return
-// Return current class id
-// @param	db				pointer to database (to be deleted)
-// @param	classData		year, season and class name
-// @param	databaseName	name of database
-// @param	collectionName	name of collection
-// @return 	[]ObjectID		class id
-// @return 	error 			function error
-func generateRandomPassword() string {
-
-	letters := "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	numbers := "0123456789"
-	var password string
-
-	for i := 0; i < 3; i++ {
-		password += string(letters[rand.Intn(25)])
-	}
-	for i := 0; i < 3; i++ {
-		password += string(numbers[rand.Intn(9)])
-	}
-
-	return password
+// findStudents return the students that match filter
+func findStudents(db *mongo.Client, filter bson.M, databaseName, collectionName string) ([]Student, error) {
 
-}
-
-func getStudentsFromFile(db *mongo.Client, request string) ([]StudentCreate, error) {
-
-	var total []string
-	var students []StudentCreate
-	var classID primitive.ObjectID
-	var err error
+	students := []Student{}
 
-	partial := strings.Split(request, ",")
-	total = append(total, strings.Split(partial[1], "\n")[1])
-	classData := strings.Split(strings.Split(partial[1], "\n")[0], "/")
+	collection := db.Database(databaseName).Collection(collectionName)
 
-	if classID, err = getClassID(db, classData, "apc_database", "schoolClass"); err != nil {
-		return students, err
-	}
+	cursor, err := collection.Find(
+		context.TODO(),
+		filter,
+		options.Find().SetSort(bson.D{{"firstname", 1}, {"lastname", 1}}),
+	)
 
-	for i := 2; i < len(partial); i++ {
-		aux := strings.Split(partial[i], "\n")
-		total = append(total, aux[0])
-		total = append(total, aux[1])
+	if err != nil {
+		return nil, err
 	}
-	total = total[:len(total)-1]
-
-	for i := 0; i < len(total); i += 2 {
 
-		names := strings.SplitAfterN(total[i+1], " ", 2)
+	for cursor.Next(context.TODO()) {
 
-		elem := StudentCreate{
+		var elem Student
 
-			FirstName: strings.Trim(names[0], "\""),
-			LastName:  strings.Trim(names[1], "\""),
-			Matricula: strings.Trim(total[i], "\""),
-			ClassID:   classID,
-			//Password:  generateRandomPassword(),
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
 		}
 
 		students = append(students, elem)
 	}
 
-	return students, nil
-}
-
-func GetUserProgress (contestsIds []int, handle string, api *goforces.Client) (interface{}, error){
-
-	ctx := context.Background()
-	done := 0
-	total := 0
-	opt := goforces.ContestStatndingsOptions{
-		Handles:        []string{handle},
-		ShowUnofficial: true,
+	if err := cursor.Err(); err != nil {
+		return nil, err
 	}
 
-	for i := 0; i < len(contestsIds); i++ {
-		standings, err := api.GetContestStandings(ctx, contestsIds[i], &opt)
+	cursor.Close(context.TODO())
 
-		if err != nil {
-			return nil, err
-		}
+	return students, nil
+}
 
-		totalProblems := len(standings.Problems)
-		total += totalProblems
-		tasks := make([]int, totalProblems)
+// rosterChanges return the fields of the student that differ from the roster row
+// Empty email and handle in the roster don't erase what the student already filled
+func rosterChanges(current Student, row roster.Row) bson.M {
 
-		for j := 0; j < len(standings.Rows); j++ {
-			for k := 0; k < totalProblems; k++ {
-				score := int(standings.Rows[j].ProblemResults[k].Points)
-				done += (score ^ tasks[k]) & (tasks[k] ^ 1)
-				tasks[k] |= score
-			}
-		}
+	set := bson.M{}
 
+	if current.FirstName != row.FirstName {
+		set["firstname"] = row.FirstName
 	}
 
-	userProgress := map[string]interface{}{
-		"done": strconv.Itoa(done),
-		"total": strconv.Itoa(total),
+	if current.LastName != row.LastName {
+		set["lastname"] = row.LastName
 	}
 
-	return userProgress, nil
-
-}
-
-func GetIndividualUserProgress (contestsIds []int, handle, groupID string, api *goforces.Client) (interface{}, error){
-	ctx := context.Background()
-	opt := goforces.ContestStatndingsOptions{
-		Handles:        []string{handle},
-		ShowUnofficial: true,
+	if row.Email != "" && current.Email != row.Email {
+		set["email"] = row.Email
 	}
 
-	var contests []interface{}
-
-	for i := 0; i < len(contestsIds); i++ {
-
-		standings, err := api.GetContestStandings(ctx, contestsIds[i], &opt)
-
-		if err != nil {
-			return nil, err
-		}
-
-		total := len(standings.Problems)
-		done := 0
-		tasks := make([]int, total)
-
-		for j := 0; j < len(standings.Rows); j++ {
-			for k := 0; k < total; k++ {
-				score := int(standings.Rows[j].ProblemResults[k].Points)
-				done += (score ^ tasks[k]) & (tasks[k] ^ 1)
-				tasks[k] |= score
-			}
-		}
-
-		userProgress := map[string]interface{}{
-			"name": standings.Contest.Name,
-			"url": "https://codeforces.com/group/" + groupID + "/contest/" + strconv.Itoa(int(standings.Contest.ID)),
-			"done" : done,
-			"total" : total,
-		}
-
-		contests = append(contests, userProgress)
+	if row.Codeforces != "" && current.Handles.Codeforces != row.Codeforces {
+		set["handles.codeforces"] = row.Codeforces
 	}
 
-	return contests, nil
-}
\ No newline at end of file
+	return set
+}
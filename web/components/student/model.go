@@ -2,6 +2,7 @@ package student
 
 import (
 	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/roster"
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
@@ -15,6 +16,10 @@ type Student struct {
 	PhotoURL  string             `json:"photourl"`
 	Email     string             `json:"email"`
 	Grades    StudentGrades      `json:"grades"`
+	// Status, final grade and mention of the enrollment in the class, only filled by GetStudentsClass
+	Status  string  `bson:"-" json:"status,omitempty"`
+	Final   float64 `bson:"-" json:"final,omitempty"`
+	Mention string  `bson:"-" json:"mention,omitempty"`
 }
 
 type StudentCreate struct {
@@ -38,6 +43,15 @@ type StudentInfo struct {
 	PhotoURL  string             `json:"photourl"`
 	Email     string             `json:"email"`
 	Grades    StudentGrades      `json:"grades"`
+	// MustChangePassword comes from the login record and is only filled by AuthStudent
+	MustChangePassword bool `bson:"-" json:"mustchangepassword,omitempty"`
+}
+
+// StudentLogin is the login body, EnrollmentID picks the class to be opened
+type StudentLogin struct {
+	Matricula    string             `json:"matricula"`
+	Password     string             `json:"password"`
+	EnrollmentID primitive.ObjectID `json:"enrollmentid"`
 }
 
 type StudentUpdate struct {
@@ -62,4 +76,6 @@ type StudentHandles struct {
 type StudentCreatePage struct {
 	Result   string                 `json:"result"`
 	Students []user.UserCredentials `json:"students"`
+	Delivery string                 `json:"delivery,omitempty"`
+	Report   *roster.Report         `json:"report,omitempty"`
 }
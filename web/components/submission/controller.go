@@ -2,14 +2,325 @@ package submission
 
 import (
 	"context"
-	"reflect"
+	"strings"
+	"time"
 
-	"github.com/apc-unb/apc-api/web/components/student"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/sirupsen/logrus"
 
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/mongodb/mongo-go-driver/mongo"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
 )
 
+// Submissions returned by a query when no limit is asked, and the most a query can return
+const (
+	DefaultLimit = 500
+	MaxLimit     = 5000
+)
+
+// CreateIndexes creates the index that keeps a single copy of each submission read from the judge
+// and the one used to find the attempts of a problem
+// @param	db				pointer to database
+// @param	databaseName	name of database
+// @param	collectionName	name of submission collection
+// @return 	error 			function error
+func CreateIndexes(db *mongo.Client, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	_, err := collection.Indexes().CreateMany(context.TODO(), []mongo.IndexModel{
+		{
+			// Manual submissions don't have judgeid and aren't unique
+			Keys:    bson.D{{"classid", 1}, {"judgeid", 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"judgeid": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{"classid", 1}, {"contestid", 1}, {"problemindex", 1}, {"submittedat", -1}},
+		},
+		{
+			Keys: bson.D{{"studentid", 1}, {"submittedat", -1}},
+		},
+	})
+
+	return err
+}
+
+// ParseVerdict converts the verdict of the judge, Codeforces names are used by every judge
+// Submissions still being judged are pending, verdicts without an equivalent are rejected
+// @param	verdict			verdict given by the judge
+// @return 	Verdict			the verdict
+func ParseVerdict(verdict string) Verdict {
+
+	switch strings.ToUpper(verdict) {
+	case "OK":
+		return Accepted
+	case "WRONG_ANSWER":
+		return WrongAnswer
+	case "TIME_LIMIT_EXCEEDED", "IDLENESS_LIMIT_EXCEEDED":
+		return TimeLimit
+	case "MEMORY_LIMIT_EXCEEDED":
+		return MemoryLimit
+	case "RUNTIME_ERROR":
+		return RuntimeError
+	case "COMPILATION_ERROR":
+		return CompilationError
+	case "PRESENTATION_ERROR":
+		return PresentationError
+	case "", "TESTING", "SUBMITTED":
+		return Pending
+	default:
+		return Rejected
+	}
+}
+
+// IsVerdict tells if the verdict is one of the known verdicts
+func IsVerdict(verdict Verdict) bool {
+	switch verdict {
+	case Accepted, WrongAnswer, TimeLimit, MemoryLimit, RuntimeError, CompilationError, PresentationError, Pending, Rejected:
+		return true
+	}
+	return false
+}
+
+// SyncClass reads the submissions of every active student with a handle in each contest of the class
+// The judge is called once per contest, a contest that fails is skipped and the others are still read
+// @param	ctx				cancels the sync
+// @param	db				pointer to database
+// @param	provider		judge of the class
+// @param	classID			id of the class
+// @param	contestIDs		judge ids of the contests of the class
+// @param	databaseName	name of database
+// @return 	int				number of submissions read
+// @return 	error 			last contest that failed
+func SyncClass(ctx context.Context, db *mongo.Client, provider judge.Provider, classID primitive.ObjectID, contestIDs []int, databaseName string) (int, error) {
+
+	if len(contestIDs) == 0 {
+		return 0, nil
+	}
+
+	enrollments, err := enrollment.GetClassEnrollments(db, classID, databaseName, "enrollment")
+
+	if err != nil {
+		return 0, err
+	}
+
+	students := Students(enrollments)
+
+	if len(students) == 0 {
+		return 0, nil
+	}
+
+	var handles []string
+
+	for handle := range students {
+		handles = append(handles, handle)
+	}
+
+	var failed error
+
+	read := 0
+
+	for _, contestID := range contestIDs {
+
+		if ctx.Err() != nil {
+			return read, ctx.Err()
+		}
+
+		found, err := provider.GetContestSubmissions(ctx, contestID, handles)
+
+		if err != nil {
+			logrus.Warnf("Not able to read the submissions of contest %d of class %s: %s", contestID, classID.Hex(), err.Error())
+			failed = err
+			continue
+		}
+
+		syncedAT := time.Now()
+
+		var submissions []Submission
+
+		for studentID, studentFound := range ByStudent(students, found) {
+			submissions = append(submissions, Submissions(classID, studentID, studentFound, syncedAT)...)
+		}
+
+		if err = Save(db, submissions, databaseName, "submission"); err != nil {
+			return read, err
+		}
+
+		read += len(submissions)
+	}
+
+	return read, failed
+}
+
+// Students return the id of each active student with a handle by handle in lower case
+func Students(enrollments []enrollment.Enrollment) map[string]primitive.ObjectID {
+
+	students := map[string]primitive.ObjectID{}
+
+	for _, current := range enrollments {
+		if current.Status == enrollment.Active && current.Handles.Codeforces != "" {
+			students[strings.ToLower(current.Handles.Codeforces)] = current.StudentID
+		}
+	}
+
+	return students
+}
+
+// ByStudent splits the submissions of a contest by the student of the handle, other handles are left out
+// @param	students		id of the students by handle in lower case
+// @param	found			submissions read from the judge
+// @return 	map				submissions of each student
+func ByStudent(students map[string]primitive.ObjectID, found []judge.Submission) map[primitive.ObjectID][]judge.Submission {
+
+	split := map[primitive.ObjectID][]judge.Submission{}
+
+	for _, current := range found {
+		if studentID, ok := students[strings.ToLower(current.Handle)]; ok {
+			split[studentID] = append(split[studentID], current)
+		}
+	}
+
+	return split
+}
+
+// Submissions converts the submissions read from the judge into submissions of the student in the class
+// @param	classID			id of the class
+// @param	studentID		id of the student
+// @param	found			submissions read from the judge
+// @param	syncedAT		time the submissions were read
+// @return 	[]Submission	the submissions
+func Submissions(classID, studentID primitive.ObjectID, found []judge.Submission, syncedAT time.Time) []Submission {
+
+	var submissions []Submission
+
+	for _, current := range found {
+		submissions = append(submissions, Submission{
+			ClassID:      classID,
+			StudentID:    studentID,
+			JudgeID:      current.ID,
+			ContestID:    current.ContestID,
+			ProblemIndex: current.ProblemIndex,
+			Handle:       current.Handle,
+			Verdict:      ParseVerdict(current.Verdict),
+			Language:     current.Language,
+			SubmittedAT:  current.CreatedAT,
+			SyncedAT:     &syncedAT,
+		})
+	}
+
+	return submissions
+}
+
+// Save replaces the submissions read before with the same judge id, the verdict of pending ones changes
+// @param	db				pointer to database
+// @param	submissions		submissions read from the judge
+// @param	databaseName	name of database
+// @param	collectionName	name of submission collection
+// @return 	error 			function error
+func Save(db *mongo.Client, submissions []Submission, databaseName, collectionName string) error {
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	for _, submission := range submissions {
+
+		filter := bson.M{
+			"classid": submission.ClassID,
+			"judgeid": submission.JudgeID,
+		}
+
+		update := bson.M{
+			"$set": bson.M{
+				"studentid":    submission.StudentID,
+				"contestid":    submission.ContestID,
+				"problemindex": submission.ProblemIndex,
+				"handle":       submission.Handle,
+				"verdict":      submission.Verdict,
+				"language":     submission.Language,
+				"submittedat":  submission.SubmittedAT,
+				"syncedat":     submission.SyncedAT,
+			},
+		}
+
+		if _, err := collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Find return the submissions that match the filter, newest first
+// @param	db				pointer to database
+// @param	filter			class, student, contest, problem and verdict
+// @param	databaseName	name of database
+// @param	collectionName	name of submission collection
+// @return 	[]Submission	submissions found, at most filter.Limit
+// @return 	error 			function error
+func Find(db *mongo.Client, filter Filter, databaseName, collectionName string) ([]Submission, error) {
+
+	submissions := []Submission{}
+
+	query := bson.M{}
+
+	if !filter.ClassID.IsZero() {
+		query["classid"] = filter.ClassID
+	}
+
+	if !filter.StudentID.IsZero() {
+		query["studentid"] = filter.StudentID
+	}
+
+	if filter.ContestID != 0 {
+		query["contestid"] = filter.ContestID
+	}
+
+	if filter.ProblemIndex != "" {
+		query["problemindex"] = strings.ToUpper(filter.ProblemIndex)
+	}
+
+	if filter.Verdict != "" {
+		query["verdict"] = filter.Verdict
+	}
+
+	limit := filter.Limit
+
+	if limit <= 0 {
+		limit = DefaultLimit
+	} else if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	cursor, err := collection.Find(context.TODO(), query, options.Find().SetSort(bson.D{{"submittedat", -1}}).SetLimit(limit))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Submission
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		submissions = append(submissions, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return submissions, nil
+}
+
 func CreateSubmissions(db *mongo.Client, submissions []SubmissionCreate, database_name, collection_name string) error {
 
 	if len(submissions) == 0 {
@@ -67,6 +378,18 @@ func GetSubmissions(db *mongo.Client, database_name, collection_name string) ([]
 
 }
 
+// GetSubmission return the submission with the given id
+func GetSubmission(db *mongo.Client, submissionID primitive.ObjectID, databaseName, collectionName string) (Submission, error) {
+
+	var found Submission
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": submissionID}).Decode(&found)
+
+	return found, err
+}
+
 func UpdateSubmissions(db *mongo.Client, submissions []Submission, database_name, collection_name string) error {
 
 	if len(submissions) == 0 {
@@ -83,16 +406,24 @@ func UpdateSubmissions(db *mongo.Client, submissions []Submission, database_name
 
 		update := bson.M{}
 
-		if reflect.DeepEqual(submission.Student, student.Student{}) {
-			update["student"] = submission.Student
+		if !submission.StudentID.IsZero() {
+			update["studentid"] = submission.StudentID
+		}
+
+		if submission.ProblemIndex != "" {
+			update["problemindex"] = submission.ProblemIndex
+		}
+
+		if submission.Verdict != "" {
+			update["verdict"] = submission.Verdict
 		}
 
-		if submission.Time != "" {
-			update["time"] = submission.Time
+		if submission.Language != "" {
+			update["language"] = submission.Language
 		}
 
-		if submission.Veredict != "" {
-			update["veredict"] = submission.Veredict
+		if !submission.SubmittedAT.IsZero() {
+			update["submittedat"] = submission.SubmittedAT
 		}
 
 		updateSet := bson.M{"$set": update}
@@ -122,3 +453,77 @@ func DeleteSubmissions(db *mongo.Client, submissions []Submission, database_name
 	return nil
 
 }
+
+// FirstAccepted return when each student of the class first solved each problem of the contest
+// @param	db				pointer to database
+// @param	classID			id of the class
+// @param	contestID		id of the contest
+// @param	databaseName	name of database
+// @param	collectionName	name of submission collection
+// @return 	map				time of the first accepted submission by student and problem
+// @return 	error 			function error
+func FirstAccepted(db *mongo.Client, classID primitive.ObjectID, contestID int, databaseName, collectionName string) (map[primitive.ObjectID]map[string]time.Time, error) {
+
+	var submissions []Submission
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	query := bson.M{
+		"classid":   classID,
+		"contestid": contestID,
+		"verdict":   Accepted,
+	}
+
+	cursor, err := collection.Find(context.TODO(), query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor.Next(context.TODO()) {
+
+		var elem Submission
+
+		if err := cursor.Decode(&elem); err != nil {
+			return nil, err
+		}
+
+		submissions = append(submissions, elem)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor.Close(context.TODO())
+
+	return Solved(submissions), nil
+}
+
+// Solved return when each student first solved each problem, problems in upper case
+// Only accepted submissions count
+// @param	submissions		submissions of the students
+// @return 	map				time of the first accepted submission by student and problem
+func Solved(submissions []Submission) map[primitive.ObjectID]map[string]time.Time {
+
+	solved := map[primitive.ObjectID]map[string]time.Time{}
+
+	for _, current := range submissions {
+
+		if current.Verdict != Accepted {
+			continue
+		}
+
+		if solved[current.StudentID] == nil {
+			solved[current.StudentID] = map[string]time.Time{}
+		}
+
+		problem := strings.ToUpper(current.ProblemIndex)
+
+		if first, ok := solved[current.StudentID][problem]; !ok || current.SubmittedAT.Before(first) {
+			solved[current.StudentID][problem] = current.SubmittedAT
+		}
+	}
+
+	return solved
+}
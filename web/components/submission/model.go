@@ -1,21 +1,59 @@
 package submission
 
 import (
-	"github.com/apc-unb/apc-api/web/components/student"
+	"time"
+
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
-// TODO : Check how submissions time are made in Pimenta Judge
-// TODO : Decide if veredict gonna be num code or string
+// Verdict is the result of a submission, the same for every judge
+type Verdict string
+
+const (
+	Accepted          Verdict = "AC"
+	WrongAnswer       Verdict = "WA"
+	TimeLimit         Verdict = "TLE"
+	MemoryLimit       Verdict = "MLE"
+	RuntimeError      Verdict = "RE"
+	CompilationError  Verdict = "CE"
+	PresentationError Verdict = "PE"
+	Pending           Verdict = "PENDING"
+	Rejected          Verdict = "REJECTED"
+)
+
+// Submission is an attempt of a student in a problem of a contest of the class
+// Submissions read from the judge keep the id the judge gave them, manual ones don't have it
 type Submission struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	Student  student.Student    `json:"student"`
-	Veredict string             `json:"veredict"`
-	Time     string             `json:"time"`
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	ClassID      primitive.ObjectID `bson:"classid,omitempty"`
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	JudgeID      int64              `bson:"judgeid,omitempty" json:"judgeid,omitempty"`
+	ContestID    int                `json:"contestid"`
+	ProblemIndex string             `json:"problemindex"`
+	Handle       string             `json:"handle"`
+	Verdict      Verdict            `json:"verdict"`
+	Language     string             `json:"language"`
+	SubmittedAT  time.Time          `json:"submittedat"`
+	SyncedAT     *time.Time         `bson:"syncedat,omitempty" json:"syncedat,omitempty"`
 }
 
 type SubmissionCreate struct {
-	Student  student.Student `json:"student"`
-	Veredict string          `json:"veredict"`
-	Time     string          `json:"time"`
+	ClassID      primitive.ObjectID `bson:"classid,omitempty"`
+	StudentID    primitive.ObjectID `bson:"studentid,omitempty"`
+	ContestID    int                `json:"contestid"`
+	ProblemIndex string             `json:"problemindex"`
+	Handle       string             `json:"handle"`
+	Verdict      Verdict            `json:"verdict"`
+	Language     string             `json:"language"`
+	SubmittedAT  time.Time          `json:"submittedat"`
+}
+
+// Filter of the submissions query, empty fields match every submission
+type Filter struct {
+	ClassID      primitive.ObjectID
+	StudentID    primitive.ObjectID
+	ContestID    int
+	ProblemIndex string
+	Verdict      Verdict
+	Limit        int64
 }
@@ -113,6 +113,18 @@ func GetTasksClass(db *mongo.Client, examID primitive.ObjectID, database_name, c
 
 }
 
+// GetTask return the task with the given id
+func GetTask(db *mongo.Client, taskID primitive.ObjectID, database_name, collection_name string) (Task, error) {
+
+	var found Task
+
+	collection := db.Database(database_name).Collection(collection_name)
+
+	err := collection.FindOne(context.TODO(), bson.M{"_id": taskID}).Decode(&found)
+
+	return found, err
+}
+
 func UpdateTasks(db *mongo.Client, tasks []Task, database_name, collection_name string) error {
 
 	if len(tasks) == 0 {
@@ -0,0 +1,182 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/apc-unb/apc-api/web/utils"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/options"
+)
+
+// GeneratePassword return a random password following the policy
+// Every character is picked with crypto/rand, so all of them are equally likely
+// @param	policy		length and charset of the password
+// @return 	string		plain password, only shown once to the user
+// @return 	error 		function error
+func GeneratePassword(policy PasswordPolicy) (string, error) {
+
+	if err := policy.Validate(); err != nil {
+		return "", err
+	}
+
+	charset := []rune(policy.Charset)
+	max := big.NewInt(int64(len(charset)))
+	password := make([]rune, policy.Length)
+
+	for i := range password {
+
+		n, err := rand.Int(rand.Reader, max)
+
+		if err != nil {
+			return "", err
+		}
+
+		password[i] = charset[n.Int64()]
+	}
+
+	return string(password), nil
+}
+
+// NewCredentials generates a password for a new user
+// Return the login record, with the hash and the first login flag, and the plain password
+// @param	id			user id
+// @param	matricula	user matricula
+// @param	policy		length and charset of the password
+// @return 	login		credentials to be stored in <collection>_login
+// @return 	plain		credentials to be returned once to who created the user
+// @return 	error 		function error
+func NewCredentials(id primitive.ObjectID, matricula string, policy PasswordPolicy) (login UserCredentials, plain UserCredentials, err error) {
+
+	var pwd string
+
+	if pwd, err = GeneratePassword(policy); err != nil {
+		return login, plain, err
+	}
+
+	login = UserCredentials{ID: id, Matricula: matricula, MustChangePassword: true}
+
+	if login.Password, err = utils.HashAndSalt([]byte(pwd)); err != nil {
+		return login, plain, err
+	}
+
+	plain = UserCredentials{ID: id, Matricula: matricula, Password: pwd, MustChangePassword: true}
+
+	return login, plain, nil
+}
+
+// ChangePassword checks the current password and stores the new one
+// Also clears the first login flag, so the user can use the whole system again
+// @param	db				pointer to database
+// @param	userID			id of the user
+// @param	change			current and new password
+// @param	policy			password policy, the new password must follow it
+// @param	databaseName	name of database
+// @param	collectionName	name of login collection
+// @return 	error 			function error
+func ChangePassword(db *mongo.Client, userID primitive.ObjectID, change PasswordChange, policy PasswordPolicy, databaseName, collectionName string) error {
+
+	var credentials UserCredentials
+	var password string
+	var err error
+
+	if err = policy.Check(change.NewPassword); err != nil {
+		return err
+	}
+
+	if change.NewPassword == change.Password {
+		return errors.New("New password must be different from the current one")
+	}
+
+	collection := db.Database(databaseName).Collection(collectionName)
+
+	filter := bson.M{
+		"_id": userID,
+	}
+
+	if err = collection.FindOne(
+		context.TODO(),
+		filter,
+		options.FindOne(),
+	).Decode(&credentials); err != nil {
+		return err
+	}
+
+	if err = utils.ComparePasswords(credentials.Password, change.Password); err != nil {
+		return errors.New("Invalid Password")
+	}
+
+	if password, err = utils.HashAndSalt([]byte(change.NewPassword)); err != nil {
+		return err
+	}
+
+	update := bson.M{"$set": bson.M{"password": password, "mustchangepassword": false}}
+
+	if _, err = collection.UpdateOne(context.TODO(), filter, update); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks if the policy can generate passwords hard enough to guess
+func (p PasswordPolicy) Validate() error {
+
+	if p.Length < MinPasswordLength {
+		return errors.New("Password length must be at least " + strconv.Itoa(MinPasswordLength) + ", got " + strconv.Itoa(p.Length))
+	}
+
+	seen := map[rune]bool{}
+
+	for _, c := range p.Charset {
+		if seen[c] {
+			return errors.New("Password charset has repeated character " + string(c))
+		}
+		seen[c] = true
+	}
+
+	if len(seen) < 10 {
+		return errors.New("Password charset must have at least 10 characters")
+	}
+
+	return nil
+}
+
+// Check tells if a password chosen by the user is strong enough
+// It must be as long as the generated passwords and mix letters with digits or symbols
+func (p PasswordPolicy) Check(password string) error {
+
+	length := p.Length
+
+	if length < MinPasswordLength {
+		length = MinPasswordLength
+	}
+
+	if utf8.RuneCountInString(password) < length {
+		return errors.New("New password must have at least " + strconv.Itoa(length) + " characters")
+	}
+
+	var letter, other bool
+
+	for _, c := range password {
+		if unicode.IsLetter(c) {
+			letter = true
+		} else if !unicode.IsSpace(c) {
+			other = true
+		}
+	}
+
+	if !letter || !other {
+		return errors.New("New password must mix letters with digits or symbols")
+	}
+
+	return nil
+}
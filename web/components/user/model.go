@@ -3,7 +3,29 @@ package user
 import "github.com/mongodb/mongo-go-driver/bson/primitive"
 
 type UserCredentials struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	Matricula string             `json:"matricula"`
-	Password  string             `json:"password"`
+	ID                 primitive.ObjectID `bson:"_id,omitempty"`
+	Matricula          string             `json:"matricula"`
+	Password           string             `json:"password"`
+	MustChangePassword bool               `json:"mustchangepassword"`
+}
+
+// PasswordChange is the body sent to replace the current password
+type PasswordChange struct {
+	Password    string `json:"password"`
+	NewPassword string `json:"newpassword"`
+}
+
+// PasswordPolicy tells how generated passwords look like
+type PasswordPolicy struct {
+	Length  int
+	Charset string
+}
+
+// MinPasswordLength is the shortest password allowed, generated or chosen by the user
+const MinPasswordLength = 6
+
+// DefaultPasswordPolicy skips letters and numbers that look alike on a printed sheet (I/1, O/0)
+var DefaultPasswordPolicy = PasswordPolicy{
+	Length:  8,
+	Charset: "ABCDEFGHJKLMNPQRSTUVWXYZ23456789",
 }
@@ -2,9 +2,17 @@ package config
 
 import (
 	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
+	"github.com/apc-unb/apc-api/auth"
+	"github.com/apc-unb/apc-api/web/codeforces"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/apc-unb/apc-api/web/mailer"
 	"github.com/mongodb/mongo-go-driver/mongo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
@@ -18,8 +26,34 @@ const (
 	port             = "port"
 	logLevel         = "log-level"
 	jwtKey           = "jwt-key"
+	jwtKeyDir        = "jwt-key-dir"
+	jwtKid           = "jwt-kid"
+	jwtTTL           = "jwt-ttl"
+	refreshTTL       = "refresh-ttl"
+	mailerKind       = "mailer"
+	mailDir          = "mail-dir"
+	mailFrom         = "mail-from"
+	smtpHost         = "smtp-host"
+	smtpPort         = "smtp-port"
+	smtpUser         = "smtp-user"
+	smtpPassword     = "smtp-password"
+	resetURL         = "reset-url"
+	resetTTL         = "reset-ttl"
+	trustProxy       = "trust-proxy"
+	loginURL         = "login-url"
+	passwordLength   = "password-length"
+	passwordCharset  = "password-charset"
 	codeforcesKey    = "codeforces-key"
 	codeforcesSecret = "codeforces-secret"
+	codeforcesTTL    = "codeforces-cache-ttl"
+	codeforcesSync   = "codeforces-sync-interval"
+	codeforcesTime   = "codeforces-timeout"
+	breakerFailures  = "codeforces-breaker-failures"
+	breakerCooldown  = "codeforces-breaker-cooldown"
+	progressStale    = "progress-stale-after"
+	fakeJudge        = "fake-judge"
+	codeforcesMode   = "codeforces-mode"
+	fixturesDir      = "codeforces-fixtures"
 )
 
 // Flags define the fields that will be passed via cmd
@@ -29,15 +63,47 @@ type Flags struct {
 	MongoPort        string
 	LogLevel         string
 	JwtSecret        string
+	JwtKeyDir        string
+	JwtKid           string
+	JwtTTL           time.Duration
+	RefreshTTL       time.Duration
+	MailerKind       string
+	MailDir          string
+	MailFrom         string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUser         string
+	SMTPPassword     string
+	ResetURL         string
+	ResetTTL         time.Duration
+	TrustProxy       bool
+	LoginURL         string
+	PasswordLength   int
+	PasswordCharset  string
 	CodeforcesKey    string
 	CodeforcesSecret string
+	CodeforcesTTL    time.Duration
+	CodeforcesSync   time.Duration
+	CodeforcesTime   time.Duration
+	BreakerFailures  int
+	BreakerCooldown  time.Duration
+	ProgressStale    time.Duration
+	FakeJudge        bool
+	CodeforcesMode   string
+	FixturesDir      string
 }
 
 // WebBuilder defines the parametric information of a whisper server instance
 type WebBuilder struct {
 	*Flags
-	DataBase *mongo.Client
-	GoForces *goforces.Client
+	DataBase       *mongo.Client
+	Keys           *auth.KeySet
+	Mailer         mailer.Mailer
+	PasswordPolicy user.PasswordPolicy
+	GoForces       *goforces.Client
+	Codeforces     *codeforces.Judge
+	Judges         *judge.Registry
+	Progress       *progress.Memory
 }
 
 // AddFlags adds flags for Builder.
@@ -46,9 +112,54 @@ func AddFlags(flags *pflag.FlagSet) {
 	flags.StringP(mongoHost, "m", "localhost", "Custom host for accessing Mongo DB services. Defaults to localhost")
 	flags.StringP(mongoPort, "t", "27017", "Custom port for accessing Mongo DB services. Defaults to 27017")
 	flags.StringP(logLevel, "l", "info", "[optional] Sets the Log Level to one of seven (trace, debug, info, warn, error, fatal, panic). Defaults to info")
-	flags.StringP(jwtKey, "k", "", "Sets the secret key used to hash the JWT")
+	flags.StringP(jwtKey, "k", "", "Sets the secret key used to hash the JWT when no key directory is given")
+	flags.String(jwtKeyDir, "", "Sets the directory of <kid>.pem RSA/EC keys used to sign the JWT with RS256/ES256")
+	flags.String(jwtKid, "", "[optional] Sets the kid of the key that signs new tokens. Defaults to the greatest kid with a private key")
+	flags.Duration(jwtTTL, 30*time.Minute, "[optional] Sets how long an access token lives. Defaults to 30m")
+	flags.Duration(refreshTTL, 7*24*time.Hour, "[optional] Sets how long a refresh token lives without being used. Defaults to 168h")
+	flags.String(mailerKind, "file", "[optional] Sets how emails are sent, smtp or file. Defaults to file")
+	flags.String(mailDir, "", "[optional] Sets the directory where the file mailer writes emails. Defaults to the log")
+	flags.String(mailFrom, "dragont@localhost", "[optional] Sets the sender address of emails")
+	flags.String(smtpHost, "localhost", "[optional] Sets the host of the SMTP server")
+	flags.String(smtpPort, "25", "[optional] Sets the port of the SMTP server")
+	flags.String(smtpUser, "", "[optional] Sets the user of the SMTP server")
+	flags.String(smtpPassword, "", "[optional] Sets the password of the SMTP server")
+	flags.String(resetURL, "http://localhost:5000/reset", "[optional] Sets the front-end page that receives the password reset token")
+	flags.Duration(resetTTL, time.Hour, "[optional] Sets how long a password reset token lives. Defaults to 1h")
+	flags.Bool(trustProxy, false, "[optional] Reads the client IP from X-Real-IP/X-Forwarded-For, only use it behind a reverse proxy")
+	flags.String(loginURL, "http://localhost:5000", "[optional] Sets the front-end page sent with the credential emails")
+	flags.Int(passwordLength, user.DefaultPasswordPolicy.Length, "[optional] Sets the length of generated passwords. Defaults to 8")
+	flags.String(passwordCharset, user.DefaultPasswordPolicy.Charset, "[optional] Sets the characters used in generated passwords")
 	flags.StringP(codeforcesKey, "c", "", "Sets the secret key of Codeforces API")
 	flags.StringP(codeforcesSecret, "s", "", "Sets the secret of Codeforces API")
+	flags.Duration(codeforcesTTL, time.Minute, "[optional] Sets how long Codeforces standings are cached, 0 disables the cache. Defaults to 1m")
+	flags.Duration(codeforcesSync, 10*time.Minute, "[optional] Sets how often the progress of the students is read from Codeforces, 0 leaves it to the sync command. Defaults to 10m")
+	flags.Duration(codeforcesTime, 10*time.Second, "[optional] Sets how long a call to Codeforces can take. Defaults to 10s")
+	flags.Int(breakerFailures, 5, "[optional] Sets how many Codeforces failures in a row stop the calls for a while. Defaults to 5")
+	flags.Duration(breakerCooldown, time.Minute, "[optional] Sets how long the calls to Codeforces stay stopped after the failures. Defaults to 1m")
+	flags.Duration(progressStale, 30*time.Minute, "[optional] Sets the age after which the progress sent at login is stale, 0 never. Defaults to 30m")
+	flags.Bool(fakeJudge, false, "[optional] Lets classes choose the fake judge, that never calls the network. Only for development")
+	addFixturesFlags(flags)
+}
+
+func addFixturesFlags(flags *pflag.FlagSet) {
+	flags.String(codeforcesMode, codeforces.Live, "[optional] Sets how Codeforces is called, live, record (saves the answers in --codeforces-fixtures) or replay (answers from --codeforces-fixtures without network). Defaults to live")
+	flags.String(fixturesDir, "fixtures/codeforces", "[optional] Sets the directory of the recorded Codeforces answers")
+}
+
+// AddSyncFlags adds the flags of the sync command, it only needs the database and Codeforces
+func AddSyncFlags(flags *pflag.FlagSet) {
+	flags.StringP(mongoHost, "m", "localhost", "Custom host for accessing Mongo DB services. Defaults to localhost")
+	flags.StringP(mongoPort, "t", "27017", "Custom port for accessing Mongo DB services. Defaults to 27017")
+	flags.StringP(logLevel, "l", "info", "[optional] Sets the Log Level to one of seven (trace, debug, info, warn, error, fatal, panic). Defaults to info")
+	flags.StringP(codeforcesKey, "c", "", "Sets the secret key of Codeforces API")
+	flags.StringP(codeforcesSecret, "s", "", "Sets the secret of Codeforces API")
+	flags.Duration(codeforcesSync, 10*time.Minute, "[optional] Sets how often the progress of the students is read from Codeforces, 0 syncs once and exits. Defaults to 10m")
+	flags.Bool(fakeJudge, false, "[optional] Syncs the classes that chose the fake judge. Only for development")
+	flags.Duration(codeforcesTime, 10*time.Second, "[optional] Sets how long a call to Codeforces can take. Defaults to 10s")
+	flags.Int(breakerFailures, 5, "[optional] Sets how many Codeforces failures in a row stop the calls for a while. Defaults to 5")
+	flags.Duration(breakerCooldown, time.Minute, "[optional] Sets how long the calls to Codeforces stay stopped after the failures. Defaults to 1m")
+	addFixturesFlags(flags)
 }
 
 // InitFromViper initializes the web server builder with properties retrieved from Viper.
@@ -59,25 +170,122 @@ func (b *WebBuilder) InitFromViper(v *viper.Viper) *WebBuilder {
 	flags.MongoPort = v.GetString(mongoPort)
 	flags.LogLevel = v.GetString(logLevel)
 	flags.JwtSecret = v.GetString(jwtKey)
+	flags.JwtKeyDir = v.GetString(jwtKeyDir)
+	flags.JwtKid = v.GetString(jwtKid)
+	flags.JwtTTL = v.GetDuration(jwtTTL)
+	flags.RefreshTTL = v.GetDuration(refreshTTL)
+	flags.MailerKind = v.GetString(mailerKind)
+	flags.MailDir = v.GetString(mailDir)
+	flags.MailFrom = v.GetString(mailFrom)
+	flags.SMTPHost = v.GetString(smtpHost)
+	flags.SMTPPort = v.GetString(smtpPort)
+	flags.SMTPUser = v.GetString(smtpUser)
+	flags.SMTPPassword = v.GetString(smtpPassword)
+	flags.ResetURL = v.GetString(resetURL)
+	flags.ResetTTL = v.GetDuration(resetTTL)
+	flags.TrustProxy = v.GetBool(trustProxy)
+	flags.LoginURL = v.GetString(loginURL)
+	flags.PasswordLength = v.GetInt(passwordLength)
+	flags.PasswordCharset = v.GetString(passwordCharset)
 	flags.CodeforcesKey = v.GetString(codeforcesKey)
 	flags.CodeforcesSecret = v.GetString(codeforcesSecret)
+	flags.CodeforcesTTL = v.GetDuration(codeforcesTTL)
+	flags.CodeforcesSync = v.GetDuration(codeforcesSync)
+	flags.CodeforcesTime = v.GetDuration(codeforcesTime)
+	flags.BreakerFailures = v.GetInt(breakerFailures)
+	flags.BreakerCooldown = v.GetDuration(breakerCooldown)
+	flags.ProgressStale = v.GetDuration(progressStale)
+	flags.FakeJudge = v.GetBool(fakeJudge)
+	flags.CodeforcesMode = v.GetString(codeforcesMode)
+	flags.FixturesDir = v.GetString(fixturesDir)
 
 	flags.check()
 
 	b.Flags = flags
 	b.DataBase = b.getMongoDB(flags.MongoHost, flags.MongoPort)
-	b.GoForces = b.getGoForces(flags.CodeforcesKey, flags.CodeforcesSecret)
+	b.Keys = b.getKeySet(flags.JwtSecret, flags.JwtKeyDir, flags.JwtKid)
+	b.Mailer = b.getMailer(flags)
+	b.PasswordPolicy = user.PasswordPolicy{Length: flags.PasswordLength, Charset: flags.PasswordCharset}
+	b.Codeforces = b.getCodeforces(flags, flags.CodeforcesTTL)
+	b.Judges = b.getJudges(flags)
+	b.Progress = progress.NewMemory()
+
+	return b
+}
+
+// InitSyncFromViper initializes only the database and Codeforces, used by the sync command
+func (b *WebBuilder) InitSyncFromViper(v *viper.Viper) *WebBuilder {
+	flags := new(Flags)
+	flags.MongoHost = v.GetString(mongoHost)
+	flags.MongoPort = v.GetString(mongoPort)
+	flags.LogLevel = v.GetString(logLevel)
+	flags.CodeforcesKey = v.GetString(codeforcesKey)
+	flags.CodeforcesSecret = v.GetString(codeforcesSecret)
+	flags.CodeforcesSync = v.GetDuration(codeforcesSync)
+	flags.CodeforcesTime = v.GetDuration(codeforcesTime)
+	flags.BreakerFailures = v.GetInt(breakerFailures)
+	flags.BreakerCooldown = v.GetDuration(breakerCooldown)
+	flags.FakeJudge = v.GetBool(fakeJudge)
+	flags.CodeforcesMode = v.GetString(codeforcesMode)
+	flags.FixturesDir = v.GetString(fixturesDir)
+
+	if err := flags.CheckCodeforces(); err != nil {
+		panic(err.Error())
+	}
+
+	b.Flags = flags
+	b.DataBase = b.getMongoDB(flags.MongoHost, flags.MongoPort)
+	b.Codeforces = b.getCodeforces(flags, 0)
+	b.Judges = b.getJudges(flags)
 
 	return b
 }
 
 func (flags *Flags) check() {
-	logrus.Infof("Flags: '%v'", flags)
-	if flags.JwtSecret == "" || flags.CodeforcesSecret == "" || flags.CodeforcesKey == "" {
-		panic("secret-key, codeforces-key and codeforces-secret cannot be empty")
+	logrus.Infof("Flags: '%v'", flags.Redacted())
+	if flags.JwtSecret == "" && flags.JwtKeyDir == "" {
+		panic("jwt-key or jwt-key-dir cannot be empty")
+	}
+	if err := flags.CheckCodeforces(); err != nil {
+		panic(err.Error())
+	}
+	if err := (user.PasswordPolicy{Length: flags.PasswordLength, Charset: flags.PasswordCharset}).Validate(); err != nil {
+		panic(err.Error())
 	}
 }
 
+// Redacted return a copy of the flags that can be logged, secrets set are replaced by "***"
+func (flags *Flags) Redacted() Flags {
+
+	redacted := *flags
+
+	for _, secret := range []*string{&redacted.JwtSecret, &redacted.SMTPPassword, &redacted.CodeforcesKey, &redacted.CodeforcesSecret} {
+		if *secret != "" {
+			*secret = "***"
+		}
+	}
+
+	return redacted
+}
+
+// CheckCodeforces tells if the Codeforces flags are valid
+// The key and secret are only needed when Codeforces is really called, not on replay
+func (flags *Flags) CheckCodeforces() error {
+	switch flags.CodeforcesMode {
+	case codeforces.Live, codeforces.Record:
+		if flags.CodeforcesSecret == "" || flags.CodeforcesKey == "" {
+			return errors.New("codeforces-key and codeforces-secret cannot be empty, unless codeforces-mode is replay")
+		}
+	case codeforces.Replay:
+		if flags.FixturesDir == "" {
+			return errors.New("codeforces-fixtures cannot be empty on replay")
+		}
+	default:
+		return errors.New("Unknown codeforces-mode " + flags.CodeforcesMode + ", use live, record or replay")
+	}
+	return nil
+}
+
 func (b *WebBuilder) getMongoDB(host, port string) *mongo.Client {
 
 	db, err := mongo.Connect(context.TODO(), "mongodb://"+host+":"+port)
@@ -97,6 +305,45 @@ func (b *WebBuilder) getMongoDB(host, port string) *mongo.Client {
 	return db
 }
 
+func (b *WebBuilder) getKeySet(secret, dir, kid string) *auth.KeySet {
+
+	if dir == "" {
+		logrus.Warnf("No JWT key directory given, signing tokens with HS256")
+		return auth.NewSecretKeySet(secret)
+	}
+
+	keys, err := auth.LoadKeySet(dir, kid)
+
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Infof("Signing tokens with key %s", keys.Signer().ID)
+
+	return keys
+}
+
+func (b *WebBuilder) getMailer(flags *Flags) mailer.Mailer {
+
+	switch flags.MailerKind {
+	case "smtp":
+		logrus.Infof("Sending emails through %s:%s", flags.SMTPHost, flags.SMTPPort)
+		return &mailer.SMTPMailer{
+			Host:     flags.SMTPHost,
+			Port:     flags.SMTPPort,
+			User:     flags.SMTPUser,
+			Password: flags.SMTPPassword,
+			From:     flags.MailFrom,
+		}
+	case "file":
+		logrus.Infof("Writing emails to '%s' instead of sending them", flags.MailDir)
+		return &mailer.FileMailer{Dir: flags.MailDir, From: flags.MailFrom}
+	default:
+		logrus.Fatalf("Unknown mailer %s, use smtp or file", flags.MailerKind)
+		return nil
+	}
+}
+
 func (b *WebBuilder) getGoForces(codeforcesKey, codeforcesSecret string) *goforces.Client {
 
 	var goForces *goforces.Client
@@ -115,3 +362,42 @@ func (b *WebBuilder) getGoForces(codeforcesKey, codeforcesSecret string) *goforc
 
 	return goForces
 }
+
+func (b *WebBuilder) getJudges(flags *Flags) *judge.Registry {
+
+	if flags.FakeJudge {
+		logrus.Warnf("Classes can use the fake judge, don't use it in production")
+		return judge.NewRegistry(b.Codeforces, judge.NewFake())
+	}
+
+	return judge.NewRegistry(b.Codeforces)
+}
+
+// getCodeforces return the Codeforces judge calling the live API, recording it or replaying the fixtures
+func (b *WebBuilder) getCodeforces(flags *Flags, ttl time.Duration) *codeforces.Judge {
+
+	var api codeforces.API
+	var err error
+
+	interval := codeforces.RateLimit
+
+	switch flags.CodeforcesMode {
+	case codeforces.Replay:
+		logrus.Infof("Replaying Codeforces answers from '%s', Codeforces won't be called", flags.FixturesDir)
+		api, err = codeforces.NewReplayer(flags.FixturesDir)
+		interval = 0
+	case codeforces.Record:
+		logrus.Infof("Recording Codeforces answers in '%s'", flags.FixturesDir)
+		b.GoForces = b.getGoForces(flags.CodeforcesKey, flags.CodeforcesSecret)
+		api, err = codeforces.NewRecorder(b.GoForces, flags.FixturesDir)
+	default:
+		b.GoForces = b.getGoForces(flags.CodeforcesKey, flags.CodeforcesSecret)
+		api = b.GoForces
+	}
+
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	return codeforces.NewJudge(api, interval, flags.CodeforcesTime, flags.BreakerFailures, flags.BreakerCooldown, ttl)
+}
@@ -0,0 +1,159 @@
+package deadline
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Kinds of late policy
+const (
+	// None counts late solves as if they were on time
+	None = "none"
+	// Linear takes the credit of a late solve from full at the deadline to zero at until
+	Linear = "linear"
+	// Fixed takes the penalty from every late solve
+	Fixed = "fixed"
+	// Cutoff doesn't count late solves
+	Cutoff = "cutoff"
+)
+
+// Policy is the deadline of a contest of the class and what happens to problems solved after it
+type Policy struct {
+	ContestID int       `json:"contestid"`
+	Kind      string    `json:"kind"`
+	Deadline  time.Time `json:"deadline"`
+	// Until is when a linear decay reaches zero
+	Until time.Time `json:"until"`
+	// Penalty is the fraction of the problem lost by a fixed policy, between 0 and 1
+	Penalty float64 `json:"penalty"`
+}
+
+// Result of a student in a contest under a policy
+type Result struct {
+	// Counted are the problems that are still worth something
+	Counted int `json:"counted"`
+	OnTime  int `json:"ontime"`
+	Late    int `json:"late"`
+	// Credit is the sum of what each problem is worth, one for problems solved on time
+	Credit float64 `json:"credit"`
+}
+
+// Validate checks the policies of a class before they are saved
+func Validate(policies []Policy) error {
+
+	contests := map[int]bool{}
+
+	for i, policy := range policies {
+
+		prefix := "Deadline " + strconv.Itoa(i+1) + ": "
+
+		if policy.ContestID <= 0 {
+			return errors.New(prefix + "contestid must be positive")
+		}
+
+		if contests[policy.ContestID] {
+			return errors.New(prefix + "contest " + strconv.Itoa(policy.ContestID) + " is repeated")
+		}
+
+		contests[policy.ContestID] = true
+
+		if err := policy.Validate(); err != nil {
+			return errors.New(prefix + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Validate checks a single policy
+func (p Policy) Validate() error {
+
+	switch p.Kind {
+	case "", None:
+		return nil
+	case Linear, Fixed, Cutoff:
+	default:
+		return errors.New("kind must be none, linear, fixed or cutoff")
+	}
+
+	if p.Deadline.IsZero() {
+		return errors.New("deadline can't be empty")
+	}
+
+	if p.Kind == Linear && !p.Until.After(p.Deadline) {
+		return errors.New("until must be after the deadline")
+	}
+
+	if p.Kind == Fixed && (p.Penalty <= 0 || p.Penalty > 1) {
+		return errors.New("penalty must be greater than 0 and at most 1")
+	}
+
+	return nil
+}
+
+// For return the policy of the contest, a contest without policy has no deadline
+func For(policies []Policy, contestID int) (Policy, bool) {
+
+	for _, policy := range policies {
+		if policy.ContestID == contestID {
+			return policy, true
+		}
+	}
+
+	return Policy{ContestID: contestID, Kind: None}, false
+}
+
+// IsLate tells if a problem solved at solvedAT missed the deadline
+func (p Policy) IsLate(solvedAT time.Time) bool {
+	return !p.Deadline.IsZero() && solvedAT.After(p.Deadline)
+}
+
+// Credit return what a problem solved at solvedAT is worth, between 0 and 1
+func (p Policy) Credit(solvedAT time.Time) float64 {
+
+	if !p.IsLate(solvedAT) {
+		return 1
+	}
+
+	switch p.Kind {
+	case Linear:
+		if !p.Until.After(p.Deadline) || !solvedAT.Before(p.Until) {
+			return 0
+		}
+		return 1 - float64(solvedAT.Sub(p.Deadline))/float64(p.Until.Sub(p.Deadline))
+	case Fixed:
+		if p.Penalty >= 1 {
+			return 0
+		}
+		return 1 - p.Penalty
+	case Cutoff:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Apply return the result of the problems solved at the given times, the first accepted submission of each problem
+func (p Policy) Apply(solved map[string]time.Time) Result {
+
+	var result Result
+
+	for _, solvedAT := range solved {
+
+		if p.IsLate(solvedAT) {
+			result.Late++
+		} else {
+			result.OnTime++
+		}
+
+		credit := p.Credit(solvedAT)
+
+		if credit > 0 {
+			result.Counted++
+			result.Credit += credit
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,618 @@
+package grading
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxFormulaLength keeps formulas small enough to be read by a person
+const MaxFormulaLength = 1000
+
+// maxDepth limits the nesting of a formula, so evaluating it can't exhaust the stack
+const maxDepth = 64
+
+// Formula is a parsed grade formula, like 0.6*avg(exams) + 0.3*avg(lists) + 0.1*sum(projects)
+//
+// Variables are the lists of grades: exams, lists and projects
+// Functions are avg, sum, min, max, count and if(condition, then, else)
+// Operators are + - * / with the usual precedence, comparisons (< <= > >= == !=) and && || !
+// Booleans are numbers, zero is false
+type Formula struct {
+	source string
+	root   node
+}
+
+// kind of value a node evaluates to
+type kind int
+
+const (
+	number kind = iota
+	list
+)
+
+type node interface {
+	eval(input Input) (value, error)
+}
+
+type value struct {
+	number float64
+	list   []float64
+	kind   kind
+}
+
+// ParseFormula parses and type checks a formula
+// Every error tells the position of the problem
+func ParseFormula(source string) (Formula, error) {
+
+	if strings.TrimSpace(source) == "" {
+		return Formula{}, errors.New("Formula can't be empty")
+	}
+
+	if len(source) > MaxFormulaLength {
+		return Formula{}, errors.New("Formula can't be longer than " + strconv.Itoa(MaxFormulaLength) + " characters")
+	}
+
+	tokens, err := tokenize(source)
+
+	if err != nil {
+		return Formula{}, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	root, err := p.expression(0)
+
+	if err != nil {
+		return Formula{}, err
+	}
+
+	if p.peek().kind != tokenEnd {
+		return Formula{}, p.errorf("unexpected " + p.peek().text)
+	}
+
+	if k, err := check(root); err != nil {
+		return Formula{}, err
+	} else if k != number {
+		return Formula{}, errors.New("Formula must result in a number, not a list of grades")
+	}
+
+	return Formula{source: source, root: root}, nil
+}
+
+// String return the formula as written
+func (f Formula) String() string {
+	return f.source
+}
+
+// Eval evaluates the formula with the grades of a student
+func (f Formula) Eval(input Input) (float64, error) {
+
+	if f.root == nil {
+		return 0, errors.New("Formula is empty")
+	}
+
+	result, err := f.root.eval(input)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(result.number) || math.IsInf(result.number, 0) {
+		return 0, errors.New("Formula result is not a number")
+	}
+
+	return result.number, nil
+}
+
+///////////////////
+//     LEXER     //
+///////////////////
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+	pos    int
+}
+
+var operators = []string{"<=", ">=", "==", "!=", "&&", "||", "+", "-", "*", "/", "<", ">", "!"}
+
+func tokenize(source string) ([]token, error) {
+
+	var tokens []token
+
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			number, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errors.New("Invalid number " + text + " at position " + strconv.Itoa(start+1))
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, number: number, pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToLower(string(runes[start:i])), pos: start})
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", pos: i})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+			i++
+
+		default:
+			found := false
+			for _, operator := range operators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: i})
+					i += len(operator)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, errors.New("Invalid character " + string(r) + " at position " + strconv.Itoa(i+1))
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, text: "end of formula", pos: len(runes)}), nil
+}
+
+////////////////////
+//     PARSER     //
+////////////////////
+
+// precedence of the binary operators, higher binds tighter
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(message string) error {
+	return errorAt(p.peek(), message)
+}
+
+func errorAt(t token, message string) error {
+	return errors.New("Formula error at position " + strconv.Itoa(t.pos+1) + ": " + message)
+}
+
+// expression parses binary operators by precedence climbing
+func (p *parser) expression(minPrecedence int) (node, error) {
+
+	p.depth++
+	defer func() { p.depth-- }()
+
+	if p.depth > maxDepth {
+		return nil, p.errorf("formula is nested too deep")
+	}
+
+	left, err := p.unary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		level, ok := precedence[t.text]
+
+		if t.kind != tokenOperator || !ok || level <= minPrecedence {
+			return left, nil
+		}
+
+		p.next()
+
+		right, err := p.expression(level)
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary{operator: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+
+	t := p.peek()
+
+	if t.kind == tokenOperator && (t.text == "-" || t.text == "!" || t.text == "+") {
+
+		p.next()
+
+		p.depth++
+		defer func() { p.depth-- }()
+
+		if p.depth > maxDepth {
+			return nil, p.errorf("formula is nested too deep")
+		}
+
+		operand, err := p.unary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return unaryOp{operator: t.text, operand: operand}, nil
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+
+	t := p.next()
+
+	switch t.kind {
+
+	case tokenNumber:
+		return constant(t.number), nil
+
+	case tokenOpen:
+		inner, err := p.expression(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokenClose {
+			return nil, p.errorf("expected )")
+		}
+		p.next()
+		return inner, nil
+
+	case tokenIdent:
+
+		if p.peek().kind != tokenOpen {
+			switch t.text {
+			case Exams, Lists, Projects:
+				return variable(t.text), nil
+			}
+			return nil, errorAt(t, "unknown variable "+t.text+", use exams, lists or projects")
+		}
+
+		if _, ok := functions[t.text]; !ok {
+			return nil, errorAt(t, "unknown function "+t.text)
+		}
+
+		p.next()
+
+		var args []node
+
+		if p.peek().kind != tokenClose {
+			for {
+				arg, err := p.expression(0)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.peek().kind != tokenComma {
+					break
+				}
+				p.next()
+			}
+		}
+
+		if p.peek().kind != tokenClose {
+			return nil, p.errorf("expected ) or ,")
+		}
+
+		p.next()
+
+		return call{name: t.text, args: args, pos: t.pos}, nil
+	}
+
+	if t.kind == tokenEnd {
+		return nil, errorAt(t, "formula ended unexpectedly")
+	}
+
+	return nil, errorAt(t, "unexpected "+t.text)
+}
+
+///////////////////
+//     NODES     //
+///////////////////
+
+type constant float64
+
+type variable string
+
+type unaryOp struct {
+	operator string
+	operand  node
+}
+
+type binary struct {
+	operator    string
+	left, right node
+}
+
+type call struct {
+	name string
+	args []node
+	pos  int
+}
+
+// functions tells if a function receives lists of grades (aggregates) or exactly 3 numbers (if)
+var functions = map[string]bool{
+	"avg":   true,
+	"sum":   true,
+	"min":   true,
+	"max":   true,
+	"count": true,
+	"if":    false,
+}
+
+func (c constant) eval(input Input) (value, error) {
+	return value{number: float64(c)}, nil
+}
+
+func (v variable) eval(input Input) (value, error) {
+	return value{list: input[string(v)], kind: list}, nil
+}
+
+func (u unaryOp) eval(input Input) (value, error) {
+
+	operand, err := u.operand.eval(input)
+
+	if err != nil {
+		return operand, err
+	}
+
+	switch u.operator {
+	case "-":
+		return value{number: -operand.number}, nil
+	case "!":
+		return value{number: boolean(operand.number == 0)}, nil
+	}
+
+	return operand, nil
+}
+
+func (b binary) eval(input Input) (value, error) {
+
+	left, err := b.left.eval(input)
+
+	if err != nil {
+		return left, err
+	}
+
+	// && and || only evaluate the right side when needed
+	if b.operator == "&&" && left.number == 0 {
+		return value{number: 0}, nil
+	}
+
+	if b.operator == "||" && left.number != 0 {
+		return value{number: 1}, nil
+	}
+
+	right, err := b.right.eval(input)
+
+	if err != nil {
+		return right, err
+	}
+
+	x, y := left.number, right.number
+
+	switch b.operator {
+	case "+":
+		return value{number: x + y}, nil
+	case "-":
+		return value{number: x - y}, nil
+	case "*":
+		return value{number: x * y}, nil
+	case "/":
+		if y == 0 {
+			return value{}, errors.New("Division by zero")
+		}
+		return value{number: x / y}, nil
+	case "<":
+		return value{number: boolean(x < y)}, nil
+	case "<=":
+		return value{number: boolean(x <= y)}, nil
+	case ">":
+		return value{number: boolean(x > y)}, nil
+	case ">=":
+		return value{number: boolean(x >= y)}, nil
+	case "==":
+		return value{number: boolean(math.Abs(x-y) < 1e-9)}, nil
+	case "!=":
+		return value{number: boolean(math.Abs(x-y) >= 1e-9)}, nil
+	}
+
+	// && and || with the left side already checked
+	return value{number: boolean(y != 0)}, nil
+}
+
+func (c call) eval(input Input) (value, error) {
+
+	if c.name == "if" {
+
+		condition, err := c.args[0].eval(input)
+
+		if err != nil {
+			return condition, err
+		}
+
+		if condition.number != 0 {
+			return c.args[1].eval(input)
+		}
+
+		return c.args[2].eval(input)
+	}
+
+	var grades []float64
+
+	for _, arg := range c.args {
+
+		result, err := arg.eval(input)
+
+		if err != nil {
+			return result, err
+		}
+
+		if result.kind == list {
+			grades = append(grades, result.list...)
+		} else {
+			grades = append(grades, result.number)
+		}
+	}
+
+	if c.name == "count" {
+		return value{number: float64(len(grades))}, nil
+	}
+
+	// Aggregates of no grades are zero, a student without lists has zero in lists
+	if len(grades) == 0 {
+		return value{number: 0}, nil
+	}
+
+	result := grades[0]
+
+	if c.name == "avg" || c.name == "sum" {
+		result = 0
+	}
+
+	for _, grade := range grades {
+		switch c.name {
+		case "avg", "sum":
+			result += grade
+		case "min":
+			result = math.Min(result, grade)
+		case "max":
+			result = math.Max(result, grade)
+		}
+	}
+
+	if c.name == "avg" {
+		result /= float64(len(grades))
+	}
+
+	return value{number: result}, nil
+}
+
+// check return the kind of value a node evaluates to and rejects lists used as numbers
+func check(n node) (kind, error) {
+
+	switch n := n.(type) {
+
+	case constant:
+		return number, nil
+
+	case variable:
+		return list, nil
+
+	case unaryOp:
+		k, err := check(n.operand)
+		if err != nil {
+			return k, err
+		}
+		if k != number {
+			return k, errors.New("Operator " + n.operator + " can't be used with a list of grades, use avg, sum, min or max")
+		}
+		return number, nil
+
+	case binary:
+		for _, side := range []node{n.left, n.right} {
+			k, err := check(side)
+			if err != nil {
+				return k, err
+			}
+			if k != number {
+				return k, errors.New("Operator " + n.operator + " can't be used with a list of grades, use avg, sum, min or max")
+			}
+		}
+		return number, nil
+
+	case call:
+		at := token{pos: n.pos}
+
+		if n.name == "if" && len(n.args) != 3 {
+			return number, errorAt(at, "if needs 3 arguments: if(condition, then, else)")
+		}
+
+		if n.name != "if" && len(n.args) == 0 {
+			return number, errorAt(at, n.name+" needs at least one argument")
+		}
+
+		for _, arg := range n.args {
+			k, err := check(arg)
+			if err != nil {
+				return k, err
+			}
+			if !functions[n.name] && k != number {
+				return k, errorAt(at, "if can't receive a list of grades, use avg, sum, min or max")
+			}
+		}
+
+		return number, nil
+	}
+
+	return number, errors.New("Invalid formula")
+}
+
+func boolean(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
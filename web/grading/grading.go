@@ -0,0 +1,306 @@
+package grading
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Sources of grades a component can be computed from
+const (
+	Exams    = "exams"
+	Lists    = "lists"
+	Projects = "projects"
+)
+
+// How a component combines its grades
+const (
+	Average = "average"
+	Sum     = "sum"
+)
+
+// Rounding modes of the final grade
+const (
+	Nearest = "nearest"
+	Up      = "up"
+	Down    = "down"
+)
+
+// UnB mentions, from the best to the worst
+const (
+	SS = "SS"
+	MS = "MS"
+	MM = "MM"
+	MI = "MI"
+	II = "II"
+	SR = "SR"
+)
+
+// Scheme tells how the final grade of a class is computed
+// The final grade is the weighted average of the components, or the result of Formula when there is one,
+// capped by Cap and rounded
+type Scheme struct {
+	Components []Component `json:"components"`
+	Formula    string      `json:"formula"`
+	Cap        float64     `json:"cap"`
+	Rounding   Rounding    `json:"rounding"`
+}
+
+// Component is a weighted part of the final grade, like the exams or the lists
+// Count is the number of grades expected, the missing ones count as zero
+// DropLowest grades are ignored before combining and Max caps the component value
+type Component struct {
+	Name       string  `json:"name"`
+	Source     string  `json:"source"`
+	Weight     float64 `json:"weight"`
+	Aggregate  string  `json:"aggregate"`
+	Count      int     `json:"count"`
+	DropLowest int     `json:"droplowest"`
+	Max        float64 `json:"max"`
+}
+
+// Rounding rounds the final grade to a multiple of Step, no rounding if Step is zero
+type Rounding struct {
+	Step float64 `json:"step"`
+	Mode string  `json:"mode"`
+}
+
+// Input has the grades of a student by source
+type Input map[string][]float64
+
+// Result is the final grade of a student and how it was computed
+type Result struct {
+	Final      float64           `json:"final"`
+	Mention    string            `json:"mention"`
+	Components []ComponentResult `json:"components"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// ComponentResult is the value of a component before its weight is applied
+type ComponentResult struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Weight float64 `json:"weight"`
+}
+
+// Empty tells if the class has no scheme, so no grade is computed
+func (s Scheme) Empty() bool {
+	return len(s.Components) == 0 && s.Formula == ""
+}
+
+// Validate checks the scheme before it is saved
+func (s Scheme) Validate() error {
+
+	if s.Empty() {
+		return nil
+	}
+
+	var weights float64
+
+	names := map[string]bool{}
+
+	for i, component := range s.Components {
+
+		prefix := "Component " + strconv.Itoa(i+1) + ": "
+
+		if component.Name == "" {
+			return errors.New(prefix + "name can't be empty")
+		}
+
+		if names[component.Name] {
+			return errors.New(prefix + "name " + component.Name + " is repeated")
+		}
+
+		names[component.Name] = true
+
+		switch component.Source {
+		case Exams, Lists, Projects:
+		default:
+			return errors.New(prefix + "source must be exams, lists or projects")
+		}
+
+		switch component.Aggregate {
+		case "", Average, Sum:
+		default:
+			return errors.New(prefix + "aggregate must be average or sum")
+		}
+
+		if component.Weight < 0 || component.Count < 0 || component.DropLowest < 0 || component.Max < 0 {
+			return errors.New(prefix + "weight, count, droplowest and max can't be negative")
+		}
+
+		if component.Count > 0 && component.DropLowest >= component.Count {
+			return errors.New(prefix + "droplowest must be smaller than count")
+		}
+
+		weights += component.Weight
+	}
+
+	if s.Formula != "" {
+		if _, err := ParseFormula(s.Formula); err != nil {
+			return err
+		}
+	} else if weights == 0 {
+		return errors.New("Sum of weights must be positive")
+	}
+
+	if s.Cap < 0 {
+		return errors.New("Cap can't be negative")
+	}
+
+	if s.Rounding.Step < 0 {
+		return errors.New("Rounding step can't be negative")
+	}
+
+	switch s.Rounding.Mode {
+	case "", Nearest, Up, Down:
+	default:
+		return errors.New("Rounding mode must be nearest, up or down")
+	}
+
+	return nil
+}
+
+// Compute return the final grade and mention of a student
+// A scheme that doesn't validate, or a formula that fails, gives a zero grade and the error
+func (s Scheme) Compute(input Input) Result {
+
+	result := Result{Components: []ComponentResult{}, Mention: SR}
+
+	if s.Empty() {
+		return result
+	}
+
+	if err := s.Validate(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	var total, weights float64
+
+	for _, component := range s.Components {
+
+		value := component.value(input[component.Source])
+
+		result.Components = append(result.Components, ComponentResult{
+			Name:   component.Name,
+			Value:  value,
+			Weight: component.Weight,
+		})
+
+		total += component.Weight * value
+		weights += component.Weight
+	}
+
+	if s.Formula == "" {
+		result.Final = s.Round(total / weights)
+		result.Mention = Mention(result.Final)
+		return result
+	}
+
+	formula, _ := ParseFormula(s.Formula)
+
+	final, err := formula.Eval(input)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Final = s.Round(final)
+	result.Mention = Mention(result.Final)
+
+	return result
+}
+
+// Round caps and rounds a final grade as the scheme tells
+func (s Scheme) Round(grade float64) float64 {
+
+	limit := s.Cap
+
+	if limit == 0 {
+		limit = 10
+	}
+
+	grade = math.Max(0, math.Min(grade, limit))
+
+	if s.Rounding.Step == 0 {
+		return grade
+	}
+
+	steps := grade / s.Rounding.Step
+
+	// Avoids 6.999999 being rounded down because of float precision
+	steps = math.Round(steps*1e6) / 1e6
+
+	switch s.Rounding.Mode {
+	case Up:
+		steps = math.Ceil(steps)
+	case Down:
+		steps = math.Floor(steps)
+	default:
+		steps = math.Round(steps)
+	}
+
+	return math.Round(steps*s.Rounding.Step*1e6) / 1e6
+}
+
+// Mention return the UnB mention of a final grade from 0 to 10
+func Mention(grade float64) string {
+	switch {
+	case grade >= 9:
+		return SS
+	case grade >= 7:
+		return MS
+	case grade >= 5:
+		return MM
+	case grade >= 3:
+		return MI
+	case grade > 0:
+		return II
+	default:
+		return SR
+	}
+}
+
+// Approved tells if the mention passes the class
+func Approved(mention string) bool {
+	return mention == SS || mention == MS || mention == MM
+}
+
+// value combines the grades of a component
+func (c Component) value(grades []float64) float64 {
+
+	values := append([]float64{}, grades...)
+
+	for len(values) < c.Count {
+		values = append(values, 0)
+	}
+
+	sort.Float64s(values)
+
+	if c.DropLowest > 0 {
+		if c.DropLowest >= len(values) {
+			values = nil
+		} else {
+			values = values[c.DropLowest:]
+		}
+	}
+
+	var value float64
+
+	for _, grade := range values {
+		value += grade
+	}
+
+	if c.Aggregate != Sum && len(values) > 0 {
+		value /= float64(len(values))
+	}
+
+	if c.Max > 0 {
+		value = math.Min(value, c.Max)
+	}
+
+	return value
+}
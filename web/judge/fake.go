@@ -0,0 +1,231 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FakeName is the name of the fake judge
+const FakeName = "fake"
+
+// fakeStart is the time of the first generated submission
+var fakeStart = time.Date(2019, time.March, 1, 14, 0, 0, 0, time.UTC)
+
+// Fake is a judge that never uses the network, made to test the controllers and routers
+// Users, contests and submissions that aren't given are generated from the handle and contest,
+// so the same call always has the same answer
+type Fake struct {
+	Users       map[string]User
+	Contests    map[int]Standings
+	Submissions map[string][]Submission
+	// Err is returned by every call when set
+	Err error
+	// Down makes the judge unavailable
+	Down bool
+}
+
+// NewFake return a fake judge that generates everything
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Name return "fake"
+func (f *Fake) Name() string {
+	return FakeName
+}
+
+// GetUser return the given user or one with a generated avatar
+// Handles starting with "unknown" don't exist
+func (f *Fake) GetUser(ctx context.Context, handle string) (User, error) {
+
+	if err := f.fail(); err != nil {
+		return User{}, err
+	}
+
+	if user, ok := f.Users[strings.ToLower(handle)]; ok {
+		return user, nil
+	}
+
+	if handle == "" || strings.HasPrefix(strings.ToLower(handle), "unknown") {
+		return User{}, errors.New("handles: User with handle " + handle + " not found")
+	}
+
+	return User{Handle: handle, Avatar: "https://judge.invalid/avatar/" + strings.ToLower(handle) + ".png"}, nil
+}
+
+// GetContestStandings return the rows of the handles in the given contest, or in a generated contest of 5 problems
+func (f *Fake) GetContestStandings(ctx context.Context, contestID int, handles []string) (Standings, error) {
+
+	if err := f.fail(); err != nil {
+		return Standings{}, err
+	}
+
+	contest, ok := f.Contests[contestID]
+
+	if !ok {
+		contest = f.generate(contestID, handles)
+	}
+
+	if len(handles) == 0 {
+		return contest, nil
+	}
+
+	wanted := map[string]bool{}
+
+	for _, handle := range handles {
+		wanted[strings.ToLower(handle)] = true
+	}
+
+	filtered := contest
+	filtered.Rows = nil
+
+	for _, row := range contest.Rows {
+		for _, handle := range row.Handles {
+			if wanted[strings.ToLower(handle)] {
+				filtered.Rows = append(filtered.Rows, row)
+				break
+			}
+		}
+	}
+
+	return filtered, nil
+}
+
+// GetUserSubmissions return the given submissions or an accepted one for each problem solved in the generated contests
+// Without contest only the given submissions are returned
+func (f *Fake) GetUserSubmissions(ctx context.Context, handle string, contestID int) ([]Submission, error) {
+
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+
+	if submissions, ok := f.Submissions[strings.ToLower(handle)]; ok {
+
+		var found []Submission
+
+		for _, submission := range submissions {
+			if contestID == 0 || submission.ContestID == contestID {
+				found = append(found, submission)
+			}
+		}
+
+		return found, nil
+	}
+
+	if contestID == 0 {
+		return nil, nil
+	}
+
+	var submissions []Submission
+
+	contest := f.generate(contestID, []string{handle})
+
+	for i, points := range contest.Rows[0].Points {
+
+		if points <= 0 {
+			continue
+		}
+
+		submissions = append(submissions, Submission{
+			ID:           int64(fakeHash(handle, contestID, i)),
+			ContestID:    contestID,
+			ProblemIndex: contest.Problems[i].Index,
+			Handle:       handle,
+			Verdict:      "OK",
+			Language:     "GNU C11",
+			CreatedAT:    fakeStart.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	return submissions, nil
+}
+
+// GetContestSubmissions return the submissions of each handle like GetUserSubmissions
+// Without handles only the given submissions are returned
+func (f *Fake) GetContestSubmissions(ctx context.Context, contestID int, handles []string) ([]Submission, error) {
+
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+
+	if len(handles) == 0 {
+		for handle := range f.Submissions {
+			handles = append(handles, handle)
+		}
+		sort.Strings(handles)
+	}
+
+	var found []Submission
+
+	for _, handle := range handles {
+
+		submissions, err := f.GetUserSubmissions(ctx, handle, contestID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		found = append(found, submissions...)
+	}
+
+	return found, nil
+}
+
+// ContestURL return a fake page of the contest
+func (f *Fake) ContestURL(groupID string, contestID int) string {
+	return "https://judge.invalid/group/" + groupID + "/contest/" + strconv.Itoa(contestID)
+}
+
+// Available is false while Down
+func (f *Fake) Available() bool {
+	return !f.Down
+}
+
+func (f *Fake) fail() error {
+	if f.Down {
+		return errors.New("Fake judge is down")
+	}
+	return f.Err
+}
+
+// generate return a contest where each handle solved about half of the problems
+func (f *Fake) generate(contestID int, handles []string) Standings {
+
+	contest := Standings{
+		ContestID: contestID,
+		Name:      "Contest " + strconv.Itoa(contestID),
+	}
+
+	for i := 0; i < 5; i++ {
+		index := string(rune('A' + i))
+		contest.Problems = append(contest.Problems, Problem{Index: index, Name: "Problem " + index})
+	}
+
+	for _, handle := range handles {
+
+		row := Row{Handles: []string{handle}}
+
+		for i := range contest.Problems {
+			if fakeHash(handle, contestID, i)%2 == 0 {
+				row.Points = append(row.Points, 1)
+			} else {
+				row.Points = append(row.Points, 0)
+			}
+		}
+
+		contest.Rows = append(contest.Rows, row)
+	}
+
+	return contest
+}
+
+func fakeHash(handle string, contestID, problem int) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(strings.ToLower(handle) + "/" + strconv.Itoa(contestID) + "/" + strconv.Itoa(problem)))
+	return hash.Sum32()
+}
@@ -0,0 +1,126 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Provider is an online judge where the students solve the contests of a class
+// Handles are compared ignoring case
+type Provider interface {
+	// Name of the judge, chosen by the class
+	Name() string
+	// GetUser return the profile of a handle
+	GetUser(ctx context.Context, handle string) (User, error)
+	// GetContestStandings return the standings of the handles in the contest, every handle when empty
+	GetContestStandings(ctx context.Context, contestID int, handles []string) (Standings, error)
+	// GetUserSubmissions return the submissions of a handle in the contest, in every contest when 0
+	GetUserSubmissions(ctx context.Context, handle string, contestID int) ([]Submission, error)
+	// GetContestSubmissions return the submissions of the handles in the contest, of every handle when empty
+	GetContestSubmissions(ctx context.Context, contestID int, handles []string) ([]Submission, error)
+	// ContestURL return the page of the contest inside the group of the class
+	ContestURL(groupID string, contestID int) string
+	// Available tells if the judge is answering
+	Available() bool
+}
+
+// User is the profile of a handle
+type User struct {
+	Handle string `json:"handle"`
+	Avatar string `json:"avatar"`
+}
+
+// Problem of a contest
+type Problem struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+}
+
+// Row is a participation in a contest, a handle can have many (official, unofficial, practice)
+// Points has one value per problem, in the order of the problems, a problem is solved when positive
+type Row struct {
+	Handles []string  `json:"handles"`
+	Points  []float64 `json:"points"`
+}
+
+// Standings of a contest
+type Standings struct {
+	ContestID int       `json:"contestid"`
+	Name      string    `json:"name"`
+	Problems  []Problem `json:"problems"`
+	Rows      []Row     `json:"rows"`
+}
+
+// Submission of a handle to a problem
+type Submission struct {
+	ID           int64     `json:"id"`
+	ContestID    int       `json:"contestid"`
+	ProblemIndex string    `json:"problemindex"`
+	Handle       string    `json:"handle"`
+	Verdict      string    `json:"verdict"`
+	Language     string    `json:"language"`
+	CreatedAT    time.Time `json:"createdat"`
+}
+
+// Registry finds the judge of a class, classes without judge use the default one
+type Registry struct {
+	fallback  Provider
+	providers map[string]Provider
+}
+
+// NewRegistry return a registry of the judges
+// @param	fallback		judge of classes without judge
+// @param	others			other judges that can be chosen
+// @return 	*Registry		the registry
+func NewRegistry(fallback Provider, others ...Provider) *Registry {
+
+	registry := &Registry{
+		fallback:  fallback,
+		providers: map[string]Provider{fallback.Name(): fallback},
+	}
+
+	for _, provider := range others {
+		registry.providers[provider.Name()] = provider
+	}
+
+	return registry
+}
+
+// For return the judge with the name, the default one when name is empty
+// @param	name			name of the judge
+// @return 	Provider		the judge
+// @return 	error 			unknown judge
+func (r *Registry) For(name string) (Provider, error) {
+
+	if name == "" {
+		return r.fallback, nil
+	}
+
+	if provider, ok := r.providers[strings.ToLower(name)]; ok {
+		return provider, nil
+	}
+
+	return nil, errors.New("Unknown judge " + name + ", use one of " + strings.Join(r.Names(), ", "))
+}
+
+// Default return the judge of classes without judge
+func (r *Registry) Default() Provider {
+	return r.fallback
+}
+
+// Names return the names of the judges, sorted
+func (r *Registry) Names() []string {
+
+	var names []string
+
+	for name := range r.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,182 @@
+package listgrade
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Kinds of grade curve
+const (
+	// Linear gives a grade proportional to the score
+	Linear = "linear"
+	// Power raises the score to Exponent, below 1 it favours lists partially solved
+	Power = "power"
+	// Steps gives the grade of the highest step reached
+	Steps = "steps"
+)
+
+// DefaultMax is the grade of a list fully solved when the curve doesn't tell
+const DefaultMax = 10
+
+// Curve turns the score of a student in a list, between 0 and 1, into the list grade
+type Curve struct {
+	Kind string  `json:"kind"`
+	Max  float64 `json:"max"`
+	// Full is the score that already gives the max grade, 1 when zero
+	Full     float64 `json:"full"`
+	Exponent float64 `json:"exponent"`
+	Steps    []Step  `json:"steps"`
+}
+
+// Step of a steps curve, a score of at least Score gets Grade
+type Step struct {
+	Score float64 `json:"score"`
+	Grade float64 `json:"grade"`
+}
+
+// Empty tells if the class has no curve, so its list grades aren't computed by the sync
+func (c Curve) Empty() bool {
+	return c.Kind == ""
+}
+
+// Validate checks the curve before it is saved
+func (c Curve) Validate() error {
+
+	if c.Empty() {
+		return nil
+	}
+
+	switch c.Kind {
+	case Linear, Power, Steps:
+	default:
+		return errors.New("Curve kind must be linear, power or steps")
+	}
+
+	if c.Max < 0 {
+		return errors.New("Curve max can't be negative")
+	}
+
+	if c.Full < 0 || c.Full > 1 {
+		return errors.New("Curve full must be between 0 and 1")
+	}
+
+	if c.Kind == Power && c.Exponent <= 0 {
+		return errors.New("Curve exponent must be positive")
+	}
+
+	if c.Kind == Steps && len(c.Steps) == 0 {
+		return errors.New("Curve steps can't be empty")
+	}
+
+	for i, step := range c.Steps {
+
+		prefix := "Step " + strconv.Itoa(i+1) + ": "
+
+		if step.Score < 0 || step.Score > 1 {
+			return errors.New(prefix + "score must be between 0 and 1")
+		}
+
+		if step.Grade < 0 {
+			return errors.New(prefix + "grade can't be negative")
+		}
+
+		if i > 0 && step.Score <= c.Steps[i-1].Score {
+			return errors.New(prefix + "scores must be increasing")
+		}
+	}
+
+	return nil
+}
+
+// Grade return the list grade of a score between 0 and 1, rounded to two decimals
+// An empty curve is linear up to DefaultMax
+func (c Curve) Grade(score float64) float64 {
+
+	max := c.Max
+
+	if max == 0 {
+		max = DefaultMax
+	}
+
+	full := c.Full
+
+	if full == 0 {
+		full = 1
+	}
+
+	score = math.Max(0, math.Min(1, score/full))
+
+	var grade float64
+
+	switch c.Kind {
+	case Power:
+		grade = max * math.Pow(score, c.Exponent)
+	case Steps:
+		for _, step := range c.Steps {
+			if score >= step.Score {
+				grade = step.Grade
+			}
+		}
+	default:
+		grade = max * score
+	}
+
+	return math.Round(grade*100) / 100
+}
+
+// Score return the weighted credit of the problems solved and the weighted total of the contest
+// Solved has the credit of each problem solved, problems without weight weigh 1 and weight 0 doesn't count
+// @param	problems		indexes of the problems of the contest
+// @param	solved			credit of the problems solved by index
+// @param	weights			weight of the problems by index
+// @return 	float64			weighted credit of the problems solved
+// @return 	float64			weighted total of the problems
+func Score(problems []string, solved map[string]float64, weights map[string]float64) (float64, float64) {
+
+	var credit, total float64
+
+	for _, problem := range problems {
+
+		weight, ok := Weight(weights, problem)
+
+		if !ok {
+			weight = 1
+		}
+
+		total += weight
+		credit += weight * solved[strings.ToUpper(problem)]
+	}
+
+	return credit, total
+}
+
+// Weight return the weight of the problem, indexes are compared ignoring case
+func Weight(weights map[string]float64, problem string) (float64, bool) {
+
+	for index, weight := range weights {
+		if strings.EqualFold(index, problem) {
+			return weight, true
+		}
+	}
+
+	return 0, false
+}
+
+// ValidateWeights checks the weights of the problems of a contest
+func ValidateWeights(weights map[string]float64) error {
+
+	for index, weight := range weights {
+
+		if strings.TrimSpace(index) == "" {
+			return errors.New("Problem index can't be empty")
+		}
+
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return errors.New("Weight of problem " + index + " must be a non negative number")
+		}
+	}
+
+	return nil
+}
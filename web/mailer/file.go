@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FileMailer writes every email as an .eml file inside Dir
+// When Dir is empty emails are only written in the log, useful for local development
+type FileMailer struct {
+	Dir     string
+	From    string
+	counter uint64
+}
+
+// Send stores the message instead of delivering it
+func (m *FileMailer) Send(message Message) error {
+
+	if len(message.To) == 0 {
+		return errors.New("Message without recipients")
+	}
+
+	if m.Dir == "" {
+		logrus.WithFields(logrus.Fields{
+			"to":      strings.Join(message.To, ", "),
+			"subject": message.Subject,
+		}).Info(message.Body)
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%d.eml", time.Now().UnixNano(), atomic.AddUint64(&m.counter, 1))
+
+	return ioutil.WriteFile(filepath.Join(m.Dir, name), message.Bytes(m.From), 0644)
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails to students and admins
+type Mailer interface {
+	Send(message Message) error
+}
+
+// Bytes return the message in the RFC 822 format
+func (m Message) Bytes(from string) []byte {
+
+	var buffer bytes.Buffer
+
+	fmt.Fprintf(&buffer, "From: %s\r\n", headerValue(from))
+	fmt.Fprintf(&buffer, "To: %s\r\n", headerValue(strings.Join(m.To, ", ")))
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", headerValue(m.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buffer, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buffer, "Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	fmt.Fprintf(&buffer, "\r\n%s\r\n", m.Body)
+
+	return buffer.Bytes()
+}
+
+// headerValue removes line breaks so a value can't inject new headers
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
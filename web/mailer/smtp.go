@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends emails through a SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	From     string
+}
+
+// Send delivers the message using PLAIN auth when an user is given
+func (m *SMTPMailer) Send(message Message) error {
+
+	var auth smtp.Auth
+
+	if len(message.To) == 0 {
+		return errors.New("Message without recipients")
+	}
+
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, message.To, message.Bytes(m.From))
+}
@@ -16,3 +16,25 @@ var (
 		Help: "The total time that the server is up",
 	})
 )
+
+var (
+	CodeforcesCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "codeforces_cache_hits",
+		Help: "The total number of Codeforces standings served from the cache",
+	})
+
+	CodeforcesCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "codeforces_cache_misses",
+		Help: "The total number of Codeforces standings not found in the cache",
+	})
+
+	CodeforcesCacheCoalesced = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "codeforces_cache_coalesced",
+		Help: "The total number of cache misses that waited for the same request already sent to Codeforces",
+	})
+
+	CodeforcesBreakerOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "codeforces_breaker_open",
+		Help: "1 while calls to Codeforces are stopped after too many failures",
+	})
+)
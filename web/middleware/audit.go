@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/apc-unb/apc-api/auth"
+	"github.com/apc-unb/apc-api/web/components/audit"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"github.com/sirupsen/logrus"
+)
+
+// AuditStore reads the documents changed by a request and writes the audit entries
+type AuditStore interface {
+	Snapshot(collectionName string, ids []primitive.ObjectID) (map[primitive.ObjectID]bson.M, error)
+	Record(entries []audit.Entry) error
+}
+
+// AuditRoute tells which entity a route changes
+// The ids are read from the IDField of the request body, an object or a list of objects ("id" when empty)
+// Routes with Skip record their own entries, or don't change anything
+type AuditRoute struct {
+	Entity     string
+	Collection string
+	IDField    string
+	Skip       bool
+}
+
+// statusRecorder keeps the status code sent by the handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// SetMiddlewareAudit records who created, updated or deleted what on every POST, PUT and DELETE
+// that succeeds, with the stored documents before and after the request
+// Routes are found by their path template, unknown routes are recorded with the template as entity
+// The audit never fails the request, errors are only logged
+func SetMiddlewareAudit(store AuditStore, routes map[string]AuditRoute) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			action := AuditAction(r.Method)
+
+			if action == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			template := r.URL.Path
+
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					template = t
+				}
+			}
+
+			route, ok := routes[template]
+
+			if route.Skip {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !ok {
+				route.Entity = template
+			}
+
+			var body interface{}
+
+			// Uploaded files are kept out of the log, only JSON bodies are read
+			if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") && r.Body != nil {
+				if data, err := ioutil.ReadAll(r.Body); err == nil {
+					r.Body.Close()
+					r.Body = ioutil.NopCloser(bytes.NewReader(data))
+					if json.Unmarshal(data, &body) != nil {
+						body = nil
+					}
+				}
+			}
+
+			ids := AuditIDs(body, route.IDField)
+
+			var before map[primitive.ObjectID]bson.M
+			var err error
+
+			if route.Collection != "" && len(ids) > 0 {
+				if before, err = store.Snapshot(route.Collection, ids); err != nil {
+					logrus.Errorf("Not able to read %s before the audit: %s", route.Entity, err.Error())
+				}
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusBadRequest {
+				return
+			}
+
+			entry := audit.Entry{
+				Action: action,
+				Route:  r.Method + " " + template,
+				Entity: route.Entity,
+			}
+
+			if claims, err := auth.ClaimsFromRequest(r); err == nil {
+				entry.ActorID = claims.UserID
+				entry.Role = string(claims.Role)
+			}
+
+			var entries []audit.Entry
+
+			if len(ids) == 0 || route.Collection == "" {
+				entry.After = audit.Redact(body)
+				entries = append(entries, entry)
+			} else {
+
+				var after map[primitive.ObjectID]bson.M
+
+				if after, err = store.Snapshot(route.Collection, ids); err != nil {
+					logrus.Errorf("Not able to read %s after the audit: %s", route.Entity, err.Error())
+				}
+
+				for _, id := range ids {
+
+					current := entry
+					current.EntityID = id
+
+					if document, ok := before[id]; ok {
+						current.Before = document
+					}
+
+					if document, ok := after[id]; ok {
+						current.After = document
+					}
+
+					entries = append(entries, current)
+				}
+			}
+
+			if err := store.Record(entries); err != nil {
+				logrus.Errorf("Not able to record the audit of %s: %s", entry.Route, err.Error())
+			}
+		})
+	}
+}
+
+// AuditAction return the action of a request method, empty when the method doesn't change anything
+func AuditAction(method string) string {
+	switch method {
+	case http.MethodPost:
+		return audit.Create
+	case http.MethodPut:
+		return audit.Update
+	case http.MethodDelete:
+		return audit.Delete
+	}
+	return ""
+}
+
+// AuditIDs return the ids found in field of the body, an object or a list of objects
+// Fields are matched ignoring case and "_id" is tried when field is empty
+func AuditIDs(body interface{}, field string) []primitive.ObjectID {
+
+	var ids []primitive.ObjectID
+
+	fields := []string{field}
+
+	if field == "" {
+		fields = []string{"id", "_id"}
+	}
+
+	find := func(object map[string]interface{}) {
+		for key, value := range object {
+			for _, name := range fields {
+				if !strings.EqualFold(key, name) {
+					continue
+				}
+				if hex, ok := value.(string); ok {
+					if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+						ids = append(ids, id)
+						return
+					}
+				}
+			}
+		}
+	}
+
+	switch current := body.(type) {
+	case map[string]interface{}:
+		find(current)
+	case []interface{}:
+		for _, elem := range current {
+			if object, ok := elem.(map[string]interface{}); ok {
+				find(object)
+			}
+		}
+	}
+
+	return ids
+}
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"errors"
 	"github.com/apc-unb/apc-api/auth"
 	"github.com/apc-unb/apc-api/web/utils"
 	"github.com/gorilla/mux"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 	"github.com/sirupsen/logrus"
 	"net/http"
 	"os"
@@ -18,19 +20,30 @@ func SetMiddlewareJSON() mux.MiddlewareFunc {
 	}
 }
 
-func SetMiddlewareAuthentication(secret string) mux.MiddlewareFunc {
+// SetMiddlewareAuthorization validates the request token against every policy
+// and stores the token claims in the request context for the routers
+func SetMiddlewareAuthorization(keys *auth.KeySet, isRevoked auth.RevokedFunc, policies ...auth.Policy) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
+			var claims *auth.Claims
 			var hostname string
 			var err error
 
-			if err = auth.CheckTokenStudent(r, secret); err != nil {
+			if claims, err = auth.CheckTokenStudent(r, keys, isRevoked); err != nil {
 				logrus.Infof(err.Error())
 				utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
+			for _, policy := range policies {
+				if err = policy(claims, r); err != nil {
+					logrus.Infof(err.Error())
+					utils.RespondWithError(w, http.StatusForbidden, "Forbidden")
+					return
+				}
+			}
+
 			if hostname, err = os.Hostname(); err != nil {
 				logrus.Infof(err.Error())
 				utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
@@ -38,32 +51,31 @@ func SetMiddlewareAuthentication(secret string) mux.MiddlewareFunc {
 			}
 
 			w.Header().Set("X-ContainerId", hostname)
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
 		})
 	}
 }
 
-func SetMiddlewareAuthenticationProfessor(secret string) mux.MiddlewareFunc {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+// OwnRouteVars is a policy that checks the route variables {studentid} and {classid}
+// against the user identity, so students only see their own data and monitors their own class
+// Monitors reach a {studentid} when the handler finds the student enrolled in their class
+func OwnRouteVars() auth.Policy {
+	return func(claims *auth.Claims, r *http.Request) error {
 
-			var hostname string
-			var err error
+		vars := mux.Vars(r)
 
-			if err = auth.CheckTokenProfessor(r, secret); err != nil {
-				logrus.Infof(err.Error())
-				utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
-				return
+		if hex, ok := vars["studentid"]; ok && claims.Role == auth.RoleStudent {
+			if studentID, err := primitive.ObjectIDFromHex(hex); err == nil && !claims.CanAccessStudent(studentID) {
+				return errors.New("User can't access student " + hex)
 			}
+		}
 
-			if hostname, err = os.Hostname(); err != nil {
-				logrus.Infof(err.Error())
-				utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
-				return
+		if hex, ok := vars["classid"]; ok {
+			if classID, err := primitive.ObjectIDFromHex(hex); err == nil && !claims.CanAccessClass(classID) {
+				return errors.New("User can't access class " + hex)
 			}
+		}
 
-			w.Header().Set("X-ContainerId", hostname)
-			next.ServeHTTP(w, r)
-		})
+		return nil
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Standard fonts every PDF reader has, so nothing needs to be embedded
+const (
+	Helvetica     = "F1"
+	HelveticaBold = "F2"
+	Courier       = "F3"
+	CourierBold   = "F4"
+)
+
+// A4 page size in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+var fonts = []struct {
+	name     string
+	baseFont string
+}{
+	{Helvetica, "Helvetica"},
+	{HelveticaBold, "Helvetica-Bold"},
+	{Courier, "Courier"},
+	{CourierBold, "Courier-Bold"},
+}
+
+// Document is a minimal PDF writer with text and lines on A4 pages
+// Coordinates are in points with the origin at the bottom left corner
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+// New creates an empty document
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new page, next drawings go to it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// Text writes a single line of text with its baseline at (x, y)
+func (d *Document) Text(x, y, size float64, font, text string) {
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(text))
+}
+
+// Line draws a line from (x1, y1) to (x2, y2), dashed lines are used as cut marks
+func (d *Document) Line(x1, y1, x2, y2 float64, dashed bool) {
+	if dashed {
+		fmt.Fprintf(d.page(), "[4 4] 0 d %.2f %.2f m %.2f %.2f l S [] 0 d\n", x1, y1, x2, y2)
+	} else {
+		fmt.Fprintf(d.page(), "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+	}
+}
+
+// Bytes return the whole document in the PDF 1.4 format
+func (d *Document) Bytes() []byte {
+
+	var buffer bytes.Buffer
+	var offsets []int
+
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	object := func(body string) {
+		offsets = append(offsets, buffer.Len())
+		fmt.Fprintf(&buffer, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// 1 catalog, 2 pages, then fonts, then a page and its content for each page
+	firstFont := 3
+	firstPage := firstFont + len(fonts)
+
+	buffer.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	var resources []string
+	for i, font := range fonts {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+		resources = append(resources, fmt.Sprintf("/%s %d 0 R", font.name, firstFont+i))
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, strings.Join(resources, " "), firstPage+2*i+1,
+		))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := buffer.Len()
+
+	fmt.Fprintf(&buffer, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buffer, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buffer, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buffer.Bytes()
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// escape converts the text to WinAnsi (Latin-1 for accented letters) and escapes PDF string delimiters
+// Characters out of Latin-1 become '?'
+func escape(text string) string {
+
+	var buffer bytes.Buffer
+
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buffer.WriteByte('\\')
+			buffer.WriteByte(byte(r))
+		case r == '\n' || r == '\r' || r == '\t':
+			buffer.WriteByte(' ')
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			buffer.WriteByte('?')
+		default:
+			buffer.WriteByte(byte(r))
+		}
+	}
+
+	return buffer.String()
+}
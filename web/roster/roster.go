@@ -0,0 +1,506 @@
+package roster
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Status of a row in the import report
+const (
+	StatusValid   = "valid"
+	StatusInvalid = "invalid"
+)
+
+// Action done, or to be done on dry run, with a row when the roster is synced
+const (
+	ActionCreated   = "created"
+	ActionEnrolled  = "enrolled"
+	ActionUpdated   = "updated"
+	ActionUnchanged = "unchanged"
+	ActionRemoved   = "removed"
+)
+
+// Options of an import, read from the query string
+type Options struct {
+	DryRun            bool
+	DeactivateMissing bool
+}
+
+// Mapping tells which column holds each field, by header name or by 1-based column number
+// Empty fields are found by the usual header names, or by position when the file has no header
+type Mapping struct {
+	Matricula  string `json:"matricula"`
+	Name       string `json:"name"`
+	FirstName  string `json:"firstname"`
+	LastName   string `json:"lastname"`
+	Email      string `json:"email"`
+	Codeforces string `json:"codeforces"`
+}
+
+// Row is a single person of the roster and what is wrong with it
+type Row struct {
+	ID         primitive.ObjectID `json:"id,omitempty"`
+	Line       int                `json:"line"`
+	Matricula  string             `json:"matricula"`
+	FirstName  string             `json:"firstname"`
+	LastName   string             `json:"lastname"`
+	Email      string             `json:"email"`
+	Codeforces string             `json:"codeforces"`
+	Status     string             `json:"status"`
+	Action     string             `json:"action,omitempty"`
+	Errors     []string           `json:"errors,omitempty"`
+}
+
+// Roster is the parsed file
+// Class is filled when the file starts with the SIGAA YEAR/SEASON/CLASS line
+type Roster struct {
+	Class    []string
+	Encoding string
+	Rows     []Row
+}
+
+// Report is returned to the professor before (dry run) or after the import
+// Removed has the registered people missing from the roster, they are only deactivated when asked
+type Report struct {
+	DryRun      bool   `json:"dryrun"`
+	Encoding    string `json:"encoding"`
+	Total       int    `json:"total"`
+	Valid       int    `json:"valid"`
+	Invalid     int    `json:"invalid"`
+	Created     int    `json:"created"`
+	Enrolled    int    `json:"enrolled"`
+	Updated     int    `json:"updated"`
+	Unchanged   int    `json:"unchanged"`
+	Deactivated bool   `json:"deactivated"`
+	Rows        []Row  `json:"rows"`
+	Removed     []Row  `json:"removed"`
+}
+
+var (
+	classLine       = regexp.MustCompile(`^\d{4}/\d+/\S+$`)
+	matriculaFormat = regexp.MustCompile(`^\d{5,12}$`)
+	handleFormat    = regexp.MustCompile(`^[A-Za-z0-9_.\-]{3,24}$`)
+
+	headerAliases = map[string][]string{
+		"matricula":  {"matricula", "mat", "registration"},
+		"name":       {"nome", "name", "nomecompleto", "aluno", "discente", "student"},
+		"firstname":  {"primeironome", "firstname"},
+		"lastname":   {"sobrenome", "lastname"},
+		"email":      {"email", "correio", "mail"},
+		"codeforces": {"codeforces", "handle", "cf", "codeforceshandle"},
+	}
+
+	// Columns of files without header, the SIGAA layout
+	defaultPositions = map[string]int{
+		"matricula":  0,
+		"name":       1,
+		"email":      2,
+		"codeforces": 3,
+	}
+
+	accents = strings.NewReplacer(
+		"á", "a", "à", "a", "â", "a", "ã", "a", "é", "e", "ê", "e", "í", "i",
+		"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ü", "u", "ç", "c",
+	)
+)
+
+// Parse reads a CSV or XLSX roster
+// CSV files may be UTF-8 or Latin-1 (Windows-1252) and separated by comma, semicolon or tab
+// @param	data		uploaded file
+// @param	mapping		columns of each field
+// @return 	Roster		rows already validated
+// @return 	error 		file can't be read or a mapped column doesn't exist
+func Parse(data []byte, mapping Mapping) (Roster, error) {
+
+	var roster Roster
+	var records [][]string
+	var err error
+
+	if records, roster.Encoding, err = Records(data); err != nil {
+		return roster, err
+	}
+
+	var start int
+
+	if roster.Class, start = skipClassLine(records); start == len(records) {
+		return roster, errors.New("Roster file has no rows")
+	}
+
+	columns, hasHeader, err := findColumns(records[start], mapping)
+
+	if err != nil {
+		return roster, err
+	}
+
+	if hasHeader {
+		start++
+	}
+
+	seen := map[string]int{}
+
+	for i := start; i < len(records); i++ {
+
+		if isEmpty(records[i]) {
+			continue
+		}
+
+		row := parseRow(i+1, records[i], columns)
+
+		if line, ok := seen[row.Matricula]; ok && row.Matricula != "" {
+			row.Errors = append(row.Errors, "Duplicated matricula, first seen on line "+strconv.Itoa(line))
+		} else {
+			seen[row.Matricula] = row.Line
+		}
+
+		roster.Rows = append(roster.Rows, row)
+	}
+
+	roster.updateStatus()
+
+	return roster, nil
+}
+
+// Records reads the cells of a CSV or XLSX file
+// CSV files may be UTF-8 or Latin-1 (Windows-1252) and separated by comma, semicolon or tab
+// @param	data		uploaded file
+// @return 	[][]string	cells of each line, blank lines are kept so errors show the line of the file
+// @return 	string		encoding of the file: utf-8, latin-1 or xlsx
+// @return 	error 		file can't be read
+func Records(data []byte) ([][]string, string, error) {
+
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		records, err := readXLSX(data)
+		return records, "xlsx", err
+	}
+
+	text, encoding := decode(data)
+	records, err := readCSV(text)
+
+	return records, encoding, err
+}
+
+// MarkExisting adds an error to the rows whose matricula is in existing
+func (r *Roster) MarkExisting(existing map[string]bool, message string) {
+	for i := range r.Rows {
+		if existing[r.Rows[i].Matricula] {
+			r.Rows[i].Errors = append(r.Rows[i].Errors, message)
+		}
+	}
+	r.updateStatus()
+}
+
+// Matriculas return the matricula of every row
+func (r Roster) Matriculas() []string {
+	var matriculas []string
+	for _, row := range r.Rows {
+		matriculas = append(matriculas, row.Matricula)
+	}
+	return matriculas
+}
+
+// Report counts the valid and invalid rows
+func (r Roster) Report(dryRun bool) Report {
+
+	report := Report{
+		DryRun:   dryRun,
+		Encoding: r.Encoding,
+		Total:    len(r.Rows),
+		Rows:     r.Rows,
+		Removed:  []Row{},
+	}
+
+	for _, row := range r.Rows {
+		if row.Status == StatusValid {
+			report.Valid++
+		} else {
+			report.Invalid++
+		}
+	}
+
+	return report
+}
+
+// CountActions counts the rows by action, after the sync filled them
+func (r *Report) CountActions() {
+
+	r.Created, r.Enrolled, r.Updated, r.Unchanged = 0, 0, 0, 0
+
+	for _, row := range r.Rows {
+		switch row.Action {
+		case ActionCreated:
+			r.Created++
+		case ActionEnrolled:
+			r.Enrolled++
+		case ActionUpdated:
+			r.Updated++
+		case ActionUnchanged:
+			r.Unchanged++
+		}
+	}
+}
+
+func (r *Roster) updateStatus() {
+	for i := range r.Rows {
+		if len(r.Rows[i].Errors) == 0 {
+			r.Rows[i].Status = StatusValid
+		} else {
+			r.Rows[i].Status = StatusInvalid
+		}
+	}
+}
+
+// decode return the file as UTF-8 and the encoding it was written
+func decode(data []byte) (string, string) {
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if utf8.Valid(data) {
+		return string(data), "utf-8"
+	}
+
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+
+	if err != nil {
+		return string(data), "unknown"
+	}
+
+	return string(decoded), "latin-1"
+}
+
+func readCSV(text string) ([][]string, error) {
+
+	lines := strings.Split(text, "\n")
+
+	// The delimiter is found on the first line with data, the SIGAA class line has none
+	firstLine := ""
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" && !classLine.MatchString(line) {
+			firstLine = line
+			break
+		}
+	}
+
+	delimiter := ','
+
+	for _, candidate := range []rune{';', '\t'} {
+		if strings.Count(firstLine, string(candidate)) > strings.Count(firstLine, string(delimiter)) {
+			delimiter = candidate
+		}
+	}
+
+	// Blank lines are kept as empty records, so the report shows the line of the file
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" && i < len(lines)-1 {
+			lines[i] = string(delimiter)
+		}
+	}
+
+	reader := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, errors.New("Invalid CSV file: " + err.Error())
+	}
+
+	for _, record := range records {
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+	}
+
+	return records, nil
+}
+
+// findColumns return the index of each field and if the row is a header
+func findColumns(first []string, mapping Mapping) (map[string]int, bool, error) {
+
+	columns := map[string]int{}
+	header := map[string]int{}
+
+	for i, cell := range first {
+		header[normalize(cell)] = i
+	}
+
+	hasHeader := false
+
+	for field, aliases := range headerAliases {
+		for _, alias := range aliases {
+			if i, ok := header[alias]; ok {
+				columns[field] = i
+				hasHeader = true
+				break
+			}
+		}
+	}
+
+	mapped := map[string]string{
+		"matricula":  mapping.Matricula,
+		"name":       mapping.Name,
+		"firstname":  mapping.FirstName,
+		"lastname":   mapping.LastName,
+		"email":      mapping.Email,
+		"codeforces": mapping.Codeforces,
+	}
+
+	for field, column := range mapped {
+
+		if column == "" {
+			continue
+		}
+
+		if n, err := strconv.Atoi(column); err == nil && n > 0 {
+			columns[field] = n - 1
+			continue
+		}
+
+		i, ok := header[normalize(column)]
+
+		if !ok {
+			return nil, false, errors.New("Column " + column + " of " + field + " not found in the header")
+		}
+
+		columns[field] = i
+		hasHeader = true
+	}
+
+	if !hasHeader && mapping == (Mapping{}) {
+		for field, i := range defaultPositions {
+			if i < len(first) {
+				columns[field] = i
+			}
+		}
+	}
+
+	// A first row without any digit in the matricula column is a header with unknown names
+	if i, ok := columns["matricula"]; ok && !hasHeader && i < len(first) && !strings.ContainsAny(first[i], "0123456789") {
+		hasHeader = true
+	}
+
+	if _, ok := columns["matricula"]; !ok {
+		return nil, false, errors.New("Matricula column not found")
+	}
+
+	_, hasName := columns["name"]
+	_, hasFirstName := columns["firstname"]
+
+	if !hasName && !hasFirstName {
+		return nil, false, errors.New("Name column not found")
+	}
+
+	return columns, hasHeader, nil
+}
+
+func parseRow(line int, record []string, columns map[string]int) Row {
+
+	cell := func(field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := Row{
+		Line:       line,
+		Matricula:  strings.NewReplacer("/", "", ".", "", "-", "", " ", "").Replace(cell("matricula")),
+		FirstName:  cell("firstname"),
+		LastName:   cell("lastname"),
+		Email:      cell("email"),
+		Codeforces: cell("codeforces"),
+	}
+
+	if row.FirstName == "" {
+		names := strings.Fields(cell("name"))
+		if len(names) > 0 {
+			row.FirstName = names[0]
+			row.LastName = strings.Join(names[1:], " ")
+		}
+	}
+
+	if row.Matricula == "" {
+		row.Errors = append(row.Errors, "Matricula is empty")
+	} else if !matriculaFormat.MatchString(row.Matricula) {
+		row.Errors = append(row.Errors, "Invalid matricula "+row.Matricula)
+	}
+
+	if row.FirstName == "" {
+		row.Errors = append(row.Errors, "Name is empty")
+	}
+
+	if row.Email != "" {
+		if _, err := mail.ParseAddress(row.Email); err != nil {
+			row.Errors = append(row.Errors, "Invalid email "+row.Email)
+		}
+	}
+
+	if row.Codeforces != "" && !handleFormat.MatchString(row.Codeforces) {
+		row.Errors = append(row.Errors, "Invalid codeforces handle "+row.Codeforces)
+	}
+
+	return row
+}
+
+// skipClassLine return the SIGAA YEAR/SEASON/CLASS line, if any, and the index of the first row after it
+func skipClassLine(records [][]string) ([]string, int) {
+
+	var class []string
+
+	start := 0
+
+	for start < len(records) && isEmpty(records[start]) {
+		start++
+	}
+
+	if start < len(records) {
+		for _, cell := range records[start] {
+			if classLine.MatchString(cell) {
+				class = strings.Split(cell, "/")
+				start++
+				break
+			}
+		}
+	}
+
+	for start < len(records) && isEmpty(records[start]) {
+		start++
+	}
+
+	return class, start
+}
+
+func normalize(header string) string {
+
+	header = accents.Replace(strings.ToLower(strings.TrimSpace(header)))
+
+	var buffer bytes.Buffer
+
+	for _, r := range header {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			buffer.WriteRune(r)
+		}
+	}
+
+	return buffer.String()
+}
+
+func isEmpty(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,221 @@
+package roster
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ScoreMapping tells which column holds the matricula and the score, by header name or by 1-based column number
+// Empty fields are found by the usual header names, or by position when the file has no header
+type ScoreMapping struct {
+	Matricula string `json:"matricula"`
+	Score     string `json:"score"`
+}
+
+// ScoreRow is the score of a single student and what is wrong with it
+type ScoreRow struct {
+	Line      int      `json:"line"`
+	Matricula string   `json:"matricula"`
+	Score     float64  `json:"score"`
+	Status    string   `json:"status"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// ScoreSheet is the parsed file of scores of a single exam or list
+type ScoreSheet struct {
+	Encoding string
+	Rows     []ScoreRow
+}
+
+var scoreAliases = []string{"nota", "score", "grade", "pontuacao", "valor", "resultado"}
+
+// ParseScores reads a CSV or XLSX file with the matricula and the score of each student
+// Scores may use decimal comma, like the spreadsheets exported by SIGAA
+// @param	data		uploaded file
+// @param	mapping		columns of the matricula and of the score
+// @return 	ScoreSheet	rows already validated
+// @return 	error 		file can't be read or a column doesn't exist
+func ParseScores(data []byte, mapping ScoreMapping) (ScoreSheet, error) {
+
+	var sheet ScoreSheet
+	var records [][]string
+	var start int
+	var err error
+
+	if records, sheet.Encoding, err = Records(data); err != nil {
+		return sheet, err
+	}
+
+	if _, start = skipClassLine(records); start == len(records) {
+		return sheet, errors.New("Score file has no rows")
+	}
+
+	matriculaColumn, scoreColumn, hasHeader, err := findScoreColumns(records[start], mapping)
+
+	if err != nil {
+		return sheet, err
+	}
+
+	if hasHeader {
+		start++
+	}
+
+	seen := map[string]int{}
+
+	for i := start; i < len(records); i++ {
+
+		if isEmpty(records[i]) {
+			continue
+		}
+
+		row := parseScoreRow(i+1, records[i], matriculaColumn, scoreColumn)
+
+		if line, ok := seen[row.Matricula]; ok && row.Matricula != "" {
+			row.Errors = append(row.Errors, "Duplicated matricula, first seen on line "+strconv.Itoa(line))
+		} else {
+			seen[row.Matricula] = row.Line
+		}
+
+		sheet.Rows = append(sheet.Rows, row)
+	}
+
+	sheet.UpdateStatus()
+
+	return sheet, nil
+}
+
+// UpdateStatus marks the rows without errors as valid, after more errors were added
+func (s *ScoreSheet) UpdateStatus() {
+	for i := range s.Rows {
+		if len(s.Rows[i].Errors) == 0 {
+			s.Rows[i].Status = StatusValid
+		} else {
+			s.Rows[i].Status = StatusInvalid
+		}
+	}
+}
+
+// findScoreColumns return the index of the matricula and of the score and if the row is a header
+// Without header the matricula is the first column and the score the last one
+func findScoreColumns(first []string, mapping ScoreMapping) (int, int, bool, error) {
+
+	header := map[string]int{}
+
+	for i, cell := range first {
+		header[normalize(cell)] = i
+	}
+
+	matricula, score := -1, -1
+
+	for _, alias := range headerAliases["matricula"] {
+		if i, ok := header[alias]; ok {
+			matricula = i
+			break
+		}
+	}
+
+	for _, alias := range scoreAliases {
+		if i, ok := header[alias]; ok {
+			score = i
+			break
+		}
+	}
+
+	hasHeader := matricula >= 0 || score >= 0
+
+	column := func(name, field string) (int, error) {
+
+		if n, err := strconv.Atoi(name); err == nil && n > 0 {
+			return n - 1, nil
+		}
+
+		i, ok := header[normalize(name)]
+
+		if !ok {
+			return 0, errors.New("Column " + name + " of " + field + " not found in the header")
+		}
+
+		hasHeader = true
+
+		return i, nil
+	}
+
+	var err error
+
+	if mapping.Matricula != "" {
+		if matricula, err = column(mapping.Matricula, "matricula"); err != nil {
+			return 0, 0, false, err
+		}
+	}
+
+	if mapping.Score != "" {
+		if score, err = column(mapping.Score, "score"); err != nil {
+			return 0, 0, false, err
+		}
+	}
+
+	if matricula < 0 {
+		matricula = 0
+	}
+
+	// A first row without any digit in the matricula column is a header with unknown names
+	if !hasHeader && matricula < len(first) && !strings.ContainsAny(first[matricula], "0123456789") {
+		hasHeader = true
+	}
+
+	if score < 0 {
+		if hasHeader {
+			return 0, 0, false, errors.New("Score column not found, send the score column")
+		}
+		score = len(first) - 1
+	}
+
+	if score == matricula {
+		return 0, 0, false, errors.New("Score column not found, send the score column")
+	}
+
+	return matricula, score, hasHeader, nil
+}
+
+func parseScoreRow(line int, record []string, matriculaColumn, scoreColumn int) ScoreRow {
+
+	cell := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := ScoreRow{
+		Line:      line,
+		Matricula: strings.NewReplacer("/", "", ".", "", "-", "", " ", "").Replace(cell(matriculaColumn)),
+	}
+
+	if row.Matricula == "" {
+		row.Errors = append(row.Errors, "Matricula is empty")
+	} else if !matriculaFormat.MatchString(row.Matricula) {
+		row.Errors = append(row.Errors, "Invalid matricula "+row.Matricula)
+	}
+
+	text := cell(scoreColumn)
+
+	if text == "" {
+		row.Errors = append(row.Errors, "Score is empty")
+		return row
+	}
+
+	score, err := strconv.ParseFloat(strings.Replace(text, ",", ".", 1), 64)
+
+	switch {
+	case err != nil || math.IsNaN(score) || math.IsInf(score, 0):
+		row.Errors = append(row.Errors, "Invalid score "+text)
+	case score < 0:
+		row.Errors = append(row.Errors, "Score can't be negative")
+	default:
+		row.Score = score
+	}
+
+	return row
+}
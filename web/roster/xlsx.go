@@ -0,0 +1,227 @@
+package roster
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string   `xml:"r,attr"`
+			T      string   `xml:"t,attr"`
+			V      string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// Largest sheet Excel opens, rows and columns beyond it are rejected
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+)
+
+func (t xlsxText) String() string {
+	text := t.T
+	for _, run := range t.Runs {
+		text += run.T
+	}
+	return text
+}
+
+// readXLSX return the cells of the first sheet, one record per spreadsheet row
+func readXLSX(data []byte) ([][]string, error) {
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return nil, errors.New("Invalid XLSX file: " + err.Error())
+	}
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	var shared xlsxSharedStrings
+	var worksheet xlsxWorksheet
+	var records [][]string
+
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err = readXML(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = readXML(files, firstSheet(files), &worksheet); err != nil {
+		return nil, err
+	}
+
+	for i, row := range worksheet.Rows {
+
+		line := row.R
+		if line == 0 {
+			line = i + 1
+		}
+
+		if line < 0 || line > xlsxMaxRows {
+			return nil, errors.New("Invalid XLSX file: invalid row " + strconv.Itoa(row.R))
+		}
+
+		prefix := "Invalid XLSX file: row " + strconv.Itoa(line) + ": "
+
+		for len(records) < line {
+			records = append(records, nil)
+		}
+
+		var record []string
+
+		for j, cell := range row.Cells {
+
+			column := j
+			if cell.R != "" {
+				column = columnIndex(cell.R)
+			}
+
+			if column < 0 || column >= xlsxMaxColumns {
+				return nil, errors.New(prefix + "invalid cell reference " + cell.R)
+			}
+
+			var value string
+
+			switch cell.T {
+			case "s":
+				index, err := strconv.Atoi(cell.V)
+				if err != nil || index < 0 || index >= len(shared.Items) {
+					return nil, errors.New(prefix + "invalid shared string " + cell.V)
+				}
+				value = shared.Items[index].String()
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "str", "b", "e":
+				value = cell.V
+			default:
+				// Numbers like 160140000 may be saved as 1.6014E8
+				value = cell.V
+				if number, err := strconv.ParseFloat(cell.V, 64); err == nil {
+					value = strconv.FormatFloat(number, 'f', -1, 64)
+				}
+			}
+
+			for len(record) <= column {
+				record = append(record, "")
+			}
+
+			record[column] = strings.TrimSpace(value)
+		}
+
+		records[line-1] = record
+	}
+
+	return records, nil
+}
+
+// firstSheet finds the file of the first sheet through the workbook relationships
+func firstSheet(files map[string]*zip.File) string {
+
+	var workbook xlsxWorkbook
+	var relationships xlsxRelationships
+
+	if readXML(files, "xl/workbook.xml", &workbook) != nil || len(workbook.Sheets) == 0 {
+		return "xl/worksheets/sheet1.xml"
+	}
+
+	if readXML(files, "xl/_rels/workbook.xml.rels", &relationships) != nil {
+		return "xl/worksheets/sheet1.xml"
+	}
+
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID == workbook.Sheets[0].ID {
+			if strings.HasPrefix(relationship.Target, "/") {
+				return strings.TrimPrefix(relationship.Target, "/")
+			}
+			return path.Join("xl", relationship.Target)
+		}
+	}
+
+	return "xl/worksheets/sheet1.xml"
+}
+
+func readXML(files map[string]*zip.File, name string, v interface{}) error {
+
+	file, ok := files[name]
+
+	if !ok {
+		return errors.New("Invalid XLSX file: " + name + " not found")
+	}
+
+	reader, err := file.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+
+	if err != nil {
+		return err
+	}
+
+	if err = xml.Unmarshal(data, v); err != nil {
+		return errors.New("Invalid XLSX file: " + err.Error())
+	}
+
+	return nil
+}
+
+// columnIndex converts a cell reference like AB12 to its 0-based column
+// References without column letters or beyond XFD return -1
+func columnIndex(reference string) int {
+
+	column := 0
+
+	for _, r := range reference {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if column = column*26 + int(r-'A'+1); column > xlsxMaxColumns {
+			return -1
+		}
+	}
+
+	return column - 1
+}
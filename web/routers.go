@@ -7,19 +7,37 @@ import (
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/apc-unb/apc-api/auth"
 
+	"github.com/apc-unb/apc-api/web/codeforces"
 	"github.com/apc-unb/apc-api/web/components/admin"
+	"github.com/apc-unb/apc-api/web/components/audit"
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/credential"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
 	"github.com/apc-unb/apc-api/web/components/exam"
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/components/loginAttempt"
 	"github.com/apc-unb/apc-api/web/components/news"
+	"github.com/apc-unb/apc-api/web/components/passwordReset"
+	"github.com/apc-unb/apc-api/web/components/progress"
 	"github.com/apc-unb/apc-api/web/components/project"
 	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/components/session"
 	"github.com/apc-unb/apc-api/web/components/student"
 	"github.com/apc-unb/apc-api/web/components/submission"
 	"github.com/apc-unb/apc-api/web/components/task"
 	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/apc-unb/apc-api/web/mailer"
+	"github.com/apc-unb/apc-api/web/middleware"
+	"github.com/apc-unb/apc-api/web/roster"
 	"github.com/apc-unb/apc-api/web/utils"
 	"github.com/gorilla/mux"
 	"github.com/mongodb/mongo-go-driver/bson/primitive"
@@ -31,32 +49,75 @@ import (
 
 func (s *Server) studentLogin(w http.ResponseWriter, r *http.Request) {
 
-	var UserCredentials user.UserCredentials
+	var studentLogin student.StudentLogin
 	var singleStudent student.StudentInfo
+	var enrollments []enrollment.Enrollment
+	var opened enrollment.Enrollment
 	var class schoolClass.SchoolClass
 	var newsArray []news.News
-	var userProgress interface{}
+	var userProgress progress.Summary
+	var tokens session.TokenPair
 	var err error
-	var jwt string
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err = decoder.Decode(&UserCredentials); err != nil {
+	if err = decoder.Decode(&studentLogin); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
+	UserCredentials := user.UserCredentials{
+		Matricula: studentLogin.Matricula,
+		Password:  studentLogin.Password,
+	}
+
+	if !s.checkLoginLock(w, r, UserCredentials.Matricula, "student") {
+		return
+	}
+
 	if singleStudent, err = student.AuthStudent(s.DataBase, UserCredentials, "apc_database", "student"); err != nil {
 		if err.Error() == "mongo: no documents in result" {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid Login or Password")
+			s.loginFailed(w, r, UserCredentials.Matricula, "student")
 		} else {
 			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		}
 		return
 	}
 
+	if err = loginAttempt.RegisterSuccess(s.DataBase, UserCredentials.Matricula, "student", "apc_database", "login_attempt"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if enrollments, err = enrollment.GetStudentEnrollments(s.DataBase, singleStudent.ID, "apc_database", "enrollment"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if opened, err = enrollment.Choose(enrollments, studentLogin.EnrollmentID); err != nil {
+		utils.RespondWithError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	singleStudent.ClassID = opened.ClassID
+	singleStudent.Grades = student.StudentGrades(opened.Grades)
+
+	if opened.Handles.Codeforces != "" {
+		singleStudent.Handles.Codeforces = opened.Handles.Codeforces
+	}
+
+	if singleStudent.MustChangePassword {
+		s.firstLogin(w, auth.Claims{
+			UserID:    singleStudent.ID,
+			Role:      auth.RoleStudent,
+			ClassID:   singleStudent.ClassID,
+			Matricula: singleStudent.Matricula,
+		})
+		return
+	}
+
 	if class, err = schoolClass.GetClass(s.DataBase, singleStudent.ClassID, "apc_database", "schoolClass"); err != nil {
 		if err.Error() == "mongo: no documents in result" {
 			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid student class")
@@ -75,22 +136,37 @@ func (s *Server) studentLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if userProgress, err = student.GetUserProgress(class.ContestsIDs, singleStudent.Handles.Codeforces, s.GoForces); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	// Login never fails because of the progress, the last good value is sent instead
+	if userProgress, err = progress.GetUserProgress(s.DataBase, s.judgeOf(class), class, singleStudent.ID, singleStudent.Handles.Codeforces, "apc_database"); err != nil {
+		logrus.Errorf("Not able to read the progress of student %s: %s", singleStudent.ID.Hex(), err.Error())
+		userProgress = s.Progress.Recall(class.ID, singleStudent.ID)
+	} else {
+		s.Progress.Remember(class.ID, singleStudent.ID, userProgress)
 	}
 
-	if jwt, err = auth.GenerateToken(s.JwtSecret, []string{UserCredentials.Matricula, singleStudent.ID.String()}); err != nil {
+	userProgress = progress.Mark(userProgress, s.ProgressStale, s.judgeOf(class).Available(), time.Now())
+
+	claims := auth.Claims{
+		UserID:    singleStudent.ID,
+		Role:      auth.RoleStudent,
+		ClassID:   singleStudent.ClassID,
+		Matricula: singleStudent.Matricula,
+	}
+
+	if tokens, err = s.newSession(claims); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	ret := map[string]interface{}{
-		"jwt":      jwt,
-		"student":  singleStudent,
-		"class":    class,
-		"news":     newsArray,
-		"progress": userProgress,
+		"jwt":          tokens.Jwt,
+		"refreshtoken": tokens.RefreshToken,
+		"student":      singleStudent,
+		"enrollment":   opened,
+		"enrollments":  enrollments,
+		"class":        class,
+		"news":         newsArray,
+		"progress":     userProgress,
 	}
 
 	utils.RespondWithJSON(w, http.StatusOK, ret)
@@ -111,7 +187,13 @@ func (s *Server) createStudents(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	if studentsList, err = student.CreateStudents(s.DataBase, s.GoForces, students, "apc_database", "student"); err != nil {
+	for _, singleStudent := range students {
+		if !s.authorizeClass(w, r, singleStudent.ClassID) {
+			return
+		}
+	}
+
+	if studentsList, err = student.CreateStudents(s.DataBase, s.Judges.Default(), students, s.PasswordPolicy, "apc_database", "student"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -127,20 +209,45 @@ func (s *Server) createStudents(w http.ResponseWriter, r *http.Request) {
 func (s *Server) createStudentsFile(w http.ResponseWriter, r *http.Request) {
 
 	var studentsList []user.UserCredentials
+	var report roster.Report
 	var err error
 
-	request, _ := ioutil.ReadAll(r.Body)
+	file, classID, importOptions, ok := s.readRoster(w, r)
 
-	defer r.Body.Close()
+	if !ok {
+		return
+	}
 
-	if studentsList, err = student.CreateStudentsFile(s.DataBase, string(request), "apc_database", "student"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	if report, studentsList, err = student.CreateStudentsFile(s.DataBase, file, classID, importOptions, s.PasswordPolicy, "apc_database", "student"); err != nil {
+		respondRosterError(w, report, err)
+		return
+	}
+
+	if importOptions.DryRun || report.Invalid > 0 {
+		respondRosterReport(w, report)
+		return
+	}
+
+	if report.Deactivated {
+		for _, removed := range report.Removed {
+			if _, err = session.RevokeUserSessions(s.DataBase, removed.ID, "apc_database", "session", "revoked_token"); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+
+	batchID, responded := s.deliverCredentials(w, r, studentsList, "student")
+
+	if responded {
 		return
 	}
 
 	jsonReturn := student.StudentCreatePage{
 		Result:   "success",
 		Students: studentsList,
+		Delivery: batchID,
+		Report:   &report,
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, jsonReturn)
@@ -176,6 +283,15 @@ func (s *Server) getStudentsClass(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Students see their classmates, not their grades
+	if claims, err := auth.ClaimsFromRequest(r); err != nil || claims.Role == auth.RoleStudent {
+		for i := range students {
+			students[i].Grades = student.StudentGrades{}
+			students[i].Final = 0
+			students[i].Mention = ""
+		}
+	}
+
 	utils.RespondWithJSON(w, http.StatusOK, students)
 
 }
@@ -185,7 +301,7 @@ func (s *Server) getStudentIndividualProgress(w http.ResponseWriter, r *http.Req
 	var err error
 	var studentDAO student.Student
 	var classDAO schoolClass.SchoolClass
-	var studentProgress interface{}
+	var studentProgress []progress.Contest
 
 	vars := mux.Vars(r)
 
@@ -196,6 +312,10 @@ func (s *Server) getStudentIndividualProgress(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	if !s.authorizeStudent(w, r, studentID) {
+		return
+	}
+
 	studentDAO, err = student.GetStudent(s.DataBase, studentID, "apc_database", "student")
 
 	if err != nil {
@@ -203,6 +323,22 @@ func (s *Server) getStudentIndividualProgress(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Students see the progress of the enrollment they opened at login
+	if claims, err := auth.ClaimsFromRequest(r); err == nil && claims.Role == auth.RoleStudent {
+		studentDAO.ClassID = claims.ClassID
+	}
+
+	if enrollments, err := enrollment.GetStudentEnrollments(s.DataBase, studentID, "apc_database", "enrollment"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	} else {
+		for _, classEnrollment := range enrollments {
+			if classEnrollment.ClassID == studentDAO.ClassID && classEnrollment.Handles.Codeforces != "" {
+				studentDAO.Handles.Codeforces = classEnrollment.Handles.Codeforces
+			}
+		}
+	}
+
 	classDAO, err = schoolClass.GetClass(s.DataBase, studentDAO.ClassID, "apc_database", "schoolClass")
 
 	if err != nil {
@@ -210,7 +346,7 @@ func (s *Server) getStudentIndividualProgress(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	studentProgress, err = student.GetIndividualUserProgress(classDAO.ContestsIDs, studentDAO.Handles.Codeforces, classDAO.GroupID, s.GoForces)
+	studentProgress, err = progress.GetIndividualUserProgress(s.DataBase, s.judgeOf(classDAO), classDAO, studentDAO.ID, studentDAO.Handles.Codeforces, "apc_database")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
@@ -234,7 +370,24 @@ func (s *Server) updateStudents(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	if err := student.UpdateStudents(s.DataBase, s.GoForces, studentUpdate, "apc_database", "student"); err != nil {
+	if !s.authorizeStudent(w, r, studentUpdate.ID) {
+		return
+	}
+
+	if studentUpdate.NewPassword != "" {
+		if err := s.PasswordPolicy.Check(studentUpdate.NewPassword); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	provider := s.Judges.Default()
+
+	if claims, err := auth.ClaimsFromRequest(r); err == nil && !claims.ClassID.IsZero() {
+		provider = s.classJudge(claims.ClassID)
+	}
+
+	if err := student.UpdateStudents(s.DataBase, provider, studentUpdate, s.PasswordPolicy, "apc_database", "student"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -267,6 +420,79 @@ func (s *Server) deleteStudents(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
+///////////////////////////////////////////////////////////////////////////////////////////
+// 									 ENROLLMENTS			 							 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) getStudentEnrollments(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	studentID, err := primitive.ObjectIDFromHex(vars["studentid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+		return
+	}
+
+	if !s.authorizeStudent(w, r, studentID) {
+		return
+	}
+
+	enrollments, err := enrollment.GetStudentEnrollments(s.DataBase, studentID, "apc_database", "enrollment")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, enrollments)
+}
+
+func (s *Server) updateEnrollmentStatus(w http.ResponseWriter, r *http.Request) {
+
+	var statusUpdate enrollment.StatusUpdate
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&statusUpdate); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	enrollmentDAO, err := enrollment.GetEnrollment(s.DataBase, statusUpdate.ID, "apc_database", "enrollment")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Enrollment ID")
+		return
+	}
+
+	if !s.authorizeClass(w, r, enrollmentDAO.ClassID) {
+		return
+	}
+
+	if err := enrollment.UpdateStatus(s.DataBase, statusUpdate, "apc_database", "enrollment"); err != nil {
+		if err.Error() == "Invalid enrollment status" {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	// A dropped student can't keep using the class he was logged in
+	if statusUpdate.Status == enrollment.Dropped {
+		if _, err := session.RevokeUserSessions(s.DataBase, enrollmentDAO.StudentID, "apc_database", "session", "revoked_token"); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////
 // 								   CLASS OF STUDENTS         							 //
 ///////////////////////////////////////////////////////////////////////////////////////////
@@ -284,11 +510,39 @@ func (s *Server) createClasses(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	if err := schoolClass.CreateClasses(s.DataBase, classes, "apc_database", "schoolClass"); err != nil {
+	for _, class := range classes {
+		if err := class.Grading.Validate(); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := class.ListCurve.Validate(); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if _, err := s.Judges.For(class.Judge); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ids, err := schoolClass.CreateClasses(s.DataBase, classes, "apc_database", "schoolClass")
+
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// contestsids is still accepted when creating a class, each id becomes a contest
+	for i, id := range ids {
+		if len(classes[i].ContestsIDs) == 0 {
+			continue
+		}
+		created := schoolClass.SchoolClass{ID: id, ContestsIDs: classes[i].ContestsIDs}
+		if _, err := contest.MigrateClass(s.DataBase, created, nil, "apc_database", "contest"); err != nil {
+			logrus.Errorf("Not able to create the contests of the class %s: %s", id.Hex(), err.Error())
+		}
+	}
+
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
@@ -339,233 +593,281 @@ func (s *Server) updateClass(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
 
-	if err := schoolClass.UpdateClass(s.DataBase, classDAO, "apc_database", "schoolClass"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := classDAO.Grading.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
-}
-
-func (s *Server) deleteClasses(w http.ResponseWriter, r *http.Request) {
-
-	var classes []schoolClass.SchoolClass
-
-	decoder := json.NewDecoder(r.Body)
-
-	if err := decoder.Decode(&classes); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := classDAO.ListCurve.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	defer r.Body.Close()
+	if _, err := s.Judges.For(classDAO.Judge); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if err := schoolClass.DeleteClasses(s.DataBase, classes, "apc_database", "schoolClass"); err != nil {
+	if err := schoolClass.UpdateClass(s.DataBase, classDAO, "apc_database", "schoolClass"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	if !classDAO.Grading.Empty() {
+		s.recomputeClassGrades(classDAO.ID)
+	}
 
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
-///////////////////////////////////////////////////////////////////////////////////////////
-// 								      SUBMISSION		 					     		 //
-///////////////////////////////////////////////////////////////////////////////////////////
+// judgeOf return the judge chosen by the class, the default one if the judge isn't available anymore
+func (s *Server) judgeOf(class schoolClass.SchoolClass) judge.Provider {
 
-func (s *Server) createSubmissions(w http.ResponseWriter, r *http.Request) {
+	provider, err := s.Judges.For(class.Judge)
 
-	var submissions []submission.SubmissionCreate
+	if err != nil {
+		logrus.Errorf("Class %s: %s", class.ID.Hex(), err.Error())
+		return s.Judges.Default()
+	}
 
-	decoder := json.NewDecoder(r.Body)
+	return provider
+}
 
-	if err := decoder.Decode(&submissions); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
+// classJudge return the judge of the class, the default one when the class can't be read
+func (s *Server) classJudge(classID primitive.ObjectID) judge.Provider {
 
-	defer r.Body.Close()
+	class, err := schoolClass.GetClass(s.DataBase, classID, "apc_database", "schoolClass")
 
-	if err := submission.CreateSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	if err != nil {
+		return s.Judges.Default()
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	return s.judgeOf(class)
 }
 
-func (s *Server) getSubmissions(w http.ResponseWriter, r *http.Request) {
+// getClassProgress return the progress of every active student of the class, with the solves on time and late
+func (s *Server) getClassProgress(w http.ResponseWriter, r *http.Request) {
 
-	submissions, err := submission.GetSubmissions(s.DataBase, "apc_database", "submission")
+	classID, err := primitive.ObjectIDFromHex(mux.Vars(r)["classid"])
 
 	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
 		return
 	}
-	utils.RespondWithJSON(w, http.StatusOK, submissions)
-}
-
-func (s *Server) updateSubmissions(w http.ResponseWriter, r *http.Request) {
-
-	var submissions []submission.Submission
 
-	decoder := json.NewDecoder(r.Body)
+	class, err := schoolClass.GetClass(s.DataBase, classID, "apc_database", "schoolClass")
 
-	if err := decoder.Decode(&submissions); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
 		return
 	}
 
-	defer r.Body.Close()
+	provider := s.judgeOf(class)
 
-	if err := submission.UpdateSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
+	reports, err := progress.GetClassProgress(s.DataBase, provider, class, "apc_database")
+
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	for i := range reports {
+		reports[i].Summary = progress.Mark(reports[i].Summary, s.ProgressStale, provider.Available(), time.Now())
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reports)
 }
 
-func (s *Server) deleteSubmissions(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteClasses(w http.ResponseWriter, r *http.Request) {
 
-	var submissions []submission.Submission
+	var classes []schoolClass.SchoolClass
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&submissions); err != nil {
+	if err := decoder.Decode(&classes); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := submission.DeleteSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
+	if err := schoolClass.DeleteClasses(s.DataBase, classes, "apc_database", "schoolClass"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 								        TASK		 				            		 //
+// 								        CONTEST		 					     		     //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) createTasks(w http.ResponseWriter, r *http.Request) {
+func (s *Server) createContests(w http.ResponseWriter, r *http.Request) {
 
-	var tasks []task.TaskCreate
+	var contests []contest.ContestCreate
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&tasks); err != nil {
+	if err := decoder.Decode(&contests); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := task.CreateTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	for _, current := range contests {
+		if !s.authorizeClass(w, r, current.ClassID) {
+			return
+		}
+		if err := current.Contest().Validate(); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
-}
-
-func (s *Server) getTasks(w http.ResponseWriter, r *http.Request) {
-
-	tasks, err := task.GetTasks(s.DataBase, "apc_database", "task")
-
-	if err != nil {
+	if err := contest.CreateContests(s.DataBase, contests, "apc_database", "contest"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	utils.RespondWithJSON(w, http.StatusOK, tasks)
-}
 
-func (s *Server) getTasksExam(w http.ResponseWriter, r *http.Request) {
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
 
-	vars := mux.Vars(r)
+func (s *Server) getClassContests(w http.ResponseWriter, r *http.Request) {
 
-	examID, err := primitive.ObjectIDFromHex(vars["examid"])
+	classID, err := primitive.ObjectIDFromHex(mux.Vars(r)["classid"])
 
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Exam ID")
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
 		return
 	}
 
-	tasks, err := task.GetTasksClass(s.DataBase, examID, "apc_database", "task")
+	contests, err := contest.GetClassContests(s.DataBase, classID, "apc_database", "contest")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, tasks)
-
+	utils.RespondWithJSON(w, http.StatusOK, contests)
 }
 
-func (s *Server) updateTasks(w http.ResponseWriter, r *http.Request) {
+func (s *Server) updateContests(w http.ResponseWriter, r *http.Request) {
 
-	var tasks []task.Task
+	var contests []contest.Contest
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&tasks); err != nil {
+	if err := decoder.Decode(&contests); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := task.UpdateTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
+	stored, ok := s.authorizeContests(w, r, contests)
+
+	if !ok {
+		return
+	}
+
+	for i, current := range contests {
+		if err := contest.Merge(stored[i], current).Validate(); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := contest.UpdateContests(s.DataBase, contests, "apc_database", "contest"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
-
 }
 
-func (s *Server) deleteTasks(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteContests(w http.ResponseWriter, r *http.Request) {
 
-	var tasks []task.Task
+	var contests []contest.Contest
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&tasks); err != nil {
+	if err := decoder.Decode(&contests); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := task.DeleteTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	if _, ok := s.authorizeContests(w, r, contests); !ok {
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	if err := contest.DeleteContests(s.DataBase, contests, "apc_database", "contest"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// authorizeContests checks the class of the stored contests, the class sent in the body isn't trusted
+// The stored contests are returned in the order they were sent
+func (s *Server) authorizeContests(w http.ResponseWriter, r *http.Request, contests []contest.Contest) ([]contest.Contest, bool) {
+
+	var found []contest.Contest
+
+	for _, current := range contests {
+
+		stored, err := contest.GetContest(s.DataBase, current.ID, "apc_database", "contest")
+
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Contest ID "+current.ID.Hex())
+			return nil, false
+		}
+
+		if !s.authorizeClass(w, r, stored.ClassID) {
+			return nil, false
+		}
+
+		found = append(found, stored)
+	}
 
+	return found, true
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 								        EXAM		 				            		 //
+// 								      SUBMISSION		 					     		 //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) createExams(w http.ResponseWriter, r *http.Request) {
+func (s *Server) createSubmissions(w http.ResponseWriter, r *http.Request) {
+
+	var submissions []submission.SubmissionCreate
 
-	var exams []exam.ExamCreate
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&exams); err != nil {
+	if err := decoder.Decode(&submissions); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := exam.CreateExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+	for _, current := range submissions {
+
+		if !submission.IsVerdict(current.Verdict) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid verdict "+string(current.Verdict))
+			return
+		}
+
+		if !s.authorizeClass(w, r, current.ClassID) {
+			return
+		}
+	}
+
+	if err := submission.CreateSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -573,52 +875,94 @@ func (s *Server) createExams(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
-func (s *Server) getExamsClass(w http.ResponseWriter, r *http.Request) {
-
-	vars := mux.Vars(r)
+// getSubmissions return the newest submissions of every class, up to the default limit
+func (s *Server) getSubmissions(w http.ResponseWriter, r *http.Request) {
 
-	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+	submissions, err := submission.Find(s.DataBase, submission.Filter{}, "apc_database", "submission")
 
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	utils.RespondWithJSON(w, http.StatusOK, submissions)
+}
 
-	exams, err := exam.GetExamsClass(s.DataBase, classID, "apc_database", "exam")
+// getClassSubmissions return the submissions of the class, newest first
+// Query string: studentid=<id>, contestid=<number>, problem=<index>, verdict=<verdict>, limit=<number>
+func (s *Server) getClassSubmissions(w http.ResponseWriter, r *http.Request) {
 
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	var filter submission.Filter
+	var err error
+
+	if filter.ClassID, err = primitive.ObjectIDFromHex(mux.Vars(r)["classid"]); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, exams)
+	if !s.authorizeClass(w, r, filter.ClassID) {
+		return
+	}
 
-}
+	query := r.URL.Query()
 
-func (s *Server) getExams(w http.ResponseWriter, r *http.Request) {
+	if hex := query.Get("studentid"); hex != "" {
+		if filter.StudentID, err = primitive.ObjectIDFromHex(hex); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+			return
+		}
+	}
 
-	exams, err := exam.GetExams(s.DataBase, "apc_database", "exam")
+	if text := query.Get("contestid"); text != "" {
+		if filter.ContestID, err = strconv.Atoi(text); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Contest ID")
+			return
+		}
+	}
+
+	filter.ProblemIndex = query.Get("problem")
+
+	if text := query.Get("verdict"); text != "" {
+		if filter.Verdict = submission.Verdict(strings.ToUpper(text)); !submission.IsVerdict(filter.Verdict) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid verdict")
+			return
+		}
+	}
+
+	if text := query.Get("limit"); text != "" {
+		if filter.Limit, err = strconv.ParseInt(text, 10, 64); err != nil || filter.Limit <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	submissions, err := submission.Find(s.DataBase, filter, "apc_database", "submission")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	utils.RespondWithJSON(w, http.StatusOK, exams)
+
+	utils.RespondWithJSON(w, http.StatusOK, submissions)
 }
 
-func (s *Server) updateExams(w http.ResponseWriter, r *http.Request) {
+func (s *Server) updateSubmissions(w http.ResponseWriter, r *http.Request) {
+
+	var submissions []submission.Submission
 
-	var exams []exam.Exam
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&exams); err != nil {
+	if err := decoder.Decode(&submissions); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := exam.UpdateExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+	if !s.authorizeSubmissions(w, r, submissions) {
+		return
+	}
+
+	if err := submission.UpdateSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -626,19 +970,24 @@ func (s *Server) updateExams(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
-func (s *Server) deleteExams(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteSubmissions(w http.ResponseWriter, r *http.Request) {
+
+	var submissions []submission.Submission
 
-	var exams []exam.Exam
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&exams); err != nil {
+	if err := decoder.Decode(&submissions); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := exam.DeleteExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+	if !s.authorizeSubmissions(w, r, submissions) {
+		return
+	}
+
+	if err := submission.DeleteSubmissions(s.DataBase, submissions, "apc_database", "submission"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -646,490 +995,2140 @@ func (s *Server) deleteExams(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
+// authorizeSubmissions checks the class of the stored submissions, the class sent in the body isn't trusted
+func (s *Server) authorizeSubmissions(w http.ResponseWriter, r *http.Request, submissions []submission.Submission) bool {
+
+	for _, current := range submissions {
+
+		stored, err := submission.GetSubmission(s.DataBase, current.ID, "apc_database", "submission")
+
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Submission ID "+current.ID.Hex())
+			return false
+		}
+
+		if !s.authorizeClass(w, r, stored.ClassID) {
+			return false
+		}
+	}
+
+	return true
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 								        NEWS		 				            		 //
+// 								        TASK		 				            		 //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) createNews(w http.ResponseWriter, r *http.Request) {
+func (s *Server) createTasks(w http.ResponseWriter, r *http.Request) {
 
-	var singleNews news.NewsCreate
+	var tasks []task.TaskCreate
 
-	body, _ := ioutil.ReadAll(r.Body)
+	decoder := json.NewDecoder(r.Body)
 
-	if err := json.Unmarshal(body, &singleNews); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	if err := decoder.Decode(&tasks); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := news.CreateNews(s.DataBase, singleNews, "apc_database", "news"); err != nil {
+	for _, current := range tasks {
+		if !s.authorizeExam(w, r, current.ExamID) {
+			return
+		}
+	}
+
+	if err := task.CreateTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
-
 }
 
-func (s *Server) getNews(w http.ResponseWriter, r *http.Request) {
+func (s *Server) getTasks(w http.ResponseWriter, r *http.Request) {
 
-	news, err := news.GetNews(s.DataBase, "apc_database", "news")
+	tasks, err := task.GetTasks(s.DataBase, "apc_database", "task")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	utils.RespondWithJSON(w, http.StatusOK, news)
+	utils.RespondWithJSON(w, http.StatusOK, tasks)
 }
 
-func (s *Server) getNewsClass(w http.ResponseWriter, r *http.Request) {
+func (s *Server) getTasksExam(w http.ResponseWriter, r *http.Request) {
 
 	vars := mux.Vars(r)
 
-	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+	examID, err := primitive.ObjectIDFromHex(vars["examid"])
 
 	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Exam ID")
 		return
 	}
 
-	newsArray, err := news.GetNewsClass(s.DataBase, classID, "apc_database", "news")
+	tasks, err := task.GetTasksClass(s.DataBase, examID, "apc_database", "task")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, newsArray)
+	utils.RespondWithJSON(w, http.StatusOK, tasks)
 
 }
 
-func (s *Server) updateNews(w http.ResponseWriter, r *http.Request) {
+func (s *Server) updateTasks(w http.ResponseWriter, r *http.Request) {
 
-	var singleNews news.News
+	var tasks []task.Task
 
-	body, _ := ioutil.ReadAll(r.Body)
+	decoder := json.NewDecoder(r.Body)
 
-	if err := json.Unmarshal(body, &singleNews); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	if err := decoder.Decode(&tasks); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := news.UpdateNews(s.DataBase, singleNews, "apc_database", "news"); err != nil {
+	if !s.authorizeTasks(w, r, tasks) {
+		return
+	}
+
+	if err := task.UpdateTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+
 }
 
-func (s *Server) deleteNews(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteTasks(w http.ResponseWriter, r *http.Request) {
 
-	var newsArray []news.News
+	var tasks []task.Task
 
-	body, _ := ioutil.ReadAll(r.Body)
+	decoder := json.NewDecoder(r.Body)
 
-	if err := json.Unmarshal(body, &newsArray); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	if err := decoder.Decode(&tasks); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := news.DeleteNews(s.DataBase, newsArray, "apc_database", "news"); err != nil {
+	if !s.authorizeTasks(w, r, tasks) {
+		return
+	}
+
+	if err := task.DeleteTasks(s.DataBase, tasks, "apc_database", "task"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+
+}
+
+// authorizeTasks checks the class of the exam of the stored tasks
+// A task moved to another exam must be moved to an exam the user can access too
+func (s *Server) authorizeTasks(w http.ResponseWriter, r *http.Request, tasks []task.Task) bool {
+
+	for _, current := range tasks {
+
+		stored, err := task.GetTask(s.DataBase, current.ID, "apc_database", "task")
+
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Task ID "+current.ID.Hex())
+			return false
+		}
+
+		if !s.authorizeExam(w, r, stored.ExamID) {
+			return false
+		}
+
+		if !current.ExamID.IsZero() && current.ExamID != stored.ExamID && !s.authorizeExam(w, r, current.ExamID) {
+			return false
+		}
+	}
+
+	return true
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 									 ADMINS  			 								 //
+// 								        EXAM		 				            		 //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) adminLogin(w http.ResponseWriter, r *http.Request) {
-
-	var UserCredentials user.UserCredentials
-	var singleAdmin admin.AdminInfo
-	var class schoolClass.SchoolClass
-	var newsArray []news.News
-	var jwt string
-	var err error
+func (s *Server) createExams(w http.ResponseWriter, r *http.Request) {
 
+	var exams []exam.ExamCreate
 	decoder := json.NewDecoder(r.Body)
 
-	if err = decoder.Decode(&UserCredentials); err != nil {
+	if err := decoder.Decode(&exams); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if singleAdmin, err = admin.AuthAdmin(s.DataBase, UserCredentials, "apc_database", "admin"); err != nil {
-		if err.Error() == "mongo: no documents in result" {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid Login or Password")
-		} else {
-			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	for _, singleExam := range exams {
+		if !s.authorizeClass(w, r, singleExam.ClassID) {
+			return
 		}
+	}
+
+	if err := exam.CreateExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if class, err = schoolClass.GetClass(s.DataBase, singleAdmin.ClassID, "apc_database", "schoolClass"); err != nil {
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) getExamsClass(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	exams, err := exam.GetExamsClass(s.DataBase, classID, "apc_database", "exam")
+
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if newsArray, err = news.GetNewsClass(s.DataBase, singleAdmin.ClassID, "apc_database", "news"); err != nil {
+	utils.RespondWithJSON(w, http.StatusOK, exams)
+
+}
+
+func (s *Server) getExams(w http.ResponseWriter, r *http.Request) {
+
+	exams, err := exam.GetExams(s.DataBase, "apc_database", "exam")
+
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	utils.RespondWithJSON(w, http.StatusOK, exams)
+}
 
-	scopes := []string{UserCredentials.Matricula, singleAdmin.ID.String()}
+func (s *Server) updateExams(w http.ResponseWriter, r *http.Request) {
 
-	if singleAdmin.Professor {
-		scopes = append(scopes, "professor")
+	var exams []exam.Exam
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&exams); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
 	}
 
-	if jwt, err = auth.GenerateToken(s.JwtSecret, scopes); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	defer r.Body.Close()
+
+	if !s.authorizeExams(w, r, exams) {
 		return
 	}
 
-	ret := map[string]interface{}{
-		"jwt":   jwt,
-		"admin": singleAdmin,
-		"class": class,
-		"news":  newsArray,
+	if err := exam.UpdateExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, ret)
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
 }
 
-func (s *Server) createAdmins(w http.ResponseWriter, r *http.Request) {
+func (s *Server) deleteExams(w http.ResponseWriter, r *http.Request) {
+
+	var exams []exam.Exam
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&exams); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeExams(w, r, exams) {
+		return
+	}
+
+	if err := exam.DeleteExams(s.DataBase, exams, "apc_database", "exam"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+// authorizeExams checks the class of the stored exams, the class sent in the body isn't trusted
+func (s *Server) authorizeExams(w http.ResponseWriter, r *http.Request, exams []exam.Exam) bool {
+
+	for _, current := range exams {
+		if !s.authorizeExam(w, r, current.ID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// authorizeExam responds with forbidden if the request user can't access the class of the stored exam
+func (s *Server) authorizeExam(w http.ResponseWriter, r *http.Request, examID primitive.ObjectID) bool {
+
+	stored, err := exam.GetExam(s.DataBase, examID, "apc_database", "exam")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Exam ID "+examID.Hex())
+		return false
+	}
+
+	return s.authorizeClass(w, r, stored.ClassID)
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								        NEWS		 				            		 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) createNews(w http.ResponseWriter, r *http.Request) {
+
+	var singleNews news.NewsCreate
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &singleNews); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeClass(w, r, singleNews.ClassID) {
+		return
+	}
+
+	if err := news.CreateNews(s.DataBase, singleNews, "apc_database", "news"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+
+}
+
+func (s *Server) getNews(w http.ResponseWriter, r *http.Request) {
+
+	news, err := news.GetNews(s.DataBase, "apc_database", "news")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, news)
+}
+
+func (s *Server) getNewsClass(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	newsArray, err := news.GetNewsClass(s.DataBase, classID, "apc_database", "news")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, newsArray)
+
+}
+
+func (s *Server) updateNews(w http.ResponseWriter, r *http.Request) {
+
+	var singleNews news.News
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &singleNews); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeNews(w, r, []news.News{singleNews}) {
+		return
+	}
+
+	if err := news.UpdateNews(s.DataBase, singleNews, "apc_database", "news"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) deleteNews(w http.ResponseWriter, r *http.Request) {
+
+	var newsArray []news.News
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &newsArray); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeNews(w, r, newsArray) {
+		return
+	}
+
+	if err := news.DeleteNews(s.DataBase, newsArray, "apc_database", "news"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+// authorizeNews checks the class of the stored news, the class sent in the body isn't trusted
+func (s *Server) authorizeNews(w http.ResponseWriter, r *http.Request, newsArray []news.News) bool {
+
+	for _, current := range newsArray {
+
+		stored, err := news.GetSingleNews(s.DataBase, current.ID, "apc_database", "news")
+
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid News ID "+current.ID.Hex())
+			return false
+		}
+
+		if !s.authorizeClass(w, r, stored.ClassID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 									 ADMINS  			 								 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) adminLogin(w http.ResponseWriter, r *http.Request) {
+
+	var UserCredentials user.UserCredentials
+	var singleAdmin admin.AdminInfo
+	var class schoolClass.SchoolClass
+	var newsArray []news.News
+	var tokens session.TokenPair
+	var err error
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err = decoder.Decode(&UserCredentials); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.checkLoginLock(w, r, UserCredentials.Matricula, "admin") {
+		return
+	}
+
+	if singleAdmin, err = admin.AuthAdmin(s.DataBase, UserCredentials, "apc_database", "admin"); err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			s.loginFailed(w, r, UserCredentials.Matricula, "admin")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err = loginAttempt.RegisterSuccess(s.DataBase, UserCredentials.Matricula, "admin", "apc_database", "login_attempt"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if singleAdmin.MustChangePassword {
+		claims := auth.Claims{
+			UserID:    singleAdmin.ID,
+			Role:      auth.RoleMonitor,
+			ClassID:   singleAdmin.ClassID,
+			Matricula: singleAdmin.Matricula,
+		}
+		if singleAdmin.Professor {
+			claims.Role = auth.RoleProfessor
+		}
+		s.firstLogin(w, claims)
+		return
+	}
+
+	if class, err = schoolClass.GetClass(s.DataBase, singleAdmin.ClassID, "apc_database", "schoolClass"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if newsArray, err = news.GetNewsClass(s.DataBase, singleAdmin.ClassID, "apc_database", "news"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	claims := auth.Claims{
+		UserID:    singleAdmin.ID,
+		Role:      auth.RoleMonitor,
+		ClassID:   singleAdmin.ClassID,
+		Matricula: singleAdmin.Matricula,
+	}
+
+	if singleAdmin.Professor {
+		claims.Role = auth.RoleProfessor
+	}
+
+	if tokens, err = s.newSession(claims); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ret := map[string]interface{}{
+		"jwt":          tokens.Jwt,
+		"refreshtoken": tokens.RefreshToken,
+		"admin":        singleAdmin,
+		"class":        class,
+		"news":         newsArray,
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) createAdmins(w http.ResponseWriter, r *http.Request) {
+
+	var admins []admin.AdminCreate
+	var adminsList []user.UserCredentials
+	var err error
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&admins); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if adminsList, err = admin.CreateAdmin(s.DataBase, s.Judges.Default(), admins, s.PasswordPolicy, "apc_database", "admin"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ret := map[string]interface{}{
+		"Admins": adminsList,
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, ret)
+}
+
+func (s *Server) createAdminsFile(w http.ResponseWriter, r *http.Request) {
+
+	var adminList []user.UserCredentials
+	var report roster.Report
+	var err error
+
+	file, classID, importOptions, ok := s.readRoster(w, r)
+
+	if !ok {
+		return
+	}
+
+	if report, adminList, err = admin.CreateAdminFile(s.DataBase, file, classID, importOptions, s.PasswordPolicy, "apc_database", "admin"); err != nil {
+		respondRosterError(w, report, err)
+		return
+	}
+
+	if importOptions.DryRun || report.Invalid > 0 {
+		respondRosterReport(w, report)
+		return
+	}
+
+	batchID, responded := s.deliverCredentials(w, r, adminList, "admin")
+
+	if responded {
+		return
+	}
+
+	ret := map[string]interface{}{
+		"students": adminList,
+		"report":   report,
+	}
+
+	if batchID != "" {
+		ret["delivery"] = batchID
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, ret)
+
+}
+
+func (s *Server) getAdmins(w http.ResponseWriter, r *http.Request) {
+
+	admins, err := admin.GetAdmins(s.DataBase, "apc_database", "admin")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, admins)
+}
+
+func (s *Server) getAdminsClass(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	students, err := admin.GetAdminsClass(s.DataBase, classID, "apc_database", "admin")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, students)
+
+}
+
+func (s *Server) updateAdmins(w http.ResponseWriter, r *http.Request) {
+
+	var adminUpdate admin.AdminUpdate
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&adminUpdate); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeAdmin(w, r, adminUpdate.ID) {
+		return
+	}
+
+	if adminUpdate.NewPassword != "" {
+		if err := s.PasswordPolicy.Check(adminUpdate.NewPassword); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if !adminUpdate.ClassID.IsZero() && !s.authorizeClass(w, r, adminUpdate.ClassID) {
+		return
+	}
+
+	if err := admin.UpdateAdmin(s.DataBase, s.Judges.Default(), adminUpdate, s.PasswordPolicy, "apc_database", "admin"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) updateAdminStudent(w http.ResponseWriter, r *http.Request) {
+
+	var adminUpdateStudent admin.AdminUpdateStudent
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&adminUpdateStudent); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeAdmin(w, r, adminUpdateStudent.AdminID) {
+		return
+	}
+
+	studentDAO, err := student.GetStudent(s.DataBase, adminUpdateStudent.StudentID, "apc_database", "student")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+		return
+	} else if !s.authorizeStudent(w, r, studentDAO.ID) {
+		return
+	}
+
+	if !adminUpdateStudent.ClassID.IsZero() && !s.authorizeClass(w, r, adminUpdateStudent.ClassID) {
+		return
+	}
+
+	if claims, err := auth.ClaimsFromRequest(r); err == nil {
+		adminUpdateStudent.Role = string(claims.Role)
+	}
+
+	provider := s.classJudge(studentDAO.ClassID)
+
+	if !adminUpdateStudent.ClassID.IsZero() {
+		provider = s.classJudge(adminUpdateStudent.ClassID)
+	}
+
+	if err := admin.UpdateAdminStudent(s.DataBase, provider, adminUpdateStudent, "apc_database", "student", "admin_login"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !adminUpdateStudent.ClassID.IsZero() {
+		studentDAO.ClassID = adminUpdateStudent.ClassID
+	}
+
+	s.recomputeGrades(studentDAO.ID, studentDAO.ClassID)
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+
+}
+
+func (s *Server) deleteAdmin(w http.ResponseWriter, r *http.Request) {
+
+	var adminDAO admin.Admin
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&adminDAO); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if err := admin.DeleteAdmin(s.DataBase, adminDAO, "apc_database", "admin"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								        PROJECTS		 				                 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) getProjectStudent(w http.ResponseWriter, r *http.Request) {
+
+	var studentProjects []project.Project
+	var err error
+	vars := mux.Vars(r)
+	studentID, err := primitive.ObjectIDFromHex(vars["studentid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+		return
+	}
+
+	if !s.authorizeStudent(w, r, studentID) {
+		return
+	}
+
+	if studentProjects, err = project.GetProjects(s.DataBase, studentID, "apc_database", "projects"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, studentProjects)
+
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+
+	var projectInfo project.Project
+	var projectReturn interface{}
+	var err error
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err = decoder.Decode(&projectInfo); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeStudent(w, r, projectInfo.StudentID) || !s.authorizeClass(w, r, projectInfo.ClassID) {
+		return
+	}
+
+	if projectReturn, err = project.CreateProject(s.DataBase, projectInfo, "apc_database"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	monitorReturn := map[string]interface{}{
+		"status":  "success",
+		"content": projectReturn,
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, monitorReturn)
+
+}
+
+func (s *Server) updateStatusProject(w http.ResponseWriter, r *http.Request) {
+
+	var projectInfo project.Project
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&projectInfo); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeProject(w, r, projectInfo.ID) {
+		return
+	}
+
+	if err := project.UpdateStatusProject(s.DataBase, projectInfo, "apc_database", "projects"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if projectDAO, err := project.GetProject(s.DataBase, *projectInfo.ID, "apc_database", "projects"); err == nil {
+		s.recomputeGrades(projectDAO.StudentID, projectDAO.ClassID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+
+	var projectDAO project.Project
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&projectDAO); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if !s.authorizeProject(w, r, projectDAO.ID) {
+		return
+	}
+
+	if err := project.UpdateProject(s.DataBase, projectDAO, "apc_database", "projects"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								        PROJECT TYPE		 				                 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) getProjectType(w http.ResponseWriter, r *http.Request) {
+
+	var types []project.ProjectType
+	var err error
+
+	if types, err = project.GetProjectsType(s.DataBase, "apc_database", "projectType"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, types)
+}
+
+func (s *Server) createProjectType(w http.ResponseWriter, r *http.Request) {
+
+	var projectTypeDAO project.ProjectType
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	if err := project.CreateProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) updateProjectType(w http.ResponseWriter, r *http.Request) {
+
+	var projectTypeDAO project.ProjectType
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	if err := project.UpdateProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if projectTypeDAO.ID != nil {
+		if current, err := project.GetProjectType(s.DataBase, *projectTypeDAO.ID, "apc_database", "projectType"); err == nil {
+			s.recomputeClassGrades(current.ClassID)
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+func (s *Server) deleteProjectType(w http.ResponseWriter, r *http.Request) {
+
+	var projectTypeDAO project.ProjectType
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer r.Body.Close()
+
+	var current project.ProjectType
+
+	if projectTypeDAO.ID != nil {
+		current, _ = project.GetProjectType(s.DataBase, *projectTypeDAO.ID, "apc_database", "projectType")
+	}
+
+	if err := project.DeleteProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !current.ClassID.IsZero() {
+		s.recomputeClassGrades(current.ClassID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								         GRADES		 				                     //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) getClassGrades(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	sheet, err := grade.GetClassGrades(s.DataBase, classID, "apc_database")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sheet)
+}
+
+func (s *Server) computeClassGrades(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	sheet, err := grade.RecomputeClass(s.DataBase, classID, "apc_database")
+
+	if err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sheet)
+}
+
+// computeListGrades writes the list grades of the class computed from the judge progress
+// Query string: dryRun=true only returns the report, force=true (professor only) replaces the grades overridden by hand
+func (s *Server) computeListGrades(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	if !s.authorizeClass(w, r, classID) {
+		return
+	}
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	force := query.Get("force") == "true"
+
+	if force && claims.Role != auth.RoleProfessor {
+		utils.RespondWithError(w, http.StatusForbidden, "Only a professor can replace the grades overridden by hand")
+		return
+	}
+
+	actor := audit.Entry{ActorID: claims.UserID, Role: string(claims.Role)}
+
+	report, err := grade.ComputeLists(s.DataBase, classID, force, query.Get("dryRun") == "true", actor, "apc_database")
+
+	if err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		} else {
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "report": report})
+		}
+		return
+	}
+
+	if !report.DryRun && report.Updated > 0 {
+		s.recomputeClassGrades(classID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "report": report})
+}
+
+// getGradebook exports the grades of the class
+// Query string: format=csv (default), xlsx or sigaa
+func (s *Server) getGradebook(w http.ResponseWriter, r *http.Request) {
+
+	var data []byte
+	var contentType, fileName string
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	class, err := schoolClass.GetClass(s.DataBase, classID, "apc_database", "schoolClass")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	sheet, err := grade.GetClassGrades(s.DataBase, classID, "apc_database")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := "notas-" + strconv.Itoa(class.Year) + "-" + strconv.Itoa(class.Season) + "-" + utils.FileName(class.ClassName)
+
+	switch r.URL.Query().Get("format") {
+	case "", "csv":
+		data, err = grade.GradebookCSV(sheet)
+		contentType, fileName = "text/csv; charset=utf-8", name+".csv"
+	case "xlsx":
+		data, err = grade.GradebookXLSX(sheet, class.ClassName)
+		contentType, fileName = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", name+".xlsx"
+	case "sigaa":
+		data, err = grade.GradebookSIGAA(sheet, class)
+		contentType, fileName = "text/csv; charset=windows-1252", "sigaa-"+name+".csv"
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid format, use csv, xlsx or sigaa")
+		return
+	}
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+fileName+"\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (s *Server) previewClassGrades(w http.ResponseWriter, r *http.Request) {
+
+	var scheme grading.Scheme
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&scheme); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if scheme.Empty() {
+		utils.RespondWithError(w, http.StatusBadRequest, "Grading scheme can't be empty")
+		return
+	}
+
+	if err := scheme.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sheet, err := grade.Preview(s.DataBase, classID, scheme, "apc_database")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sheet)
+}
+
+// importClassGrades sets the score of one exam or list from a CSV or XLSX file of matricula and score
+// Query string: source=exams|lists, index=<1-based number>, max=<highest score>, dryRun=true, reason=<text>
+// and the column of the matricula and of the score by header name or 1-based number
+func (s *Server) importClassGrades(w http.ResponseWriter, r *http.Request) {
+
+	var sheet roster.ScoreSheet
+	var target grade.Target
+	var report grade.ImportReport
+	var data []byte
+	var max float64
+
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	if !s.authorizeClass(w, r, classID) {
+		return
+	}
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	target.Source = query.Get("source")
+
+	if target.Index, err = strconv.Atoi(query.Get("index")); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid index of the exam or list")
+		return
+	}
+
+	if err := target.Validate(); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if text := query.Get("max"); text != "" {
+		if max, err = strconv.ParseFloat(text, 64); err != nil || max <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid maximum score")
+			return
+		}
+	}
+
+	if data, err = readUpload(r); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	mapping := roster.ScoreMapping{
+		Matricula: query.Get("matricula"),
+		Score:     query.Get("score"),
+	}
+
+	if sheet, err = roster.ParseScores(data, mapping); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	actor := audit.Entry{ActorID: claims.UserID, Role: string(claims.Role)}
+
+	if report, err = grade.ImportScores(s.DataBase, classID, sheet, target, max, query.Get("dryRun") == "true", actor, query.Get("reason"), "apc_database"); err != nil {
+		if err == grade.ErrConflict {
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		} else {
+			utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "report": report})
+		}
+		return
+	}
+
+	if report.Invalid > 0 && !report.DryRun {
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  "Score file has invalid rows, nothing was imported",
+			"report": report,
+		})
+		return
+	}
+
+	if !report.DryRun && report.Updated > 0 {
+		s.recomputeClassGrades(classID)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "report": report})
+}
+
+// getGradeHistory return the grade changes of a student in the class, oldest first
+func (s *Server) getGradeHistory(w http.ResponseWriter, r *http.Request) {
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	studentID, err := primitive.ObjectIDFromHex(vars["studentid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+		return
+	}
+
+	if !s.authorizeClass(w, r, classID) || !s.authorizeStudent(w, r, studentID) {
+		return
+	}
+
+	timeline, err := gradeHistory.GetTimeline(s.DataBase, studentID, classID, "apc_database", "gradeHistory")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, timeline)
+}
+
+// revertGradeChange puts back the grade replaced by a change of the history
+func (s *Server) revertGradeChange(w http.ResponseWriter, r *http.Request) {
+
+	var request gradeHistory.RevertRequest
+
+	vars := mux.Vars(r)
+
+	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err := decoder.Decode(&request); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if strings.TrimSpace(request.Reason) == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Reason of the revert is required")
+		return
+	}
+
+	if !s.authorizeClass(w, r, classID) {
+		return
+	}
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	change, err := gradeHistory.GetChange(s.DataBase, request.ChangeID, "apc_database", "gradeHistory")
+
+	if err != nil || change.ClassID != classID {
+		utils.RespondWithError(w, http.StatusNotFound, "Grade change not found")
+		return
+	}
+
+	changes, err := gradeHistory.Revert(s.DataBase, change, gradeHistory.Change{
+		ActorID: claims.UserID,
+		Role:    string(claims.Role),
+		Reason:  request.Reason,
+	}, "apc_database")
+
+	if err != nil {
+		switch err.Error() {
+		case "Grade changed after this change, revert the latest change first", "Only the last exam or list can be removed":
+			utils.RespondWithError(w, http.StatusConflict, err.Error())
+		default:
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	s.recomputeGrades(change.StudentID, classID)
+
+	utils.RespondWithJSON(w, http.StatusOK, changes)
+}
+
+// recomputeGrades computes again the final grade of a student after his grades changed
+// The change is already saved, so a failure here is only logged
+func (s *Server) recomputeGrades(studentID, classID primitive.ObjectID) {
+	if _, err := grade.Recompute(s.DataBase, studentID, classID, "apc_database"); err != nil {
+		logrus.Errorf("Not able to compute grade of student %s: %s", studentID.Hex(), err.Error())
+	}
+}
+
+// recomputeClassGrades computes again the final grade of the class after its scheme or projects changed
+func (s *Server) recomputeClassGrades(classID primitive.ObjectID) {
+	if _, err := grade.RecomputeClass(s.DataBase, classID, "apc_database"); err != nil {
+		logrus.Errorf("Not able to compute grades of class %s: %s", classID.Hex(), err.Error())
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								        SESSIONS		 				                 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) refreshToken(w http.ResponseWriter, r *http.Request) {
+
+	var refreshRequest session.RefreshRequest
+	var sessionDAO session.Session
+	var refreshToken string
+	var jwt string
+	var err error
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err = decoder.Decode(&refreshRequest); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if sessionDAO, refreshToken, err = session.RefreshSession(s.DataBase, refreshRequest.RefreshToken, s.RefreshTTL, "apc_database", "session"); err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	claims := auth.Claims{
+		UserID:    sessionDAO.UserID,
+		Role:      auth.Role(sessionDAO.Role),
+		ClassID:   sessionDAO.ClassID,
+		Matricula: sessionDAO.Matricula,
+		SessionID: sessionDAO.ID.Hex(),
+	}
+
+	if jwt, err = auth.GenerateToken(s.Keys, claims, s.JwtTTL); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, session.TokenPair{Jwt: jwt, RefreshToken: refreshToken})
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	sessionID, _ := primitive.ObjectIDFromHex(claims.SessionID)
+
+	if err = session.RevokeSession(s.DataBase, claims.UserID, sessionID, claims.Id, time.Unix(claims.ExpiresAt, 0), "apc_database", "session", "revoked_token"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+func (s *Server) logoutAll(w http.ResponseWriter, r *http.Request) {
+
+	var sessions int
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if sessions, err = session.RevokeUserSessions(s.DataBase, claims.UserID, "apc_database", "session", "revoked_token"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "sessions": sessions})
+}
+
+func (s *Server) getJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.RespondWithJSON(w, http.StatusOK, s.Keys.JWKS())
+}
+
+func (s *Server) changePassword(w http.ResponseWriter, r *http.Request) {
+
+	var passwordChange user.PasswordChange
+	var tokens session.TokenPair
+	var collectionName string
+	var err error
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+
+	if err = decoder.Decode(&passwordChange); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	defer r.Body.Close()
+
+	if claims.Role == auth.RoleStudent {
+		collectionName = "student_login"
+	} else {
+		collectionName = "admin_login"
+	}
+
+	if err = user.ChangePassword(s.DataBase, claims.UserID, passwordChange, s.PasswordPolicy, "apc_database", collectionName); err != nil {
+		if err.Error() == "Invalid Password" {
+			utils.RespondWithError(w, http.StatusUnauthorized, err.Error())
+		} else {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	if _, err = session.RevokeUserSessions(s.DataBase, claims.UserID, "apc_database", "session", "revoked_token"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	newClaims := auth.Claims{
+		UserID:    claims.UserID,
+		Role:      claims.Role,
+		ClassID:   claims.ClassID,
+		Matricula: claims.Matricula,
+	}
+
+	if tokens, err = s.newSession(newClaims); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tokens)
+}
+
+// firstLogin responds with a token that can only change the generated password
+// No refresh token is given, the user gets a full session after the change
+func (s *Server) firstLogin(w http.ResponseWriter, claims auth.Claims) {
+
+	claims.MustChangePassword = true
+
+	jwt, err := auth.GenerateToken(s.Keys, claims, s.JwtTTL)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"jwt":                jwt,
+		"mustchangepassword": true,
+	})
+}
+
+// newSession creates a session for the authenticated user and return its access and refresh tokens
+func (s *Server) newSession(claims auth.Claims) (session.TokenPair, error) {
+
+	var tokens session.TokenPair
+	var sessionDAO session.Session
+	var err error
+
+	sessionDAO = session.Session{
+		UserID:    claims.UserID,
+		ClassID:   claims.ClassID,
+		Role:      string(claims.Role),
+		Matricula: claims.Matricula,
+	}
+
+	if sessionDAO, tokens.RefreshToken, err = session.CreateSession(s.DataBase, sessionDAO, s.RefreshTTL, "apc_database", "session"); err != nil {
+		return tokens, err
+	}
+
+	claims.SessionID = sessionDAO.ID.Hex()
+
+	if tokens.Jwt, err = auth.GenerateToken(s.Keys, claims, s.JwtTTL); err != nil {
+		return tokens, err
+	}
+
+	return tokens, nil
+}
+
+// isTokenRevoked checks the token id and its session against the revoked tokens
+func (s *Server) isTokenRevoked(claims *auth.Claims) (bool, error) {
+	return session.IsRevoked(s.DataBase, claims.Id, claims.SessionID, "apc_database", "revoked_token")
+}
+
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								     LOGIN ATTEMPTS		 				                 //
+///////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) unlockLogin(w http.ResponseWriter, r *http.Request) {
 
-	var admins []admin.AdminCreate
-	var adminsList []user.UserCredentials
+	var unlockRequest loginAttempt.UnlockRequest
+	var unlocked bool
 	var err error
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&admins); err != nil {
+	if err = decoder.Decode(&unlockRequest); err != nil || unlockRequest.Matricula == "" {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if adminsList, err = admin.CreateAdmin(s.DataBase, s.GoForces, admins, "apc_database", "admin"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	if unlockRequest.CollectionName != "student" && unlockRequest.CollectionName != "admin" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid collection, must be student or admin")
 		return
 	}
 
-	ret := map[string]interface{}{
-		"Admins": adminsList,
+	if unlocked, err = loginAttempt.Unlock(s.DataBase, unlockRequest, "apc_database", "login_attempt"); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, ret)
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "unlocked": unlocked})
 }
 
-func (s *Server) createAdminsFile(w http.ResponseWriter, r *http.Request) {
-
-	var adminList []user.UserCredentials
-	var err error
-
-	request, _ := ioutil.ReadAll(r.Body)
+// checkLoginLock responds with too many requests if the matricula or the request IP is locked
+func (s *Server) checkLoginLock(w http.ResponseWriter, r *http.Request, matricula, collectionName string) bool {
 
-	defer r.Body.Close()
+	retryAfter, err := loginAttempt.RetryAfter(s.DataBase, matricula, utils.ClientIP(r, s.TrustProxy), collectionName, "apc_database", "login_attempt")
 
-	if adminList, err = admin.CreateAdminFile(s.DataBase, string(request), "apc_database", "student"); err != nil {
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+		return false
 	}
 
-	ret := map[string]interface{}{
-		"students": adminList,
+	if retryAfter > 0 {
+		respondTooManyAttempts(w, retryAfter)
+		return false
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, ret)
-
+	return true
 }
 
-func (s *Server) getAdmins(w http.ResponseWriter, r *http.Request) {
+// loginFailed counts the wrong password and responds with unauthorized,
+// or with too many requests if this failure locked the account
+func (s *Server) loginFailed(w http.ResponseWriter, r *http.Request, matricula, collectionName string) {
 
-	admins, err := admin.GetAdmins(s.DataBase, "apc_database", "admin")
+	retryAfter, err := loginAttempt.RegisterFailure(s.DataBase, matricula, utils.ClientIP(r, s.TrustProxy), collectionName, "apc_database", "login_attempt")
 
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, admins)
+	if retryAfter > 0 {
+		respondTooManyAttempts(w, retryAfter)
+		return
+	}
+
+	utils.RespondWithError(w, http.StatusUnauthorized, "Invalid Login or Password")
 }
 
-func (s *Server) getAdminsClass(w http.ResponseWriter, r *http.Request) {
+func respondTooManyAttempts(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(retryAfter.Seconds() + 0.999)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	utils.RespondWithError(w, http.StatusTooManyRequests, "Too many login attempts, try again in "+strconv.Itoa(seconds)+" seconds")
+}
 
-	vars := mux.Vars(r)
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								        ROSTER		 				                     //
+///////////////////////////////////////////////////////////////////////////////////////////
 
-	classID, err := primitive.ObjectIDFromHex(vars["classid"])
+// readRoster parses the uploaded roster, sent as the "file" field of a multipart form or as the raw body
+// Query string: dryRun=true, deactivateMissing=true, classid=<id> and the column of each field
+// (matricula, name, firstname, lastname, email, codeforces) by header name or 1-based number
+func (s *Server) readRoster(w http.ResponseWriter, r *http.Request) (file roster.Roster, classID primitive.ObjectID, importOptions roster.Options, ok bool) {
 
-	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Class ID")
-		return
+	var data []byte
+	var err error
+
+	defer r.Body.Close()
+
+	query := r.URL.Query()
+
+	if hex := query.Get("classid"); hex != "" {
+		if classID, err = primitive.ObjectIDFromHex(hex); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid class ID")
+			return file, classID, importOptions, false
+		}
+		if !s.authorizeClass(w, r, classID) {
+			return file, classID, importOptions, false
+		}
 	}
 
-	students, err := admin.GetAdminsClass(s.DataBase, classID, "apc_database", "admin")
+	importOptions = roster.Options{
+		DryRun:            query.Get("dryRun") == "true",
+		DeactivateMissing: query.Get("deactivateMissing") == "true",
+	}
 
-	if err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	if data, err = readUpload(r); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return file, classID, importOptions, false
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, students)
+	mapping := roster.Mapping{
+		Matricula:  query.Get("matricula"),
+		Name:       query.Get("name"),
+		FirstName:  query.Get("firstname"),
+		LastName:   query.Get("lastname"),
+		Email:      query.Get("email"),
+		Codeforces: query.Get("codeforces"),
+	}
+
+	if file, err = roster.Parse(data, mapping); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return file, classID, importOptions, false
+	}
 
+	return file, classID, importOptions, true
 }
 
-func (s *Server) updateAdmins(w http.ResponseWriter, r *http.Request) {
+// readUpload return the file sent as the "file" field of a multipart form or as the raw body
+func readUpload(r *http.Request) ([]byte, error) {
 
-	var adminUpdate admin.AdminUpdate
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return ioutil.ReadAll(r.Body)
+	}
 
-	decoder := json.NewDecoder(r.Body)
+	upload, _, err := r.FormFile("file")
 
-	if err := decoder.Decode(&adminUpdate); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
+	if err != nil {
+		return nil, err
 	}
 
-	defer r.Body.Close()
+	defer upload.Close()
 
-	if err := admin.UpdateAdmin(s.DataBase, s.GoForces, adminUpdate, "apc_database", "admin"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	return ioutil.ReadAll(upload)
+}
+
+// respondRosterReport answers a dry run, or an import refused because of invalid rows
+func respondRosterReport(w http.ResponseWriter, report roster.Report) {
+
+	if report.Invalid > 0 && !report.DryRun {
+		utils.RespondWithJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"error":  "Roster has invalid rows, nothing was imported",
+			"report": report,
+		})
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"result": "success", "report": report})
 }
 
-func (s *Server) updateAdminStudent(w http.ResponseWriter, r *http.Request) {
+func respondRosterError(w http.ResponseWriter, report roster.Report, err error) {
+	switch err.Error() {
+	case "mongo: no documents in result":
+		utils.RespondWithError(w, http.StatusBadRequest, "Class of the roster not found")
+	case "YEAR/SEASON/CLASSNAME header error":
+		utils.RespondWithError(w, http.StatusBadRequest, "Send the classid or a YEAR/SEASON/CLASS line in the roster")
+	default:
+		utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{"error": err.Error(), "report": report})
+	}
+}
 
-	var adminUpdateStudent admin.AdminUpdateStudent
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								         AUDIT		 				                     //
+///////////////////////////////////////////////////////////////////////////////////////////
 
-	decoder := json.NewDecoder(r.Body)
+// auditRoutes tells the audit middleware the entity changed by each mutating route
+var auditRoutes = map[string]middleware.AuditRoute{
+	"/logout":                         {Skip: true},
+	"/logout/all":                     {Skip: true},
+	"/student":                        {Entity: "student", Collection: "student"},
+	"/student/file":                   {Entity: "student", Collection: "student"},
+	"/enrollment":                     {Entity: "enrollment", Collection: "enrollment"},
+	"/grade/{classid}":                {Skip: true},
+	"/grade/{classid}/preview":        {Skip: true},
+	"/grade/{classid}/import":         {Skip: true},
+	"/grade/{classid}/lists":          {Skip: true},
+	"/grade/{classid}/history/revert": {Skip: true},
+	"/admin":                          {Entity: "admin", Collection: "admin"},
+	"/admin/file":                     {Entity: "admin", Collection: "admin"},
+	"/admin/student":                  {Entity: "student", Collection: "student", IDField: "studentid"},
+	"/admin/unlock":                   {Entity: "loginAttempt"},
+	"/submission":                     {Entity: "submission", Collection: "submission"},
+	"/contest":                        {Entity: "contest", Collection: "contest"},
+	"/task":                           {Entity: "task", Collection: "task"},
+	"/exam":                           {Entity: "exam", Collection: "exam"},
+	"/news":                           {Entity: "news", Collection: "news"},
+	"/class":                          {Entity: "schoolClass", Collection: "schoolClass"},
+	"/project":                        {Entity: "project", Collection: "projects"},
+	"/project/status":                 {Entity: "project", Collection: "projects"},
+	"/project/type":                   {Entity: "projectType", Collection: "projectType"},
+}
 
-	if err := decoder.Decode(&adminUpdateStudent); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
-	}
+// getAuditLog return the audit log, newest first
+// Query string: actorid=<id>, entity=<name>, entityid=<id>, from and to as RFC 3339 dates, limit=<number>
+func (s *Server) getAuditLog(w http.ResponseWriter, r *http.Request) {
 
-	defer r.Body.Close()
+	var filter audit.Filter
+	var err error
 
-	if err := admin.UpdateAdminStudent(s.DataBase, s.GoForces, adminUpdateStudent, "apc_database", "student", "admin_login"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
+	query := r.URL.Query()
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	if hex := query.Get("actorid"); hex != "" {
+		if filter.ActorID, err = primitive.ObjectIDFromHex(hex); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Actor ID")
+			return
+		}
+	}
 
-}
+	if hex := query.Get("entityid"); hex != "" {
+		if filter.EntityID, err = primitive.ObjectIDFromHex(hex); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid Entity ID")
+			return
+		}
+	}
 
-func (s *Server) deleteAdmin(w http.ResponseWriter, r *http.Request) {
+	filter.Entity = query.Get("entity")
 
-	var adminDAO admin.Admin
+	if text := query.Get("from"); text != "" {
+		if filter.From, err = time.Parse(time.RFC3339, text); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid from date, use RFC 3339")
+			return
+		}
+	}
 
-	decoder := json.NewDecoder(r.Body)
+	if text := query.Get("to"); text != "" {
+		if filter.To, err = time.Parse(time.RFC3339, text); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid to date, use RFC 3339")
+			return
+		}
+	}
 
-	if err := decoder.Decode(&adminDAO); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
+	if text := query.Get("limit"); text != "" {
+		if filter.Limit, err = strconv.ParseInt(text, 10, 64); err != nil || filter.Limit <= 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
 	}
 
-	defer r.Body.Close()
+	entries, err := audit.Find(s.DataBase, filter, "apc_database", "audit")
 
-	if err := admin.DeleteAdmin(s.DataBase, adminDAO, "apc_database", "admin"); err != nil {
+	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	utils.RespondWithJSON(w, http.StatusOK, entries)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 								        PROJECTS		 				                 //
+// 								      CREDENTIALS		 				                 //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) getProjectStudent(w http.ResponseWriter, r *http.Request) {
+func (s *Server) getCredentialDeliveries(w http.ResponseWriter, r *http.Request) {
 
-	var studentProjects []project.Project
+	var deliveries []credential.Delivery
+	var batchID primitive.ObjectID
 	var err error
-	vars := mux.Vars(r)
-	studentID, err := primitive.ObjectIDFromHex(vars["studentid"])
 
-	if err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Student ID")
+	params := mux.Vars(r)
+
+	if batchID, err = primitive.ObjectIDFromHex(params["batchid"]); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid batch ID")
 		return
 	}
 
-	if studentProjects, err = project.GetProjects(s.DataBase, studentID, "apc_database", "projects"); err != nil {
+	if deliveries, err = credential.GetDeliveries(s.DataBase, batchID, "apc_database", "credential_delivery"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, studentProjects)
-
+	utils.RespondWithJSON(w, http.StatusOK, deliveries)
 }
 
-func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+// deliverCredentials hands out the credentials of an import as asked in the query string
+// ?email=true queues an email to each user and return the batch id to follow the deliveries
+// ?slips=pdf responds with the printable slips instead of JSON, then responded is true
+func (s *Server) deliverCredentials(w http.ResponseWriter, r *http.Request, credentials []user.UserCredentials, collectionName string) (batchID string, responded bool) {
 
-	var projectInfo project.Project
-	var projectReturn interface{}
+	var recipients map[primitive.ObjectID]credential.Recipient
+	var deliveries []credential.Delivery
 	var err error
 
-	decoder := json.NewDecoder(r.Body)
+	query := r.URL.Query()
+	sendEmail := query.Get("email") == "true"
+	printSlips := query.Get("slips") == "pdf"
 
-	if err = decoder.Decode(&projectInfo); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
-		return
+	if !sendEmail && !printSlips {
+		return "", false
 	}
 
-	defer r.Body.Close()
-
-	if projectReturn, err = project.CreateProject(s.DataBase, projectInfo, "apc_database"); err != nil {
+	if recipients, err = credential.GetRecipients(s.DataBase, credentials, "apc_database", collectionName); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+		return "", true
 	}
 
-	monitorReturn := map[string]interface{}{
-		"status":  "success",
-		"content": projectReturn,
+	if sendEmail && len(credentials) > 0 {
+
+		if deliveries, err = credential.QueueDeliveries(s.DataBase, credentials, recipients, collectionName, "apc_database", "credential_delivery"); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return "", true
+		}
+
+		messages := map[primitive.ObjectID]mailer.Message{}
+
+		for _, c := range credentials {
+			messages[c.ID] = mailer.Message{
+				Subject: "DraGonT - Dados de acesso",
+				Body: "Olá " + recipients[c.ID].FirstName + ",\n\n" +
+					"Seu acesso ao DraGonT foi criado:\n\n" +
+					"Matrícula: " + c.Matricula + "\n" +
+					"Senha temporária: " + c.Password + "\n\n" +
+					"Acesse " + s.LoginURL + " e troque a senha no primeiro acesso.",
+			}
+		}
+
+		go credential.SendDeliveries(s.DataBase, s.Mailer, deliveries, messages, "apc_database", "credential_delivery")
+
+		batchID = deliveries[0].BatchID.Hex()
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, monitorReturn)
+	if printSlips {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"credenciais.pdf\"")
+		if batchID != "" {
+			w.Header().Set("X-Delivery-Batch", batchID)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write(credential.SlipsPDF("DraGonT - APC", credential.BuildSlips(credentials, recipients)))
+		return batchID, true
+	}
 
+	return batchID, false
 }
 
-func (s *Server) updateStatusProject(w http.ResponseWriter, r *http.Request) {
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								     PASSWORD RESET		 				                 //
+///////////////////////////////////////////////////////////////////////////////////////////
 
-	var projectInfo project.Project
+func (s *Server) forgotStudentPassword(w http.ResponseWriter, r *http.Request) {
+	s.forgotPassword(w, r, "student")
+}
+
+func (s *Server) forgotAdminPassword(w http.ResponseWriter, r *http.Request) {
+	s.forgotPassword(w, r, "admin")
+}
+
+// forgotPassword emails a reset link to the user of that matricula
+// It always answers with success so nobody can find out which matriculas exist
+// The token and the email are made after the answer, so its timing doesn't tell it either
+func (s *Server) forgotPassword(w http.ResponseWriter, r *http.Request, collectionName string) {
+
+	var forgotRequest passwordReset.ForgotRequest
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&projectInfo); err != nil {
+	if err := decoder.Decode(&forgotRequest); err != nil || forgotRequest.Matricula == "" {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := project.UpdateStatusProject(s.DataBase, projectInfo, "apc_database", "projects"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	go s.sendResetToken(forgotRequest.Matricula, collectionName)
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
+}
+
+// sendResetToken creates a reset token for the matricula and emails it, errors are only logged
+func (s *Server) sendResetToken(matricula, collectionName string) {
+
+	recipient, token, err := passwordReset.CreateResetToken(s.DataBase, matricula, s.ResetTTL, "apc_database", collectionName, "password_reset")
+
+	if err != nil {
+		logrus.Infof("Password reset of %s not sent: %s", matricula, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	message := mailer.Message{
+		To:      []string{recipient.Email},
+		Subject: "DraGonT - Redefinição de senha",
+		Body: "Olá " + recipient.FirstName + ",\n\n" +
+			"Para redefinir sua senha acesse o link abaixo em até " + s.ResetTTL.String() + ":\n\n" +
+			s.ResetURL + "?token=" + token + "\n\n" +
+			"Se você não pediu a redefinição, ignore este email.",
+	}
+
+	if err = s.Mailer.Send(message); err != nil {
+		logrus.Errorf("Password reset of %s not sent: %s", matricula, err.Error())
+	}
 }
 
-func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+func (s *Server) resetPassword(w http.ResponseWriter, r *http.Request) {
 
-	var projectDAO project.Project
+	var resetRequest passwordReset.ResetRequest
+	var resetToken passwordReset.ResetToken
+	var err error
 
 	decoder := json.NewDecoder(r.Body)
 
-	if err := decoder.Decode(&projectDAO); err != nil {
+	if err = decoder.Decode(&resetRequest); err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
 	defer r.Body.Close()
 
-	if err := project.UpdateProject(s.DataBase, projectDAO, "apc_database", "projects"); err != nil {
+	if resetToken, err = passwordReset.ResetPassword(s.DataBase, resetRequest, s.PasswordPolicy, "apc_database", "password_reset"); err != nil {
+		if err.Error() == "mongo: no documents in result" {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+		} else {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	if _, err = session.RevokeUserSessions(s.DataBase, resetToken.UserID, "apc_database", "session", "revoked_token"); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"result": "success"})
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
-// 								        PROJECT TYPE		 				                 //
+// 								      AUTHORIZATION		 				                 //
 ///////////////////////////////////////////////////////////////////////////////////////////
 
-func (s *Server) getProjectType(w http.ResponseWriter, r *http.Request) {
+// authorizeStudent responds with forbidden if the request user can't access the student
+func (s *Server) authorizeStudent(w http.ResponseWriter, r *http.Request, studentID primitive.ObjectID) bool {
 
-	var types []project.ProjectType
-	var err error
+	claims, err := auth.ClaimsFromRequest(r)
 
-	if types, err = project.GetProjectsType(s.DataBase, "apc_database", "projectType"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
 	}
 
-	utils.RespondWithJSON(w, http.StatusOK, types)
-}
+	var classIDs []primitive.ObjectID
 
-func (s *Server) createProjectType(w http.ResponseWriter, r *http.Request) {
+	// Monitors only reach the students enrolled in their class
+	if claims.Role == auth.RoleMonitor {
 
-	var projectTypeDAO project.ProjectType
+		enrollments, err := enrollment.GetStudentEnrollments(s.DataBase, studentID, "apc_database", "enrollment")
 
-	body, _ := ioutil.ReadAll(r.Body)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return false
+		}
 
-	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
-		return
+		for _, current := range enrollments {
+			classIDs = append(classIDs, current.ClassID)
+		}
 	}
 
-	defer r.Body.Close()
+	if !claims.CanAccessStudent(studentID, classIDs...) {
+		utils.RespondWithError(w, http.StatusForbidden, "Forbidden")
+		return false
+	}
 
-	if err := project.CreateProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	return true
+}
+
+// authorizeClass responds with forbidden if the request user can't access the class
+func (s *Server) authorizeClass(w http.ResponseWriter, r *http.Request, classID primitive.ObjectID) bool {
+
+	claims, err := auth.ClaimsFromRequest(r)
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	if !claims.CanAccessClass(classID) {
+		utils.RespondWithError(w, http.StatusForbidden, "Forbidden")
+		return false
+	}
+
+	return true
 }
 
-func (s *Server) updateProjectType(w http.ResponseWriter, r *http.Request) {
+// authorizeAdmin responds with forbidden if a monitor tries to act as another admin
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request, adminID primitive.ObjectID) bool {
 
-	var projectTypeDAO project.ProjectType
+	claims, err := auth.ClaimsFromRequest(r)
 
-	body, _ := ioutil.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+		return false
+	}
 
-	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
-		return
+	if claims.Role != auth.RoleProfessor && claims.UserID != adminID {
+		utils.RespondWithError(w, http.StatusForbidden, "Forbidden")
+		return false
 	}
 
-	defer r.Body.Close()
+	return true
+}
 
-	if err := project.UpdateProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+// authorizeProject responds with forbidden if the request user can't access the project
+// Students must own the project and monitors must share its class
+func (s *Server) authorizeProject(w http.ResponseWriter, r *http.Request, projectID *primitive.ObjectID) bool {
+
+	if projectID == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Project ID")
+		return false
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	projectDAO, err := project.GetProject(s.DataBase, *projectID, "apc_database", "projects")
+
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid Project ID")
+		return false
+	}
+
+	return s.authorizeStudent(w, r, projectDAO.StudentID) && s.authorizeClass(w, r, projectDAO.ClassID)
 }
 
-func (s *Server) deleteProjectType(w http.ResponseWriter, r *http.Request) {
+///////////////////////////////////////////////////////////////////////////////////////////
+// 								         HEALTH		 				                     //
+///////////////////////////////////////////////////////////////////////////////////////////
 
-	var projectTypeDAO project.ProjectType
+// health tells if the server and its dependencies are working
+// The database down fails the check (503), Codeforces down or an old sync only degrades it
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
 
-	body, _ := ioutil.ReadAll(r.Body)
+	status, code := "ok", http.StatusOK
 
-	if err := json.Unmarshal(body, &projectTypeDAO); err != nil {
-		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
-		return
+	database := map[string]interface{}{"status": "up"}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	if err := s.DataBase.Ping(ctx, nil); err != nil {
+		database = map[string]interface{}{"status": "down", "error": err.Error()}
+		status, code = "down", http.StatusServiceUnavailable
 	}
 
-	defer r.Body.Close()
+	breaker := s.Codeforces.Breaker().State()
 
-	if err := project.DeleteProjectType(s.DataBase, projectTypeDAO, "apc_database", "projectType"); err != nil {
-		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+	lastSync, err := progress.LastSync(s.DataBase, "apc_database", "progress")
+
+	if err != nil {
+		logrus.Errorf("Not able to read the last Codeforces sync: %s", err.Error())
 	}
 
-	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "success"})
+	judge := map[string]interface{}{
+		"status":   "up",
+		"breaker":  breaker,
+		"lastsync": lastSync,
+	}
+
+	if breaker.State == codeforces.Open {
+		judge["status"] = "down"
+	} else if progress.Mark(progress.Summary{SyncedAT: lastSync}, s.ProgressStale, breaker.State != codeforces.Open, time.Now()).Status != progress.Fresh {
+		judge["status"] = "degraded"
+	}
+
+	if judge["status"] != "up" && code == http.StatusOK {
+		status = "degraded"
+	}
+
+	utils.RespondWithJSON(w, code, map[string]interface{}{
+		"status": status,
+		"dependencies": map[string]interface{}{
+			"database": database,
+			"judge":    judge,
+		},
+	})
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////
@@ -1184,7 +3183,7 @@ func (s *Server) insertData(w http.ResponseWriter, r *http.Request) {
 		GroupID: "qpBtprcUFF",
 	}
 
-	s.insert("schoolClass", classDAO3)
+	classID3 := s.insert("schoolClass", classDAO3)
 
 	studentDAO := student.StudentCreate{
 		ClassID:   classID,
@@ -1445,6 +3444,12 @@ func (s *Server) insertData(w http.ResponseWriter, r *http.Request) {
 
 	s.insert("task", taskDAO)
 
+	for _, id := range []primitive.ObjectID{classID, classID2, classID3} {
+		if _, err := contest.MigrateClass(s.DataBase, schoolClass.SchoolClass{ID: id, ContestsIDs: classDAO.ContestsIDs}, nil, "apc_database", "contest"); err != nil {
+			panic(err)
+		}
+	}
+
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"result": "Data created!"})
 
 }
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/components/audit"
+	"github.com/apc-unb/apc-api/web/middleware"
+	"github.com/gorilla/mux"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+type fakeAuditStore struct {
+	documents map[primitive.ObjectID]bson.M
+	entries   []audit.Entry
+}
+
+func (f *fakeAuditStore) Snapshot(collectionName string, ids []primitive.ObjectID) (map[primitive.ObjectID]bson.M, error) {
+	found := map[primitive.ObjectID]bson.M{}
+	for _, id := range ids {
+		if document, ok := f.documents[id]; ok {
+			found[id] = document
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeAuditStore) Record(entries []audit.Entry) error {
+	f.entries = append(f.entries, entries...)
+	return nil
+}
+
+func auditRouter(store *fakeAuditStore, status int, handler func()) *mux.Router {
+
+	routes := map[string]middleware.AuditRoute{
+		"/news":   {Entity: "news", Collection: "news"},
+		"/logout": {Skip: true},
+	}
+
+	router := mux.NewRouter()
+	router.Use(middleware.SetMiddlewareAudit(store, routes))
+
+	serve := func(w http.ResponseWriter, r *http.Request) {
+		handler()
+		w.WriteHeader(status)
+	}
+
+	router.HandleFunc("/news", serve).Methods("GET", "POST", "PUT", "DELETE")
+	router.HandleFunc("/logout", serve).Methods("POST")
+	router.HandleFunc("/exam/{examid}", serve).Methods("PUT")
+
+	return router
+}
+
+func TestAuditMiddlewareUpdate(t *testing.T) {
+
+	id := primitive.NewObjectID()
+	store := &fakeAuditStore{documents: map[primitive.ObjectID]bson.M{id: {"title": "Old"}}}
+
+	router := auditRouter(store, http.StatusOK, func() {
+		store.documents[id] = bson.M{"title": "New"}
+	})
+
+	body := `[{"ID": "` + id.Hex() + `", "title": "New"}]`
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/news", strings.NewReader(body)))
+
+	if len(store.entries) != 1 {
+		t.Fatalf("Update should be recorded once, got: %d.", len(store.entries))
+	}
+
+	entry := store.entries[0]
+
+	if entry.Action != audit.Update || entry.Entity != "news" || entry.EntityID != id || entry.Route != "PUT /news" {
+		t.Errorf("Invalid entry, got: %+v.", entry)
+	}
+
+	if entry.Before.(bson.M)["title"] != "Old" || entry.After.(bson.M)["title"] != "New" {
+		t.Errorf("Entry should have the document before and after, got: %v %v.", entry.Before, entry.After)
+	}
+}
+
+func TestAuditMiddlewareSkip(t *testing.T) {
+
+	store := &fakeAuditStore{}
+
+	router := auditRouter(store, http.StatusOK, func() {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/news", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/logout", nil))
+
+	if len(store.entries) != 0 {
+		t.Errorf("Reads and skipped routes shouldn't be recorded, got: %+v.", store.entries)
+	}
+
+	router = auditRouter(store, http.StatusBadRequest, func() {})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("DELETE", "/news", strings.NewReader(`{}`)))
+
+	if len(store.entries) != 0 {
+		t.Errorf("Failed requests shouldn't be recorded, got: %+v.", store.entries)
+	}
+}
+
+func TestAuditMiddlewareCreate(t *testing.T) {
+
+	store := &fakeAuditStore{}
+
+	read := ""
+
+	router := auditRouter(store, http.StatusCreated, func() {})
+
+	router.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) {
+		data := make([]byte, 64)
+		n, _ := r.Body.Read(data)
+		read = string(data[:n])
+	}).Methods("POST")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/body", strings.NewReader(`{"matricula": "1", "password": "secret"}`)))
+
+	if !strings.Contains(read, "secret") {
+		t.Errorf("Handler should read the whole body, got: %s.", read)
+	}
+
+	if len(store.entries) != 1 || store.entries[0].Entity != "/body" || store.entries[0].Action != audit.Create {
+		t.Fatalf("Unknown route should be recorded with its template, got: %+v.", store.entries)
+	}
+
+	after := store.entries[0].After.(map[string]interface{})
+
+	if _, ok := after["password"]; ok || after["matricula"] != "1" {
+		t.Errorf("Created data should be recorded without passwords, got: %v.", after)
+	}
+}
+
+func TestAuditIDs(t *testing.T) {
+
+	id := primitive.NewObjectID()
+
+	cases := []struct {
+		body  interface{}
+		field string
+		want  int
+	}{
+		{map[string]interface{}{"id": id.Hex()}, "", 1},
+		{map[string]interface{}{"_id": id.Hex()}, "", 1},
+		{map[string]interface{}{"StudentID": id.Hex()}, "studentid", 1},
+		{[]interface{}{map[string]interface{}{"ID": id.Hex()}, map[string]interface{}{"ID": "invalid"}}, "", 1},
+		{map[string]interface{}{"name": "x"}, "", 0},
+		{"text", "", 0},
+	}
+
+	for _, c := range cases {
+		if ids := middleware.AuditIDs(c.body, c.field); len(ids) != c.want || (c.want > 0 && ids[0] != id) {
+			t.Errorf("Invalid ids of %v, expected: %d, got: %v.", c.body, c.want, ids)
+		}
+	}
+}
+
+func TestAuditRedact(t *testing.T) {
+
+	document := bson.M{
+		"name":     "Ana",
+		"password": "hash",
+		"nested":   bson.D{{Key: "newPassword", Value: "x"}, {Key: "email", Value: "a@b.c"}},
+		"list":     bson.A{bson.M{"adminpassword": "y", "id": 1}},
+	}
+
+	redacted := audit.Redact(document).(bson.M)
+
+	if _, ok := redacted["password"]; ok || redacted["name"] != "Ana" {
+		t.Errorf("Password should be removed, got: %v.", redacted)
+	}
+
+	if nested := redacted["nested"].(bson.D); len(nested) != 1 || nested[0].Key != "email" {
+		t.Errorf("Nested password should be removed, got: %v.", nested)
+	}
+
+	if item := redacted["list"].(bson.A)[0].(bson.M); len(item) != 1 {
+		t.Errorf("Password inside lists should be removed, got: %v.", item)
+	}
+
+	if _, ok := document["password"]; !ok {
+		t.Errorf("Redact shouldn't change the document")
+	}
+}
@@ -0,0 +1,193 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/auth"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestAuthClaims(t *testing.T) {
+
+	keys := auth.NewSecretKeySet("SUPER_SECRET")
+	classID := primitive.NewObjectID()
+
+	claims := auth.Claims{
+		UserID:    primitive.NewObjectID(),
+		Role:      auth.RoleStudent,
+		ClassID:   classID,
+		Matricula: "160156666",
+	}
+
+	jwt, err := auth.GenerateToken(keys, claims, 30*time.Minute)
+
+	if err != nil {
+		t.Fatalf("Error generating token: %s", err.Error())
+	}
+
+	request := httptest.NewRequest("GET", "/student", nil)
+	request.Header.Set("Authorization", "Bearer "+jwt)
+
+	parsed, err := auth.ExtractClaims(request, keys)
+
+	if err != nil {
+		t.Fatalf("Error parsing token: %s", err.Error())
+	}
+
+	if parsed.UserID != claims.UserID {
+		t.Errorf("Invalid token user id, got: %s, want: %s.", parsed.UserID.Hex(), claims.UserID.Hex())
+	}
+
+	if parsed.Role != auth.RoleStudent {
+		t.Errorf("Invalid token role, got: %s, want: %s.", parsed.Role, auth.RoleStudent)
+	}
+
+	if parsed.Matricula != "160156666" {
+		t.Errorf("Invalid token matricula, got: %s, want: %s.", parsed.Matricula, "160156666")
+	}
+
+	if _, err := auth.ExtractClaims(request, auth.NewSecretKeySet("WRONG_SECRET")); err == nil {
+		t.Errorf("Token signed with another secret should be rejected")
+	}
+
+	if _, err := auth.CheckTokenProfessor(request, keys, nil); err == nil {
+		t.Errorf("Student token should not have professor scope")
+	}
+
+	if parsed.Id == "" {
+		t.Errorf("Token should have an id to be revoked")
+	}
+
+	revoked := func(c *auth.Claims) (bool, error) {
+		return c.Id == parsed.Id, nil
+	}
+
+	if _, err := auth.CheckTokenStudent(request, keys, revoked); err == nil {
+		t.Errorf("Revoked token should be rejected")
+	}
+
+	if parsed.CanAccessStudent(primitive.NewObjectID()) {
+		t.Errorf("Student should not access another student")
+	}
+
+	if !parsed.CanAccessStudent(claims.UserID) {
+		t.Errorf("Student should access its own data")
+	}
+
+	if parsed.CanAccessClass(primitive.NewObjectID()) {
+		t.Errorf("Student should not access another class")
+	}
+
+	monitor := auth.Claims{UserID: primitive.NewObjectID(), Role: auth.RoleMonitor, ClassID: classID}
+
+	if monitor.CanAccessStudent(claims.UserID) || monitor.CanAccessStudent(claims.UserID, primitive.NewObjectID()) {
+		t.Errorf("Monitor should not access a student of another class")
+	}
+
+	if !monitor.CanAccessStudent(claims.UserID, primitive.NewObjectID(), classID) {
+		t.Errorf("Monitor should access a student enrolled in its class")
+	}
+
+	if err := auth.AllowRoles(auth.RoleMonitor, auth.RoleProfessor)(parsed, request); err == nil {
+		t.Errorf("Student should not go through an admin policy")
+	}
+
+	professor := auth.Claims{UserID: primitive.NewObjectID(), Role: auth.RoleProfessor}
+
+	if !professor.CanAccessClass(classID) {
+		t.Errorf("Professor should access any class")
+	}
+
+	if !professor.CanAccessStudent(claims.UserID) {
+		t.Errorf("Professor should access any student")
+	}
+}
+
+func TestAuthKeyRotation(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "jwt-keys")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	writeKey(t, filepath.Join(dir, "2019-01.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	keys, err := auth.LoadKeySet(dir, "")
+
+	if err != nil {
+		t.Fatalf("Error loading keys: %s", err.Error())
+	}
+
+	claims := auth.Claims{UserID: primitive.NewObjectID(), Role: auth.RoleMonitor}
+
+	oldToken, err := auth.GenerateToken(keys, claims, 30*time.Minute)
+
+	if err != nil {
+		t.Fatalf("Error generating token: %s", err.Error())
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecBytes, _ := x509.MarshalECPrivateKey(ecKey)
+	writeKey(t, filepath.Join(dir, "2019-02.pem"), "EC PRIVATE KEY", ecBytes)
+
+	if err := keys.Reload(); err != nil {
+		t.Fatalf("Error reloading keys: %s", err.Error())
+	}
+
+	if keys.Signer().ID != "2019-02" {
+		t.Errorf("Invalid signing key, got: %s, want: %s.", keys.Signer().ID, "2019-02")
+	}
+
+	if keys.Signer().Method.Alg() != "ES256" {
+		t.Errorf("Invalid signing method, got: %s, want: %s.", keys.Signer().Method.Alg(), "ES256")
+	}
+
+	if _, err := auth.ParseToken(oldToken, keys); err != nil {
+		t.Errorf("Token signed with the old key should still be valid: %s", err.Error())
+	}
+
+	newToken, _ := auth.GenerateToken(keys, claims, 30*time.Minute)
+
+	if _, err := auth.ParseToken(newToken, keys); err != nil {
+		t.Errorf("Token signed with the new key should be valid: %s", err.Error())
+	}
+
+	if _, err := auth.ParseToken(newToken, auth.NewSecretKeySet("SUPER_SECRET")); err == nil {
+		t.Errorf("Token should not be accepted by another key set")
+	}
+
+	jwks := keys.JWKS()
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("Invalid amount of public keys, got: %d, want: %d.", len(jwks.Keys), 2)
+	}
+
+	if jwks.Keys[0].Kty != "RSA" || jwks.Keys[1].Kty != "EC" {
+		t.Errorf("Invalid key types, got: %s %s, want: RSA EC.", jwks.Keys[0].Kty, jwks.Keys[1].Kty)
+	}
+
+	if len(auth.NewSecretKeySet("SUPER_SECRET").JWKS().Keys) != 0 {
+		t.Errorf("HMAC secrets should never be published")
+	}
+}
+
+func writeKey(t *testing.T, path, kind string, der []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,273 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/codeforces"
+	"github.com/togatoga/goforces"
+)
+
+type fakeStandings struct {
+	calls   int32
+	release chan struct{}
+	fail    bool
+}
+
+func (f *fakeStandings) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+
+	atomic.AddInt32(&f.calls, 1)
+
+	if f.release != nil {
+		<-f.release
+	}
+
+	if f.fail {
+		return nil, errors.New("Codeforces is down")
+	}
+
+	standings := &goforces.Standings{Contest: goforces.Contest{ID: int64(contestID)}}
+
+	for _, handle := range []string{"ana", "Joao"} {
+		standings.Rows = append(standings.Rows, goforces.RanklistRow{
+			Party: goforces.Party{Members: []goforces.Member{{Handle: handle}}},
+		})
+	}
+
+	return standings, nil
+}
+
+func TestCodeforcesCacheTTL(t *testing.T) {
+
+	api := &fakeStandings{}
+	cache := codeforces.NewCache(api, 50*time.Millisecond)
+
+	options := &goforces.ContestStatndingsOptions{Handles: []string{"ana", "joao"}, ShowUnofficial: true}
+	reordered := &goforces.ContestStatndingsOptions{Handles: []string{"JOAO", "ana"}, ShowUnofficial: true}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetContestStandings(context.Background(), 1, options); err != nil {
+			t.Fatalf("Standings should be returned, got: %s", err.Error())
+		}
+	}
+
+	cache.GetContestStandings(context.Background(), 1, reordered)
+
+	if api.calls != 1 {
+		t.Errorf("Same contest and handles should call Codeforces once, got: %d.", api.calls)
+	}
+
+	cache.GetContestStandings(context.Background(), 2, options)
+
+	if api.calls != 2 {
+		t.Errorf("Other contest should call Codeforces, got: %d.", api.calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	cache.GetContestStandings(context.Background(), 1, options)
+
+	if api.calls != 3 {
+		t.Errorf("Expired standings should call Codeforces again, got: %d.", api.calls)
+	}
+}
+
+func TestCodeforcesCacheWholeContest(t *testing.T) {
+
+	api := &fakeStandings{}
+	cache := codeforces.NewCache(api, time.Minute)
+
+	cache.GetContestStandings(context.Background(), 1, &goforces.ContestStatndingsOptions{ShowUnofficial: true})
+
+	standings, err := cache.GetContestStandings(context.Background(), 1, &goforces.ContestStatndingsOptions{Handles: []string{"joao"}, ShowUnofficial: true})
+
+	if err != nil || api.calls != 1 {
+		t.Fatalf("Handles should be read from the whole contest, got: %d calls %v.", api.calls, err)
+	}
+
+	if len(standings.Rows) != 1 || standings.Rows[0].Party.Members[0].Handle != "Joao" {
+		t.Errorf("Only the rows of the handles should be returned, got: %+v.", standings.Rows)
+	}
+}
+
+func TestCodeforcesCacheCoalesce(t *testing.T) {
+
+	api := &fakeStandings{release: make(chan struct{})}
+	cache := codeforces.NewCache(api, 0)
+
+	var wait sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			cache.GetContestStandings(context.Background(), 1, nil)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(api.release)
+	wait.Wait()
+
+	if api.calls != 1 {
+		t.Errorf("Concurrent requests should call Codeforces once, got: %d.", api.calls)
+	}
+
+	cache.GetContestStandings(context.Background(), 1, nil)
+
+	if api.calls != 2 {
+		t.Errorf("Without TTL nothing should be cached, got: %d.", api.calls)
+	}
+}
+
+func TestCodeforcesCacheError(t *testing.T) {
+
+	api := &fakeStandings{fail: true}
+	cache := codeforces.NewCache(api, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetContestStandings(context.Background(), 1, nil); err == nil {
+			t.Errorf("Error should be returned")
+		}
+	}
+
+	if api.calls != 2 {
+		t.Errorf("Errors shouldn't be cached, got: %d calls.", api.calls)
+	}
+}
+
+type slowStandings struct {
+	calls int32
+	fail  bool
+	delay time.Duration
+}
+
+func (f *slowStandings) GetContestStandings(ctx context.Context, contestID int, options *goforces.ContestStatndingsOptions) (*goforces.Standings, error) {
+
+	atomic.AddInt32(&f.calls, 1)
+
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if f.fail {
+		return nil, errors.New("Codeforces is down")
+	}
+
+	return &goforces.Standings{}, nil
+}
+
+func TestCodeforcesBreaker(t *testing.T) {
+
+	api := &slowStandings{fail: true}
+	breaker := codeforces.NewBreaker(api, time.Second, 2, 30*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		breaker.GetContestStandings(context.Background(), 1, nil)
+	}
+
+	if state := breaker.State(); state.State != codeforces.Open || api.calls != 2 || state.LastError == "" {
+		t.Fatalf("Breaker should open after 2 failures, got: %+v %d calls.", state, api.calls)
+	}
+
+	if _, err := breaker.GetContestStandings(context.Background(), 1, nil); err != codeforces.ErrOpen {
+		t.Errorf("Open breaker should fail without calling Codeforces, got: %v.", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if state := breaker.State(); state.State != codeforces.HalfOpen {
+		t.Errorf("Breaker should try again after the cooldown, got: %s.", state.State)
+	}
+
+	breaker.GetContestStandings(context.Background(), 1, nil)
+
+	if state := breaker.State(); state.State != codeforces.Open || api.calls != 3 {
+		t.Errorf("Failed try should open the breaker again, got: %s %d calls.", state.State, api.calls)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	api.fail = false
+
+	if _, err := breaker.GetContestStandings(context.Background(), 1, nil); err != nil {
+		t.Fatalf("Standings should be returned, got: %s", err.Error())
+	}
+
+	if state := breaker.State(); state.State != codeforces.Closed || state.Failures != 0 || state.LastSuccess == nil {
+		t.Errorf("Successful try should close the breaker, got: %+v.", state)
+	}
+}
+
+func TestCodeforcesBreakerTimeout(t *testing.T) {
+
+	api := &slowStandings{delay: time.Second}
+	breaker := codeforces.NewBreaker(api, 20*time.Millisecond, 1, time.Minute)
+
+	start := time.Now()
+
+	if _, err := breaker.GetContestStandings(context.Background(), 1, nil); err == nil || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("Slow call should time out, got: %v after %s.", err, time.Since(start))
+	}
+
+	if state := breaker.State(); state.State != codeforces.Open {
+		t.Errorf("Timeout should count as failure, got: %s.", state.State)
+	}
+
+	breaker = codeforces.NewBreaker(api, time.Second, 1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	breaker.GetContestStandings(ctx, 1, nil)
+
+	if state := breaker.State(); state.State != codeforces.Closed {
+		t.Errorf("Cancelled call shouldn't count as failure, got: %s.", state.State)
+	}
+}
+
+func TestCodeforcesBreakerClientErrors(t *testing.T) {
+
+	breaker := codeforces.NewBreaker(&fakeStandings{}, time.Second, 1, time.Minute)
+
+	refused := errors.New("Request Error: FAILED Comment: handle: User with handle ana_typo not found")
+
+	for i := 0; i < 3; i++ {
+		err := breaker.Call(context.Background(), func(ctx context.Context) error { return refused })
+
+		if err != refused {
+			t.Fatalf("Error of the request should be returned, got: %v.", err)
+		}
+	}
+
+	if state := breaker.State(); state.State != codeforces.Closed || state.Failures != 0 {
+		t.Errorf("Requests refused by Codeforces shouldn't open the breaker, got: %+v.", state)
+	}
+
+	unavailable := map[string]bool{
+		"Request Error: FAILED Comment: handle: User with handle x not found":      false,
+		"Request Error: 400 Bad Request":                                           false,
+		"Status Error: FAILED":                                                     false,
+		"Request Error: 503 Service Unavailable":                                   true,
+		"Request Error: FAILED Comment: Call limit exceeded":                       true,
+		"Get https://codeforces.com/api/contest.status: context deadline exceeded": true,
+		"dial tcp: connection refused":                                             true,
+	}
+
+	for message, want := range unavailable {
+		if got := codeforces.Unavailable(errors.New(message)); got != want {
+			t.Errorf("Invalid unavailable of %q, got: %t, want: %t.", message, got, want)
+		}
+	}
+
+	breaker.Call(context.Background(), func(ctx context.Context) error { return errors.New("Request Error: 502 Bad Gateway") })
+
+	if state := breaker.State(); state.State != codeforces.Open {
+		t.Errorf("5xx answer should open the breaker, got: %s.", state.State)
+	}
+}
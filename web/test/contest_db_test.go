@@ -4,12 +4,11 @@ import (
 	"context"
 	"log"
 	"testing"
+	"time"
 
-	"github.com/apc-unb/apc-api/web/components/exam"
+	"github.com/apc-unb/apc-api/web/components/contest"
 	"github.com/apc-unb/apc-api/web/components/schoolClass"
-	"github.com/apc-unb/apc-api/web/components/student"
-	"github.com/apc-unb/apc-api/web/components/submission"
-	"github.com/apc-unb/apc-api/web/components/task"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestContestDB(t *testing.T) {
@@ -27,134 +26,147 @@ func TestContestDB(t *testing.T) {
 		log.Fatal(err)
 	}
 
-	// Get test collection of student
+	// Get test collection of contest
 	collection := db.Database("apc_database_test").Collection("contest_test")
 
 	// Drop all content to start testing
 	collection.Drop(context.TODO())
 
-	// Instantiate some students objects
-	task1 := task.Task{
-		Statement:   "Some 2 números inteiros",
-		Score:       2.5,
-		Tags:        []string{"String", "Matrix", "Array"},
-		Submissions: []submission.Submission{},
+	if err := contest.CreateIndexes(db, "apc_database_test", "contest_test"); err != nil {
+		t.Fatalf("Failed to create contest indexes : %s", err)
 	}
 
-	task2 := task.Task{
-		Statement:   "Some 3 números inteiros",
-		Score:       4.5,
-		Tags:        []string{"Dp", "Array"},
-		Submissions: []submission.Submission{},
-	}
-
-	task3 := task.Task{
-		Statement:   "Some 5 números inteiros",
-		Score:       5.5,
-		Tags:        []string{"Sement Tree", "Trie"},
-		Submissions: []submission.Submission{},
-	}
-
-	task4 := task.Task{
-		Statement:   "Some",
-		Score:       1.5,
-		Tags:        []string{"Ad Hoc"},
-		Submissions: []submission.Submission{},
-	}
+	classID := primitive.NewObjectID()
+	opens := time.Date(2019, time.March, 25, 8, 0, 0, 0, time.UTC)
 
-	class1 := schoolClass.SchoolClass{
-		ProfessorFirstName: "Carla",
-		ProfessorLastName:  "Castanho",
-		Year:               2019,
-		Season:             1,
-		Students:           []student.Student{},
+	// Instantiate some contests objects
+	contest1 := contest.ContestCreate{
+		ClassID:   classID,
+		ContestID: 1101,
+		Name:      "Lista 2 - Repetição",
+		List:      2,
+		OpensAT:   opens,
+		ClosesAT:  opens.Add(7 * 24 * time.Hour),
 	}
 
-	class2 := schoolClass.SchoolClass{
-		ProfessorFirstName: "Marcos",
-		ProfessorLastName:  "Caetano",
-		Year:               2018,
-		Season:             2,
-		Students:           []student.Student{},
+	contest2 := contest.ContestCreate{
+		ClassID:   classID,
+		ContestID: 1100,
+		Name:      "Lista 1 - Condicionais",
+		List:      1,
+		Weight:    2,
 	}
 
-	contest1 := exam.ExamCreate{
-		Date:  "25/11/1997",
-		Class: class1,
-		Tasks: []task.Task{task1, task2},
-	}
-
-	contest2 := exam.ExamCreate{
-		Date:  "25/11/2018",
-		Class: class2,
-		Tasks: []task.Task{task1, task2, task3, task4},
+	contest3 := contest.ContestCreate{
+		ClassID:   primitive.NewObjectID(),
+		ContestID: 1100,
+		List:      1,
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
 	// 								 INSERT CONTEST DB TEST 							  	 //
 	///////////////////////////////////////////////////////////////////////////////////////////
-	// Test if contest class array can be inserted in test database
-	// Checks if err variable is not null
+	// Test if contest array can be inserted in test database
+	// The same judge contest can be in many classes, but only once in each class
 
-	if err := exam.CreateContests(db, []exam.ExamCreate{contest1, contest2}, "apc_database_test", "contest_test"); err != nil {
+	if err := contest.CreateContests(db, []contest.ContestCreate{contest1, contest2, contest3}, "apc_database_test", "contest_test"); err != nil {
 		t.Errorf("Failed to insert contests in Database : %s", err)
 	}
 
+	if err := contest.CreateContests(db, []contest.ContestCreate{contest2}, "apc_database_test", "contest_test"); err == nil {
+		t.Errorf("Contest repeated in the class was inserted")
+	}
+
 	///////////////////////////////////////////////////////////////////////////////////////////
-	// 							   GET ALL CONTEST FROM DB TEST 				      		 //
+	// 							   GET CONTESTS OF CLASS FROM DB TEST 				      	 //
 	///////////////////////////////////////////////////////////////////////////////////////////
+	// It is expected that the output is ordered by list
 
-	var contests []exam.Contest
+	var contests []contest.Contest
 
-	if contests, err = exam.GetContests(db, "apc_database_test", "contest_test"); err != nil {
+	if contests, err = contest.GetClassContests(db, classID, "apc_database_test", "contest_test"); err != nil {
 		t.Errorf("Failed to get contests from Database : %s", err)
 	}
 
+	if len(contests) != 2 || contests[0].ContestID != 1100 || contests[1].ContestID != 1101 {
+		t.Fatalf("Invalid contests of class, got: %+v.", contests)
+	}
+
+	if contests[0].Weight != 2 || contests[1].Weight != 1 {
+		t.Errorf("Contest without weight should weigh 1, got: %f.", contests[1].Weight)
+	}
+
 	///////////////////////////////////////////////////////////////////////////////////////////
 	// 							UPDATE LIST OF CONTEST FROM DB TEST   		         		 //
 	///////////////////////////////////////////////////////////////////////////////////////////
 
-	contests[0].Class.ProfessorFirstName = "Marcos"
-	contests[0].Class.ProfessorLastName = "Caetano"
+	update := contest.Contest{
+		ID:     contests[1].ID,
+		Name:   "Lista 2",
+		Weight: 3,
+	}
 
-	if err := exam.UpdateContests(db, []exam.Contest{contests[0]}, "apc_database_test", "contest_test"); err != nil {
+	if err := contest.UpdateContests(db, []contest.Contest{update}, "apc_database_test", "contest_test"); err != nil {
 		t.Errorf("Failed to update contests in Database : %s", err)
 	}
 
-	contests = nil
-
-	if contests, err = exam.GetContests(db, "apc_database_test", "contest_test"); err != nil {
-		t.Errorf("Failed to get contests from Database : %s", err)
-	}
+	var updated contest.Contest
 
-	if contests[0].Class.ProfessorFirstName != "Marcos" {
-		t.Errorf("Invalid contest[0] professor first name, got: %s, want: %s.", contests[0].Class.ProfessorFirstName, "Marcos")
+	if updated, err = contest.GetContest(db, contests[1].ID, "apc_database_test", "contest_test"); err != nil {
+		t.Errorf("Failed to get contest from Database : %s", err)
 	}
 
-	if contests[0].Class.ProfessorLastName != "Caetano" {
-		t.Errorf("Invalid contest[0] professor last name, got: %s, want: %s.", contests[0].Class.ProfessorLastName, "Caetano")
+	if updated.Name != "Lista 2" || updated.Weight != 3 || updated.List != 2 || !updated.OpensAT.Equal(opens) {
+		t.Errorf("Update should only change the fields sent, got: %+v.", updated)
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
 	// 							DELETE LIST OF CONTEST FROM DB TEST   		         		 //
 	///////////////////////////////////////////////////////////////////////////////////////////
 
-	if err := exam.DeleteContests(db, []exam.Contest{contests[0]}, "apc_database_test", "contest_test"); err != nil {
+	if err := contest.DeleteContests(db, []contest.Contest{contests[0]}, "apc_database_test", "contest_test"); err != nil {
 		t.Errorf("Failed to delete contests in Database : %s", err)
 	}
 
-	contests = nil
-
-	if contests, err = exam.GetContests(db, "apc_database_test", "contest_test"); err != nil {
+	if contests, err = contest.GetClassContests(db, classID, "apc_database_test", "contest_test"); err != nil {
 		t.Errorf("Failed to get contests from Database : %s", err)
 	}
 
-	if len(contests) != 1 {
+	if len(contests) != 1 || contests[0].ContestID != 1101 {
 		t.Errorf("Invalid contests size, got: %d, want: %d.", len(contests), 1)
 	}
 
-	if len(contests[0].Tasks) != 4 {
-		t.Errorf("Invalid tasks size from contest, got: %d, want: %d.", len(contests[0].Tasks), 3)
+	///////////////////////////////////////////////////////////////////////////////////////////
+	// 							MIGRATE CONTESTSIDS OF CLASS DB TEST   		         		 //
+	///////////////////////////////////////////////////////////////////////////////////////////
+	// The contestsids of a class become contests only once
+	// A contest deleted after the migration must not come back on the next one
+
+	classes := db.Database("apc_database_test").Collection("schoolClass")
+	legacyID := primitive.NewObjectID()
+
+	if _, err := classes.InsertOne(context.TODO(), schoolClass.SchoolClass{ID: legacyID, ContestsIDs: []int{1200, 1201}}); err != nil {
+		t.Fatalf("Failed to insert class in Database : %s", err)
+	}
+
+	if created, err := contest.MigrateClasses(db, "apc_database_test", "contest_test"); err != nil || created != 2 {
+		t.Fatalf("Contestsids should become 2 contests, got: %d, %v.", created, err)
+	}
+
+	if contests, err = contest.GetClassContests(db, legacyID, "apc_database_test", "contest_test"); err != nil || len(contests) != 2 {
+		t.Fatalf("Invalid contests of migrated class, got: %+v, %v.", contests, err)
+	}
+
+	if err := contest.DeleteContests(db, []contest.Contest{contests[0]}, "apc_database_test", "contest_test"); err != nil {
+		t.Errorf("Failed to delete contests in Database : %s", err)
+	}
+
+	if created, err := contest.MigrateClasses(db, "apc_database_test", "contest_test"); err != nil || created != 0 {
+		t.Errorf("Migrated class shouldn't create contests again, got: %d, %v.", created, err)
+	}
+
+	if class, err := schoolClass.GetClass(db, legacyID, "apc_database_test", "schoolClass"); err != nil || len(class.ContestsIDs) != 0 {
+		t.Errorf("Migrated class should lose its contestsids, got: %+v, %v.", class, err)
 	}
 
 }
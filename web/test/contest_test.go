@@ -2,77 +2,102 @@ package test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/apc-unb/apc-api/web/components/contest"
 	"github.com/apc-unb/apc-api/web/components/schoolClass"
-	"github.com/apc-unb/apc-api/web/components/student"
-	"github.com/apc-unb/apc-api/web/components/submission"
-	"github.com/apc-unb/apc-api/web/components/task"
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestContest(t *testing.T) {
 
-	grades := student.StudentGrades{
-		Exams:    []float64{1.4, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
+	opens := time.Date(2019, time.March, 25, 8, 0, 0, 0, time.UTC)
+	closes := time.Date(2019, time.April, 1, 23, 59, 0, 0, time.UTC)
+
+	contest1 := contest.Contest{
+		ClassID:   primitive.NewObjectID(),
+		ContestID: 1100,
+		Name:      "Lista 1 - Condicionais",
+		List:      1,
+		Weight:    2,
+		OpensAT:   opens,
+		ClosesAT:  closes,
+		Policy:    deadline.Fixed,
+		Penalty:   0.5,
 	}
 
-	student1 := student.Student{
-		FirstName: "Thiago",
-		LastName:  "Veras Machado",
-		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
-		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
-		Grade:     grades,
+	if err := contest1.Validate(); err != nil {
+		t.Errorf("Valid contest returned error: %s", err.Error())
 	}
 
-	class := schoolClass.SchoolClass{
-		ProfessorFirstName: "Carla",
-		ProfessorLastName:  "Castanho",
-		Year:               2019,
-		Season:             1,
-		Students:           []student.Student{student1},
+	policy := contest1.Deadline()
+
+	if policy.ContestID != 1100 || policy.Kind != deadline.Fixed || !policy.Deadline.Equal(closes) || policy.Penalty != 0.5 {
+		t.Errorf("Deadline should be the close date and the policy of the contest, got: %+v.", policy)
+	}
+
+	if kind := (contest.Contest{ContestID: 1101}).Deadline().Kind; kind != deadline.None {
+		t.Errorf("Contest without policy should have none, got: %s.", kind)
+	}
+
+	invalid := []contest.Contest{
+		{ContestID: 1100},
+		{ClassID: contest1.ClassID},
+		{ClassID: contest1.ClassID, ContestID: 1100, Weight: -1},
+		{ClassID: contest1.ClassID, ContestID: 1100, OpensAT: closes, ClosesAT: opens},
+		{ClassID: contest1.ClassID, ContestID: 1100, Policy: deadline.Cutoff},
+		{ClassID: contest1.ClassID, ContestID: 1100, ClosesAT: closes, Policy: deadline.Fixed, Penalty: 2},
+		{ClassID: contest1.ClassID, ContestID: 1100, ClosesAT: closes, Policy: "forever"},
 	}
 
-	submission1 := submission.Submission{
-		Student:  student1,
-		Veredict: "AC",
-		Time:     "Jun/04/2019 03:51",
+	for i, current := range invalid {
+		if err := current.Validate(); err == nil {
+			t.Errorf("Invalid contest %d returned no error.", i)
+		}
 	}
 
-	task1 := task.Task{
-		Statement:   "Some",
-		Score:       1.5,
-		Tags:        []string{"Ad Hoc"},
-		Submissions: []submission.Submission{submission1},
+	merged := contest.Merge(contest1, contest.Contest{Name: "Lista 1", Policy: deadline.None})
+
+	if merged.Name != "Lista 1" || merged.Policy != deadline.None || merged.List != 1 || merged.Weight != 2 || !merged.ClosesAT.Equal(closes) {
+		t.Errorf("Merge should only change the fields sent, got: %+v.", merged)
 	}
 
-	contest := contest.Contest{
-		Date:  "25/11/1997",
-		Class: class,
-		Tasks: []task.Task{task1},
+	if merged.ClassID != contest1.ClassID {
+		t.Errorf("Merge shouldn't change the class, got: %s.", merged.ClassID.Hex())
 	}
+}
+
+func TestContestFromClass(t *testing.T) {
+
+	due := time.Date(2019, time.April, 1, 23, 59, 0, 0, time.UTC)
 
-	if contest.Date != "25/11/1997" {
-		t.Errorf("Invalid contest date, got: %s, want: %s.", contest.Date, "25/11/1997")
+	class := schoolClass.SchoolClass{
+		ID:          primitive.NewObjectID(),
+		ContestsIDs: []int{1102, 1100, 0, 1102, 1101},
 	}
 
-	if contest.Class.ProfessorFirstName != "Carla" {
-		t.Errorf("Invalid submission class professor first name, got: %s, want: %s.", contest.Class.ProfessorFirstName, "Carla")
+	contests := contest.FromClass(class, []deadline.Policy{{ContestID: 1100, Kind: deadline.Cutoff, Deadline: due}})
+
+	if len(contests) != 3 {
+		t.Fatalf("Repeated and empty contests should be skipped, got: %+v.", contests)
 	}
 
-	if contest.Class.Students[0].FirstName != "Thiago" {
-		t.Errorf("Invalid submission class student 0 first name, got: %s, want: %s.", contest.Class.Students[0].FirstName, "Thiago")
+	for i, current := range contests {
+		if current.List != i+1 || current.Weight != 1 || current.ClassID != class.ID {
+			t.Errorf("Contest %d should be list %d of the class with weight 1, got: %+v.", i, i+1, current)
+		}
 	}
 
-	if contest.Tasks[0].Statement != "Some" {
-		t.Errorf("Invalid contest task 0 statement, got: %s, want: %s.", contest.Tasks[0].Statement, "Some")
+	if ids := contest.IDs(contests); ids[0] != 1102 || ids[1] != 1100 || ids[2] != 1101 {
+		t.Errorf("Contests should be in the order of the class, got: %v.", ids)
 	}
 
-	if contest.Tasks[0].Submissions[0].Veredict != "AC" {
-		t.Errorf("Invalid contest task 0 submission 0 veredict, got: %s, want: %s.", contest.Tasks[0].Submissions[0].Veredict, "AC")
+	if contests[1].Policy != deadline.Cutoff || !contests[1].ClosesAT.Equal(due) {
+		t.Errorf("Deadline of the class should become the close date of the contest, got: %+v.", contests[1])
 	}
 
+	if contests[0].Policy != "" || !contests[0].ClosesAT.IsZero() {
+		t.Errorf("Contest without deadline shouldn't have a close date, got: %+v.", contests[0])
+	}
 }
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/components/credential"
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestCredentialSlipsPDF(t *testing.T) {
+
+	var credentials []user.UserCredentials
+	recipients := map[primitive.ObjectID]credential.Recipient{}
+
+	for i := 0; i < 11; i++ {
+		id := primitive.NewObjectID()
+		credentials = append(credentials, user.UserCredentials{ID: id, Matricula: fmt.Sprintf("1901%05d", i), Password: "ABCD2345"})
+		recipients[id] = credential.Recipient{ID: id, FirstName: "João", LastName: "(Silva)"}
+	}
+
+	slips := credential.BuildSlips(credentials, recipients)
+
+	if len(slips) != 11 {
+		t.Fatalf("Invalid amount of slips, got: %d, want: %d.", len(slips), 11)
+	}
+
+	if slips[0].Name != "João (Silva)" || slips[0].Password != "ABCD2345" {
+		t.Errorf("Invalid slip, got: %v.", slips[0])
+	}
+
+	document := credential.SlipsPDF("DraGonT - APC", slips)
+
+	if !bytes.HasPrefix(document, []byte("%PDF-1.4")) || !bytes.HasSuffix(document, []byte("%%EOF\n")) {
+		t.Fatalf("Invalid PDF header or trailer")
+	}
+
+	if count := bytes.Count(document, []byte("/Type /Page ")); count != 2 {
+		t.Errorf("Invalid amount of pages, got: %d, want: %d.", count, 2)
+	}
+
+	// Accents are written in Latin-1 and parenthesis are escaped
+	if !bytes.Contains(document, []byte("Nome: Jo\xe3o \\(Silva\\)")) {
+		t.Errorf("Slip name not found in the PDF")
+	}
+
+	// Every xref entry must point to the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(document)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+
+	if !bytes.HasPrefix(document[xref:], []byte("xref")) {
+		t.Fatalf("startxref does not point to the xref table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(document[xref:], -1)
+
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(document[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Errorf("Invalid offset of object %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/components/submission"
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+var due = time.Date(2019, time.April, 1, 23, 59, 0, 0, time.UTC)
+
+func TestDeadlineCredit(t *testing.T) {
+
+	before := due.Add(-time.Hour)
+	after := due.Add(24 * time.Hour)
+
+	policies := []struct {
+		policy deadline.Policy
+		before float64
+		after  float64
+	}{
+		{deadline.Policy{Kind: deadline.None, Deadline: due}, 1, 1},
+		{deadline.Policy{Kind: deadline.Linear, Deadline: due, Until: due.Add(4 * 24 * time.Hour)}, 1, 0.75},
+		{deadline.Policy{Kind: deadline.Linear, Deadline: due, Until: due.Add(12 * time.Hour)}, 1, 0},
+		{deadline.Policy{Kind: deadline.Fixed, Deadline: due, Penalty: 0.3}, 1, 0.7},
+		{deadline.Policy{Kind: deadline.Cutoff, Deadline: due}, 1, 0},
+		{deadline.Policy{Kind: deadline.Cutoff}, 1, 1},
+	}
+
+	for _, current := range policies {
+
+		if credit := current.policy.Credit(before); math.Abs(credit-current.before) > 1e-9 {
+			t.Errorf("Invalid credit on time of %s, got: %f, want: %f.", current.policy.Kind, credit, current.before)
+		}
+
+		if credit := current.policy.Credit(after); math.Abs(credit-current.after) > 1e-9 {
+			t.Errorf("Invalid credit late of %s, got: %f, want: %f.", current.policy.Kind, credit, current.after)
+		}
+	}
+
+	policy := deadline.Policy{Kind: deadline.Fixed, Deadline: due, Penalty: 0.5}
+
+	result := policy.Apply(map[string]time.Time{"A": before, "B": after, "C": due})
+
+	if result.OnTime != 2 || result.Late != 1 || result.Counted != 3 || result.Credit != 2.5 {
+		t.Errorf("Invalid result of the fixed policy, got: %+v.", result)
+	}
+
+	policy.Kind = deadline.Cutoff
+
+	if result := policy.Apply(map[string]time.Time{"A": before, "B": after}); result.Counted != 1 || result.Late != 1 || result.Credit != 1 {
+		t.Errorf("Late solves shouldn't count with a cutoff, got: %+v.", result)
+	}
+}
+
+func TestDeadlineValidate(t *testing.T) {
+
+	valid := []deadline.Policy{
+		{ContestID: 1},
+		{ContestID: 2, Kind: deadline.Cutoff, Deadline: due},
+		{ContestID: 3, Kind: deadline.Linear, Deadline: due, Until: due.Add(time.Hour)},
+		{ContestID: 4, Kind: deadline.Fixed, Deadline: due, Penalty: 1},
+	}
+
+	if err := deadline.Validate(valid); err != nil {
+		t.Errorf("Policies should be valid, got: %v.", err)
+	}
+
+	invalid := [][]deadline.Policy{
+		{{ContestID: 0}},
+		{{ContestID: 1}, {ContestID: 1}},
+		{{ContestID: 1, Kind: "late"}},
+		{{ContestID: 1, Kind: deadline.Cutoff}},
+		{{ContestID: 1, Kind: deadline.Linear, Deadline: due, Until: due}},
+		{{ContestID: 1, Kind: deadline.Fixed, Deadline: due}},
+		{{ContestID: 1, Kind: deadline.Fixed, Deadline: due, Penalty: 1.5}},
+	}
+
+	for _, policies := range invalid {
+		if err := deadline.Validate(policies); err == nil {
+			t.Errorf("Policies should be invalid: %+v.", policies)
+		}
+	}
+}
+
+func TestProgressDeadline(t *testing.T) {
+
+	ana := primitive.NewObjectID()
+	joao := primitive.NewObjectID()
+
+	submissions := []submission.Submission{
+		{StudentID: ana, ProblemIndex: "A", Verdict: submission.WrongAnswer, SubmittedAT: due.Add(-3 * time.Hour)},
+		{StudentID: ana, ProblemIndex: "a", Verdict: submission.Accepted, SubmittedAT: due.Add(2 * time.Hour)},
+		{StudentID: ana, ProblemIndex: "A", Verdict: submission.Accepted, SubmittedAT: due.Add(-time.Hour)},
+		{StudentID: ana, ProblemIndex: "B", Verdict: submission.Accepted, SubmittedAT: due.Add(time.Hour)},
+		{StudentID: joao, ProblemIndex: "C", Verdict: submission.Accepted, SubmittedAT: due.Add(time.Hour)},
+	}
+
+	solved := submission.Solved(submissions)
+
+	if len(solved[ana]) != 2 || !solved[ana]["A"].Equal(due.Add(-time.Hour)) {
+		t.Fatalf("Problems should be solved at the first accepted submission, got: %v.", solved[ana])
+	}
+
+	class := schoolClass.SchoolClass{
+		ID:          primitive.NewObjectID(),
+		ContestsIDs: []int{100, 101},
+	}
+
+	contests := contest.FromClass(class, []deadline.Policy{{ContestID: 100, Kind: deadline.Cutoff, Deadline: due}})
+
+	snapshots := []progress.Snapshot{
+		{StudentID: ana, ContestID: 100, Handle: "ana", Done: 2, Total: 3, SyncedAT: due},
+		{StudentID: joao, ContestID: 100, Handle: "joao", Done: 1, Total: 3, SyncedAT: due},
+	}
+
+	snapshots = progress.ApplyPolicy(snapshots, contests[0].Deadline(), solved)
+
+	if snapshot := snapshots[0]; snapshot.Done != 1 || snapshot.OnTime != 1 || snapshot.Late != 1 || snapshot.Credit != 1 {
+		t.Errorf("Only the problem solved on time should count, got: %+v.", snapshot)
+	}
+
+	if snapshot := snapshots[1]; snapshot.Done != 0 || snapshot.Late != 1 {
+		t.Errorf("Late problem shouldn't count with a cutoff, got: %+v.", snapshot)
+	}
+
+	enrollments := []enrollment.Enrollment{
+		{StudentID: ana, Matricula: "1", Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "ana"}},
+		{StudentID: joao, Matricula: "2", Status: enrollment.Dropped, Handles: enrollment.Handles{Codeforces: "joao"}},
+	}
+
+	reports := progress.Reports(class, contests, judge.NewFake(), enrollments, snapshots)
+
+	if len(reports) != 1 || reports[0].Matricula != "1" || len(reports[0].Contests) != 2 {
+		t.Fatalf("Report should have the active students and every contest, got: %+v.", reports)
+	}
+
+	first := reports[0].Contests[0]
+
+	if first.Deadline == nil || !first.Deadline.Equal(due) || first.Policy != deadline.Cutoff || first.OnTime != 1 || first.Late != 1 {
+		t.Errorf("Contest should show its deadline and the solves on time and late, got: %+v.", first)
+	}
+
+	if other := reports[0].Contests[1]; other.Deadline != nil || other.Policy != deadline.None {
+		t.Errorf("Contest without deadline shouldn't have a policy, got: %+v.", other)
+	}
+
+	if summary := reports[0].Summary; summary.Done != "1" || summary.OnTime != 1 || summary.Late != 1 {
+		t.Errorf("Summary should add the solves on time and late, got: %+v.", summary)
+	}
+}
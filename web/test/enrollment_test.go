@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestEnrollmentChoose(t *testing.T) {
+
+	now := time.Now()
+
+	approved := enrollment.Enrollment{ID: primitive.NewObjectID(), Status: enrollment.Approved, CreatedAT: now.Add(-time.Hour)}
+	active := enrollment.Enrollment{ID: primitive.NewObjectID(), Status: enrollment.Active, CreatedAT: now.Add(-2 * time.Hour)}
+	dropped := enrollment.Enrollment{ID: primitive.NewObjectID(), Status: enrollment.Dropped, CreatedAT: now}
+
+	enrollments := []enrollment.Enrollment{approved, active, dropped}
+
+	if chosen, err := enrollment.Choose(enrollments, primitive.NilObjectID); err != nil || chosen.ID != active.ID {
+		t.Errorf("Active enrollment should be opened by default, got: %v %v.", chosen.Status, err)
+	}
+
+	if chosen, err := enrollment.Choose(enrollments, approved.ID); err != nil || chosen.ID != approved.ID {
+		t.Errorf("Asked enrollment should be opened, got: %v %v.", chosen.Status, err)
+	}
+
+	if _, err := enrollment.Choose(enrollments, dropped.ID); err == nil {
+		t.Errorf("Dropped enrollment should not be opened")
+	}
+
+	if _, err := enrollment.Choose(enrollments, primitive.NewObjectID()); err == nil {
+		t.Errorf("Enrollment of another student should not be opened")
+	}
+
+	if chosen, err := enrollment.Choose([]enrollment.Enrollment{approved, dropped}, primitive.NilObjectID); err != nil || chosen.ID != approved.ID {
+		t.Errorf("Finished enrollment should be opened without an active one, got: %v %v.", chosen.Status, err)
+	}
+
+	if _, err := enrollment.Choose([]enrollment.Enrollment{dropped}, primitive.NilObjectID); err == nil {
+		t.Errorf("Student with only dropped enrollments should not log in")
+	}
+}
+
+func TestEnrollmentValidStatus(t *testing.T) {
+
+	for _, status := range []string{enrollment.Active, enrollment.Dropped, enrollment.Approved, enrollment.Failed} {
+		if !enrollment.ValidStatus(status) {
+			t.Errorf("Status %s should be valid", status)
+		}
+	}
+
+	if enrollment.ValidStatus("graduated") {
+		t.Errorf("Unknown status should be invalid")
+	}
+}
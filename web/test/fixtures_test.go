@@ -0,0 +1,122 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/codeforces"
+	"github.com/apc-unb/apc-api/web/config"
+	"github.com/togatoga/goforces"
+)
+
+func TestCodeforcesRecordReplay(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "fixtures")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	api := &fakeCodeforcesAPI{status: []goforces.Submission{{ID: 42, ContestID: 7, Verdict: "OK"}}}
+
+	recorder, err := codeforces.NewRecorder(api, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	options := &goforces.ContestStatndingsOptions{Handles: []string{"Joao", "ana"}, ShowUnofficial: true}
+
+	if _, err := recorder.GetContestStandings(ctx, 7, options); err != nil {
+		t.Fatalf("Recorder should return the answer, got: %v.", err)
+	}
+	if _, err := recorder.GetContestStatus(ctx, 7, &goforces.ContestStatusOptions{Handle: "ana"}); err != nil {
+		t.Fatalf("Recorder should return the answer, got: %v.", err)
+	}
+	if _, err := recorder.GetUserInfo(ctx, []string{"unknown"}); err == nil {
+		t.Fatal("Recorder should return the error of Codeforces.")
+	}
+
+	replayer, err := codeforces.NewReplayer(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standings, err := replayer.GetContestStandings(ctx, 7, &goforces.ContestStatndingsOptions{Handles: []string{"ana", "joao"}, ShowUnofficial: true})
+
+	if err != nil || len(standings.Rows) != 2 || standings.Rows[1].Party.Members[0].Handle != "Joao" {
+		t.Errorf("Standings should be replayed whatever the order of the handles, got: %+v %v.", standings, err)
+	}
+
+	submissions, err := replayer.GetContestStatus(ctx, 7, &goforces.ContestStatusOptions{Handle: "ana"})
+
+	if err != nil || len(submissions) != 1 || submissions[0].ID != 42 {
+		t.Errorf("Submissions should be replayed, got: %v %v.", submissions, err)
+	}
+
+	if _, err := replayer.GetUserInfo(ctx, []string{"unknown"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Recorded error should be replayed, got: %v.", err)
+	}
+
+	if _, err := replayer.GetContestStandings(ctx, 8, options); err == nil || !strings.Contains(err.Error(), "No fixture") {
+		t.Errorf("Call without fixture should fail, got: %v.", err)
+	}
+
+	judge := codeforces.NewJudge(replayer, 0, 0, 1, 0, 0)
+
+	if user, err := judge.GetUser(ctx, "ana"); err == nil {
+		t.Errorf("User never recorded should fail, got: %v.", user)
+	}
+}
+
+func TestCheckCodeforces(t *testing.T) {
+
+	flags := config.Flags{CodeforcesMode: codeforces.Replay, FixturesDir: "fixtures/codeforces"}
+
+	if err := flags.CheckCodeforces(); err != nil {
+		t.Errorf("Replay shouldn't need key and secret, got: %v.", err)
+	}
+
+	flags.CodeforcesMode = codeforces.Record
+
+	if err := flags.CheckCodeforces(); err == nil {
+		t.Error("Record should need key and secret.")
+	}
+
+	flags.CodeforcesKey, flags.CodeforcesSecret = "key", "secret"
+
+	if err := flags.CheckCodeforces(); err != nil {
+		t.Errorf("Record with key and secret should be valid, got: %v.", err)
+	}
+
+	flags.CodeforcesMode = "offline"
+
+	if err := flags.CheckCodeforces(); err == nil {
+		t.Error("Unknown mode should fail.")
+	}
+}
+
+func TestFlagsRedacted(t *testing.T) {
+
+	flags := config.Flags{SMTPUser: "apc", SMTPPassword: "smtp-pass", JwtSecret: "jwt-secret", CodeforcesSecret: "cf-secret"}
+
+	logged := fmt.Sprintf("%v", flags.Redacted())
+
+	for _, secret := range []string{"smtp-pass", "jwt-secret", "cf-secret"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Secret %s shouldn't be logged, got: %s.", secret, logged)
+		}
+	}
+
+	if !strings.Contains(logged, "apc") || flags.SMTPPassword != "smtp-pass" {
+		t.Errorf("Only a copy of the secrets should be redacted, got: %s.", logged)
+	}
+}
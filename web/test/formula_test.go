@@ -0,0 +1,131 @@
+package test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/grading"
+)
+
+func TestFormulaEval(t *testing.T) {
+
+	input := grading.Input{
+		grading.Exams:    {4, 6},
+		grading.Lists:    {10, 8},
+		grading.Projects: {1, 0.5},
+	}
+
+	cases := []struct {
+		formula string
+		want    float64
+	}{
+		{"0.6*avg(exams) + 0.3*avg(lists) + 0.1*sum(projects)", 0.6*5 + 0.3*9 + 0.1*1.5},
+		{"if(avg(exams) < 5, avg(exams), 0.7*avg(exams) + 0.3*avg(lists))", 0.7*5 + 0.3*9},
+		{"if(avg(exams) < 6, avg(exams), 10)", 5},
+		{"min(exams) + max(lists)", 14},
+		{"max(exams, lists, 9.5)", 10},
+		{"count(exams) * 2 - -1", 5},
+		{"2 + 3 * 4 - 8 / 2", 10},
+		{"(2 + 3) * 4", 20},
+		{"avg(exams) >= 5 && !(count(lists) == 0)", 1},
+		{"avg(exams) > 5 || 0", 0},
+		{"0 && 1 / 0", 0},
+		{"SUM(Projects)", 1.5},
+		{"avg(exams) + .5", 5.5},
+	}
+
+	for _, c := range cases {
+
+		formula, err := grading.ParseFormula(c.formula)
+
+		if err != nil {
+			t.Errorf("Formula %s should parse, got: %s", c.formula, err.Error())
+			continue
+		}
+
+		if got, err := formula.Eval(input); err != nil || math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Invalid result of %s, expected: %v, got: %v %v.", c.formula, c.want, got, err)
+		}
+	}
+}
+
+func TestFormulaEmptyGrades(t *testing.T) {
+
+	formula, err := grading.ParseFormula("avg(exams) + sum(lists) + min(projects)")
+
+	if err != nil {
+		t.Fatalf("Formula should parse, got: %s", err.Error())
+	}
+
+	if got, err := formula.Eval(grading.Input{}); err != nil || got != 0 {
+		t.Errorf("Student without grades should have zero, got: %v %v.", got, err)
+	}
+
+	formula, _ = grading.ParseFormula("10 / count(exams)")
+
+	if _, err := formula.Eval(grading.Input{}); err == nil {
+		t.Errorf("Division by zero should fail")
+	}
+}
+
+func TestFormulaInvalid(t *testing.T) {
+
+	cases := map[string]string{
+		"":                         "empty",
+		"exams":                    "list",
+		"exams + 1":                "list",
+		"avg(exams":                "position 10",
+		"avg(exam)":                "unknown variable exam",
+		"pow(exams, 2)":            "unknown function pow",
+		"if(avg(exams) < 5, 1)":    "3 arguments",
+		"if(exams, 1, 2)":          "list",
+		"avg()":                    "at least one",
+		"1 +":                      "ended",
+		"1 2":                      "unexpected 2",
+		"avg(exams) ^ 2":           "Invalid character",
+		"1..2":                     "Invalid number",
+		strings.Repeat("(", 100):   "nested",
+		strings.Repeat("1+", 600):  "longer",
+		strings.Repeat("-", 100):   "nested",
+		"avg(exams) ; drop(table)": "Invalid character",
+	}
+
+	for source, want := range cases {
+		if _, err := grading.ParseFormula(source); err == nil {
+			t.Errorf("Formula %q should be invalid", source)
+		} else if !strings.Contains(err.Error(), want) {
+			t.Errorf("Invalid error of %q, expected: %s, got: %s.", source, want, err.Error())
+		}
+	}
+}
+
+func TestFormulaScheme(t *testing.T) {
+
+	scheme := grading.Scheme{
+		Formula:  "if(avg(exams) < 5, avg(exams), 0.6*avg(exams) + 0.4*avg(lists))",
+		Rounding: grading.Rounding{Step: 0.1},
+	}
+
+	if err := scheme.Validate(); err != nil {
+		t.Fatalf("Scheme should be valid, got: %s", err.Error())
+	}
+
+	result := scheme.Compute(grading.Input{grading.Exams: {4, 5}, grading.Lists: {10}})
+
+	if result.Final != 4.5 || result.Mention != grading.MI || result.Error != "" {
+		t.Errorf("Invalid result, expected: 4.5 MI, got: %v %s %s.", result.Final, result.Mention, result.Error)
+	}
+
+	scheme.Formula = "avg(exams) / 0"
+
+	if result := scheme.Compute(grading.Input{}); result.Error == "" || result.Mention != grading.SR {
+		t.Errorf("Failed formula should give SR with the error, got: %v %s.", result.Mention, result.Error)
+	}
+
+	scheme.Formula = "avg(exams) +"
+
+	if err := scheme.Validate(); err == nil {
+		t.Errorf("Scheme with invalid formula should be invalid")
+	}
+}
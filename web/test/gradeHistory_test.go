@@ -0,0 +1,86 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestGradeHistoryDiff(t *testing.T) {
+
+	before := enrollment.Grades{Exams: []float64{5, 7}, Lists: []float64{10, 8}}
+	after := enrollment.Grades{Exams: []float64{5, 8, 6}, Lists: []float64{10}}
+
+	base := gradeHistory.Change{
+		StudentID: primitive.NewObjectID(),
+		ActorID:   primitive.NewObjectID(),
+		Origin:    gradeHistory.OriginManual,
+		Reason:    "Revisão de prova",
+	}
+
+	changes := gradeHistory.Diff(before, after, base)
+
+	if len(changes) != 3 {
+		t.Fatalf("Invalid number of changes, expected: 3, got: %d.", len(changes))
+	}
+
+	expected := []struct {
+		source string
+		index  int
+		old    *float64
+		new    *float64
+	}{
+		{grading.Exams, 2, &before.Exams[1], &after.Exams[1]},
+		{grading.Exams, 3, nil, &after.Exams[2]},
+		{grading.Lists, 2, &before.Lists[1], nil},
+	}
+
+	value := func(v *float64) interface{} {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+
+	for i, want := range expected {
+
+		change := changes[i]
+
+		if change.Source != want.source || change.Index != want.index || value(change.Old) != value(want.old) || value(change.New) != value(want.new) {
+			t.Errorf("Invalid change %d, expected: %s %d %v -> %v, got: %s %d %v -> %v.", i, want.source, want.index,
+				value(want.old), value(want.new), change.Source, change.Index, value(change.Old), value(change.New))
+		}
+
+		if change.StudentID != base.StudentID || change.ActorID != base.ActorID || change.Reason != base.Reason || change.Origin != gradeHistory.OriginManual {
+			t.Errorf("Change %d should keep the fields of base, got: %+v.", i, change)
+		}
+	}
+
+	if changes := gradeHistory.Diff(before, before, base); len(changes) != 0 {
+		t.Errorf("Same grades shouldn't have changes, got: %d.", len(changes))
+	}
+}
+
+func TestEnrollmentWithGrade(t *testing.T) {
+
+	values := []float64{5}
+
+	updated := enrollment.WithGrade(values, 3, 9)
+
+	if len(updated) != 3 || updated[0] != 5 || updated[1] != 0 || updated[2] != 9 {
+		t.Errorf("Missing grades should get 0, got: %v.", updated)
+	}
+
+	if updated = enrollment.WithGrade(values, 1, 7); updated[0] != 7 || values[0] != 5 {
+		t.Errorf("Grades should be copied, got: %v %v.", updated, values)
+	}
+
+	grades := enrollment.Grades{Exams: []float64{1}, Lists: []float64{2}}
+
+	if changed := grades.With(grading.Lists, []float64{3}); changed.Source(grading.Lists)[0] != 3 || grades.Lists[0] != 2 || changed.Source(grading.Exams)[0] != 1 {
+		t.Errorf("Only the lists should change, got: %+v.", changed)
+	}
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/apc-unb/apc-api/web/xlsx"
+)
+
+func TestParseScores(t *testing.T) {
+
+	data := []byte("2019/2/A\nMatrícula;Nome;Nota\n19/0012345;João Araújo;7,5\n190012346;Maria;\n190012347;Ana;abc\n190012345;João;8\n190012348;Pedro;-1\n")
+
+	sheet, err := roster.ParseScores(data, roster.ScoreMapping{})
+
+	if err != nil {
+		t.Fatalf("Scores should be parsed, got: %s", err.Error())
+	}
+
+	if len(sheet.Rows) != 5 {
+		t.Fatalf("Invalid number of rows, expected: 5, got: %d.", len(sheet.Rows))
+	}
+
+	if row := sheet.Rows[0]; row.Matricula != "190012345" || row.Score != 7.5 || row.Status != roster.StatusValid || row.Line != 3 {
+		t.Errorf("Invalid first row, got: %+v.", row)
+	}
+
+	errors := []string{"Score is empty", "Invalid score abc", "Duplicated matricula", "negative"}
+
+	for i, want := range errors {
+		row := sheet.Rows[i+1]
+		if row.Status != roster.StatusInvalid || len(row.Errors) == 0 || !strings.Contains(row.Errors[0], want) {
+			t.Errorf("Row %d should have error %s, got: %+v.", i+2, want, row)
+		}
+	}
+}
+
+func TestParseScoresColumns(t *testing.T) {
+
+	// Without header the score is the last column
+	sheet, err := roster.ParseScores([]byte("190012345,João,9.5\n"), roster.ScoreMapping{})
+
+	if err != nil || len(sheet.Rows) != 1 || sheet.Rows[0].Score != 9.5 {
+		t.Errorf("Score should be the last column, got: %+v %v.", sheet.Rows, err)
+	}
+
+	data, _ := xlsx.Write("Notas", [][]interface{}{
+		{"Aluno", "Prova 2", "Mat"},
+		{"João", 6.25, "190012345"},
+	})
+
+	if _, err := roster.ParseScores(data, roster.ScoreMapping{}); err == nil {
+		t.Errorf("Unknown score column should fail")
+	}
+
+	sheet, err = roster.ParseScores(data, roster.ScoreMapping{Score: "Prova 2"})
+
+	if err != nil || len(sheet.Rows) != 1 || sheet.Rows[0].Score != 6.25 || sheet.Rows[0].Matricula != "190012345" {
+		t.Errorf("Mapped score column should be read, got: %+v %v.", sheet.Rows, err)
+	}
+
+	if _, err := roster.ParseScores(data, roster.ScoreMapping{Score: "Prova 3"}); err == nil {
+		t.Errorf("Missing mapped column should fail")
+	}
+}
+
+func TestGradeTarget(t *testing.T) {
+
+	valid := []grade.Target{{Source: grading.Exams, Index: 1}, {Source: grading.Lists, Index: 12}}
+	invalid := []grade.Target{{Source: grading.Projects, Index: 1}, {Source: grading.Exams}, {Source: grading.Lists, Index: 51}}
+
+	for _, target := range valid {
+		if err := target.Validate(); err != nil {
+			t.Errorf("Target %+v should be valid, got: %s", target, err.Error())
+		}
+	}
+
+	for _, target := range invalid {
+		if err := target.Validate(); err == nil {
+			t.Errorf("Target %+v should be invalid", target)
+		}
+	}
+}
+
+func TestGradeDiff(t *testing.T) {
+
+	class := []grade.StudentGrade{
+		{Matricula: "190012345", FirstName: "João", Status: enrollment.Active, Grades: enrollment.Grades{Exams: []float64{5, 7}}},
+		{Matricula: "190012346", FirstName: "Maria", Status: enrollment.Active, Grades: enrollment.Grades{Exams: []float64{4, 4}}},
+		{Matricula: "190012347", FirstName: "Ana", Status: enrollment.Dropped},
+		{Matricula: "190012348", FirstName: "Pedro", Status: enrollment.Active},
+		{Matricula: "190012350", FirstName: "Bia", Status: enrollment.Active},
+	}
+
+	sheet, _ := roster.ParseScores([]byte("matricula,nota\n190012345,8\n190012346,4\n190012347,9\n190012349,6\n190012348,6\n190012350,11\n"), roster.ScoreMapping{})
+
+	report := grade.Diff(sheet, class, grade.Target{Source: grading.Exams, Index: 2}, 10)
+
+	if report.Total != 6 || report.Valid != 3 || report.Invalid != 3 || report.Updated != 2 || report.Unchanged != 1 {
+		t.Fatalf("Invalid report counts, got: %+v.", report)
+	}
+
+	if change := report.Changes[0]; change.Old == nil || *change.Old != 7 || change.New != 8 || change.Action != roster.ActionUpdated {
+		t.Errorf("Invalid change of existing grade, got: %+v.", change)
+	}
+
+	if change := report.Changes[1]; change.Old == nil || change.Action != roster.ActionUnchanged {
+		t.Errorf("Same grade should be unchanged, got: %+v.", change)
+	}
+
+	if change := report.Changes[2]; change.Old != nil || change.New != 6 || change.Action != roster.ActionUpdated {
+		t.Errorf("Student without the exam should have no old grade, got: %+v.", change)
+	}
+
+	errors := map[string]string{
+		"190012347": "dropped",
+		"190012349": "not enrolled",
+		"190012350": "maximum of 10",
+	}
+
+	for _, row := range report.Rows {
+		if want, ok := errors[row.Matricula]; ok && (len(row.Errors) == 0 || !strings.Contains(row.Errors[0], want)) {
+			t.Errorf("Row of %s should have error %s, got: %v.", row.Matricula, want, row.Errors)
+		}
+	}
+
+	if sheet.Rows[2].Status != roster.StatusValid {
+		t.Errorf("Diff shouldn't change the parsed file")
+	}
+}
@@ -0,0 +1,137 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/apc-unb/apc-api/web/utils"
+	"github.com/apc-unb/apc-api/web/xlsx"
+)
+
+func gradebookSheet() []grade.StudentGrade {
+	return []grade.StudentGrade{
+		{
+			Matricula: "190012345",
+			FirstName: "João",
+			LastName:  "Araújo",
+			Status:    enrollment.Active,
+			Grades:    enrollment.Grades{Exams: []float64{7.5, 8}, Lists: []float64{10}},
+			Projects:  []float64{2},
+			Result:    grading.Result{Final: 7.8, Mention: grading.MS},
+		},
+		{
+			Matricula: "190012346",
+			FirstName: "Maria",
+			LastName:  "Silva",
+			Status:    enrollment.Dropped,
+			Grades:    enrollment.Grades{Exams: []float64{3}},
+			Projects:  []float64{},
+			Result:    grading.Result{Final: 1, Mention: grading.II},
+		},
+	}
+}
+
+func TestGradebook(t *testing.T) {
+
+	table := grade.Gradebook(gradebookSheet())
+
+	header := []interface{}{"Matrícula", "Nome", "Situação", "Prova 1", "Prova 2", "Lista 1", "Projeto 1", "Nota final", "Menção"}
+
+	if len(table) != 3 || len(table[0]) != len(header) {
+		t.Fatalf("Invalid gradebook size, got: %d rows %d columns.", len(table), len(table[0]))
+	}
+
+	for i := range header {
+		if table[0][i] != header[i] {
+			t.Errorf("Invalid header %d, expected: %v, got: %v.", i, header[i], table[0][i])
+		}
+	}
+
+	if table[1][1] != "João Araújo" || table[1][3] != 7.5 || table[1][7] != 7.8 || table[1][8] != grading.MS {
+		t.Errorf("Invalid first row, got: %v.", table[1])
+	}
+
+	if table[2][4] != "" || table[2][5] != "" || table[2][6] != "" {
+		t.Errorf("Missing grades should be empty, got: %v.", table[2])
+	}
+}
+
+func TestGradebookCSV(t *testing.T) {
+
+	data, err := grade.GradebookCSV(gradebookSheet())
+
+	if err != nil {
+		t.Fatalf("CSV should be written, got: %s", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	if len(lines) != 3 || lines[1] != "190012345,João Araújo,active,7.5,8,10,2,7.8,MS" {
+		t.Errorf("Invalid CSV, got: %q.", lines)
+	}
+}
+
+func TestGradebookSIGAA(t *testing.T) {
+
+	class := schoolClass.SchoolClass{ClassName: "A", Year: 2019, Season: 2}
+
+	data, err := grade.GradebookSIGAA(gradebookSheet(), class)
+
+	if err != nil {
+		t.Fatalf("SIGAA file should be written, got: %s", err.Error())
+	}
+
+	// Windows-1252: ú is a single byte
+	if !bytes.Contains(data, []byte("Jo\xe3o Ara\xfajo")) {
+		t.Errorf("SIGAA file should be encoded in Windows-1252, got: %q.", data)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\r\n")
+
+	if len(lines) != 3 || lines[0] != "2019/2/A" || !strings.HasSuffix(lines[2], ";7,8;MS") {
+		t.Errorf("Invalid SIGAA file, dropped students should be left out, got: %q.", lines)
+	}
+}
+
+func TestGradebookXLSX(t *testing.T) {
+
+	data, err := grade.GradebookXLSX(gradebookSheet(), "Turma A")
+
+	if err != nil {
+		t.Fatalf("XLSX should be written, got: %s", err.Error())
+	}
+
+	parsed, err := roster.Parse(data, roster.Mapping{})
+
+	if err != nil {
+		t.Fatalf("XLSX should be read back, got: %s", err.Error())
+	}
+
+	if len(parsed.Rows) != 2 || parsed.Rows[0].Matricula != "190012345" || parsed.Rows[1].LastName != "Silva" {
+		t.Errorf("Invalid XLSX rows, got: %+v.", parsed.Rows)
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+
+	for index, want := range cases {
+		if got := xlsx.ColumnName(index); got != want {
+			t.Errorf("Invalid column %d, expected: %s, got: %s.", index, want, got)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+
+	if got := utils.FileName("Turma A/\"B\"; ção"); got != "Turma_AB_o" {
+		t.Errorf("Invalid file name, got: %s.", got)
+	}
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/grading"
+)
+
+func TestGradingCompute(t *testing.T) {
+
+	scheme := grading.Scheme{
+		Components: []grading.Component{
+			{Name: "Provas", Source: grading.Exams, Weight: 6, Count: 3},
+			{Name: "Listas", Source: grading.Lists, Weight: 3, DropLowest: 1},
+			{Name: "Projetos", Source: grading.Projects, Weight: 1, Aggregate: grading.Sum, Max: 10},
+		},
+		Rounding: grading.Rounding{Step: 0.1},
+	}
+
+	if err := scheme.Validate(); err != nil {
+		t.Fatalf("Scheme should be valid, got: %s", err.Error())
+	}
+
+	result := scheme.Compute(grading.Input{
+		grading.Exams:    {8, 7},
+		grading.Lists:    {2, 10, 9},
+		grading.Projects: {6, 6},
+	})
+
+	// Provas: (8 + 7 + 0) / 3 = 5, Listas: (10 + 9) / 2 = 9.5, Projetos: 12 capped to 10
+	want := []float64{5, 9.5, 10}
+
+	for i, component := range result.Components {
+		if math.Abs(component.Value-want[i]) > 1e-9 {
+			t.Errorf("Invalid %s value, expected: %.2f, got: %.2f.", component.Name, want[i], component.Value)
+		}
+	}
+
+	// (6*5 + 3*9.5 + 1*10) / 10 = 6.85, rounded to 6.9
+	if result.Final != 6.9 || result.Mention != grading.MM {
+		t.Errorf("Invalid final grade, expected: 6.9 MM, got: %v %s.", result.Final, result.Mention)
+	}
+}
+
+func TestGradingRound(t *testing.T) {
+
+	cases := []struct {
+		rounding grading.Rounding
+		cap      float64
+		grade    float64
+		want     float64
+	}{
+		{grading.Rounding{}, 0, 6.85, 6.85},
+		{grading.Rounding{Step: 0.5}, 0, 6.74, 6.5},
+		{grading.Rounding{Step: 0.5, Mode: grading.Up}, 0, 6.51, 7},
+		{grading.Rounding{Step: 1, Mode: grading.Down}, 0, 6.99, 6},
+		{grading.Rounding{Step: 0.1, Mode: grading.Up}, 0, 7, 7},
+		{grading.Rounding{}, 0, 11.5, 10},
+		{grading.Rounding{}, 9, 9.5, 9},
+		{grading.Rounding{}, 0, -1, 0},
+	}
+
+	for _, c := range cases {
+		scheme := grading.Scheme{Cap: c.cap, Rounding: c.rounding}
+		if got := scheme.Round(c.grade); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("Invalid rounding of %v with %+v, expected: %v, got: %v.", c.grade, c.rounding, c.want, got)
+		}
+	}
+}
+
+func TestGradingMention(t *testing.T) {
+
+	cases := map[float64]string{
+		10:  grading.SS,
+		9:   grading.SS,
+		8.9: grading.MS,
+		7:   grading.MS,
+		6.9: grading.MM,
+		5:   grading.MM,
+		4.9: grading.MI,
+		3:   grading.MI,
+		2.9: grading.II,
+		0.1: grading.II,
+		0:   grading.SR,
+	}
+
+	for grade, want := range cases {
+		if got := grading.Mention(grade); got != want {
+			t.Errorf("Invalid mention of %v, expected: %s, got: %s.", grade, want, got)
+		}
+	}
+
+	if !grading.Approved(grading.MM) || grading.Approved(grading.MI) {
+		t.Errorf("Only SS, MS and MM should pass")
+	}
+}
+
+func TestGradingValidate(t *testing.T) {
+
+	invalid := []grading.Scheme{
+		{Components: []grading.Component{{Name: "Provas", Source: "homework", Weight: 1}}},
+		{Components: []grading.Component{{Name: "Provas", Source: grading.Exams}}},
+		{Components: []grading.Component{{Name: "", Source: grading.Exams, Weight: 1}}},
+		{Components: []grading.Component{{Name: "Provas", Source: grading.Exams, Weight: 1, Count: 2, DropLowest: 2}}},
+		{Components: []grading.Component{{Name: "Provas", Source: grading.Exams, Weight: 1}, {Name: "Provas", Source: grading.Lists, Weight: 1}}},
+		{Components: []grading.Component{{Name: "Provas", Source: grading.Exams, Weight: 1}}, Rounding: grading.Rounding{Mode: "bankers"}},
+	}
+
+	for i, scheme := range invalid {
+		if err := scheme.Validate(); err == nil {
+			t.Errorf("Scheme %d should be invalid", i)
+		}
+	}
+
+	if err := (grading.Scheme{}).Validate(); err != nil {
+		t.Errorf("Empty scheme should be valid, got: %s", err.Error())
+	}
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/codeforces"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/togatoga/goforces"
+)
+
+type fakeCodeforcesAPI struct {
+	fakeStandings
+	status      []goforces.Submission
+	handle      string
+	statusCalls int
+}
+
+func (f *fakeCodeforcesAPI) GetUserInfo(ctx context.Context, handles []string) ([]goforces.User, error) {
+	if handles[0] == "unknown" {
+		return nil, errors.New("handles: User with handle unknown not found")
+	}
+	return []goforces.User{{Handle: handles[0], Avatar: "//userpic.codeforces.com/" + handles[0] + ".jpg"}}, nil
+}
+
+func (f *fakeCodeforcesAPI) GetContestStatus(ctx context.Context, contestID int, options *goforces.ContestStatusOptions) ([]goforces.Submission, error) {
+	f.handle = ""
+	if options != nil {
+		f.handle = options.Handle
+	}
+	f.statusCalls++
+	return f.status, nil
+}
+
+func (f *fakeCodeforcesAPI) GetUserStatus(ctx context.Context, handle string, options *goforces.UserStatusOptions) ([]goforces.Submission, error) {
+	return nil, nil
+}
+
+func TestFakeJudge(t *testing.T) {
+
+	fake := judge.NewFake()
+
+	first, err := fake.GetContestStandings(context.Background(), 7, []string{"ana", "joao"})
+
+	if err != nil || len(first.Problems) != 5 || len(first.Rows) != 2 {
+		t.Fatalf("Contest should be generated for the handles, got: %+v %v.", first, err)
+	}
+
+	second, _ := fake.GetContestStandings(context.Background(), 7, []string{"ANA"})
+
+	if len(second.Rows) != 1 || !reflect.DeepEqual(second.Rows[0].Points, first.Rows[0].Points) {
+		t.Errorf("Same handle and contest should have the same result, got: %v %v.", first.Rows[0], second.Rows)
+	}
+
+	submissions, _ := fake.GetUserSubmissions(context.Background(), "ana", 7)
+
+	solved := 0
+	for _, points := range first.Rows[0].Points {
+		if points > 0 {
+			solved++
+		}
+	}
+
+	if len(submissions) != solved {
+		t.Errorf("Each solved problem should have a submission, expected: %d, got: %d.", solved, len(submissions))
+	}
+
+	if both, _ := fake.GetContestSubmissions(context.Background(), 7, []string{"ana", "joao"}); len(both) <= len(submissions) {
+		t.Errorf("Contest submissions should have the submissions of each handle, got: %d.", len(both))
+	}
+
+	if _, err := fake.GetUser(context.Background(), "unknown_user"); err == nil {
+		t.Errorf("Unknown handle should fail")
+	}
+
+	fake.Contests = map[int]judge.Standings{1: {ContestID: 1, Name: "Lista", Rows: []judge.Row{{Handles: []string{"ana"}}, {Handles: []string{"bia"}}}}}
+
+	if contest, _ := fake.GetContestStandings(context.Background(), 1, []string{"Bia"}); contest.Name != "Lista" || len(contest.Rows) != 1 {
+		t.Errorf("Given contest should be filtered by handle, got: %+v.", contest)
+	}
+
+	fake.Down = true
+
+	if _, err := fake.GetUser(context.Background(), "ana"); err == nil || fake.Available() {
+		t.Errorf("Down judge should fail")
+	}
+}
+
+func TestJudgeRegistry(t *testing.T) {
+
+	cf := codeforces.NewJudge(&fakeCodeforcesAPI{}, 0, 0, 1, 0, 0)
+	fake := judge.NewFake()
+
+	registry := judge.NewRegistry(cf, fake)
+
+	if provider, err := registry.For(""); err != nil || provider.Name() != codeforces.Name {
+		t.Errorf("Class without judge should use the default one, got: %v %v.", provider, err)
+	}
+
+	if provider, err := registry.For("Fake"); err != nil || provider != judge.Provider(fake) {
+		t.Errorf("Judge should be found by name, got: %v %v.", provider, err)
+	}
+
+	if _, err := registry.For("uri"); err == nil {
+		t.Errorf("Unknown judge should fail")
+	}
+
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"codeforces", "fake"}) {
+		t.Errorf("Invalid judge names, got: %v.", names)
+	}
+}
+
+func TestCodeforcesJudge(t *testing.T) {
+
+	api := &fakeCodeforcesAPI{status: []goforces.Submission{{
+		ID:                  10,
+		ContestID:           3,
+		CreationTimeSeconds: 1551448800,
+		Problem:             goforces.Problem{Index: "B"},
+		Author:              goforces.Party{Members: []goforces.Member{{Handle: "ana"}}},
+		ProgrammingLanguage: "GNU C11",
+		Verdict:             "OK",
+	}}}
+
+	cf := codeforces.NewJudge(api, 0, 0, 1, 0, 0)
+
+	if user, err := cf.GetUser(context.Background(), "ana"); err != nil || user.Avatar != "https://userpic.codeforces.com/ana.jpg" {
+		t.Errorf("Avatar should have the scheme, got: %+v %v.", user, err)
+	}
+
+	standings, err := cf.GetContestStandings(context.Background(), 3, []string{"ana"})
+
+	if err != nil || standings.ContestID != 3 || len(standings.Rows) != 2 || standings.Rows[0].Handles[0] != "ana" {
+		t.Errorf("Standings should be converted, got: %+v %v.", standings, err)
+	}
+
+	submissions, err := cf.GetUserSubmissions(context.Background(), "ana", 3)
+
+	if err != nil || len(submissions) != 1 || api.handle != "ana" {
+		t.Fatalf("Submissions of the contest should be read, got: %+v %v.", submissions, err)
+	}
+
+	if s := submissions[0]; s.ProblemIndex != "B" || s.Handle != "ana" || s.Verdict != "OK" || s.CreatedAT.Unix() != 1551448800 {
+		t.Errorf("Submission should be converted, got: %+v.", s)
+	}
+
+	api.status = append(api.status, goforces.Submission{ID: 11, ContestID: 3, Author: goforces.Party{Members: []goforces.Member{{Handle: "bia"}}}})
+	api.statusCalls = 0
+
+	submissions, err = cf.GetContestSubmissions(context.Background(), 3, []string{"ANA", "joao"})
+
+	if err != nil || len(submissions) != 1 || submissions[0].Handle != "ana" || api.handle != "" || api.statusCalls != 1 {
+		t.Errorf("Submissions of the contest should be read once and kept by handle, got: %+v %v.", submissions, err)
+	}
+
+	if cf.ContestURL("abc", 3) != "https://codeforces.com/group/abc/contest/3" {
+		t.Errorf("Invalid contest url, got: %s.", cf.ContestURL("abc", 3))
+	}
+}
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/deadline"
+	"github.com/apc-unb/apc-api/web/grading"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/apc-unb/apc-api/web/listgrade"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestListGradeCurve(t *testing.T) {
+
+	tests := []struct {
+		curve listgrade.Curve
+		score float64
+		grade float64
+	}{
+		{listgrade.Curve{}, 0.5, 5},
+		{listgrade.Curve{Kind: listgrade.Linear, Max: 100}, 1.0 / 3, 33.33},
+		{listgrade.Curve{Kind: listgrade.Linear, Full: 0.8}, 0.9, 10},
+		{listgrade.Curve{Kind: listgrade.Power, Exponent: 0.5}, 0.25, 5},
+		{listgrade.Curve{Kind: listgrade.Steps, Steps: []listgrade.Step{{Score: 0.5, Grade: 5}, {Score: 0.9, Grade: 10}}}, 0.4, 0},
+		{listgrade.Curve{Kind: listgrade.Steps, Steps: []listgrade.Step{{Score: 0.5, Grade: 5}, {Score: 0.9, Grade: 10}}}, 0.7, 5},
+	}
+
+	for i, test := range tests {
+		if grade := test.curve.Grade(test.score); grade != test.grade {
+			t.Errorf("Invalid grade of curve %d, expected: %f, got: %f.", i, test.grade, grade)
+		}
+	}
+
+	invalid := []listgrade.Curve{
+		{Kind: "log"},
+		{Kind: listgrade.Linear, Full: 2},
+		{Kind: listgrade.Power},
+		{Kind: listgrade.Steps},
+		{Kind: listgrade.Steps, Steps: []listgrade.Step{{Score: 0.9, Grade: 10}, {Score: 0.5, Grade: 5}}},
+	}
+
+	for i, curve := range invalid {
+		if err := curve.Validate(); err == nil {
+			t.Errorf("Invalid curve %d returned no error.", i)
+		}
+	}
+}
+
+func TestListGradeScore(t *testing.T) {
+
+	problems := []string{"A", "B", "C", "D"}
+	solved := map[string]float64{"A": 1, "C": 0.5}
+
+	credit, total := listgrade.Score(problems, solved, map[string]float64{"c": 3, "D": 0})
+
+	if credit != 2.5 || total != 5 {
+		t.Errorf("Problems should count by weight, got: %f of %f.", credit, total)
+	}
+
+	if err := listgrade.ValidateWeights(map[string]float64{"A": -1}); err == nil {
+		t.Errorf("Negative weight returned no error.")
+	}
+}
+
+func TestGradeHistoryOrigins(t *testing.T) {
+
+	enrollmentID := primitive.NewObjectID()
+	value := func(v float64) *float64 { return &v }
+
+	change := func(index int, origin string, old, new *float64) gradeHistory.Change {
+		return gradeHistory.Change{ID: primitive.NewObjectID(), EnrollmentID: enrollmentID, Source: grading.Lists, Index: index, Origin: origin, Old: old, New: new}
+	}
+
+	judged := change(1, gradeHistory.OriginJudge, nil, value(6))
+	typed := change(1, gradeHistory.OriginManual, value(6), value(9))
+	revert := change(1, gradeHistory.OriginRevert, value(9), value(6))
+	revert.RevertOf = typed.ID
+
+	imported := change(2, gradeHistory.OriginImport, nil, value(7))
+
+	origins := gradeHistory.Origins([]gradeHistory.Change{judged, typed, imported})
+
+	if origin := origins[gradeHistory.Grade{EnrollmentID: enrollmentID, Source: grading.Lists, Index: 1}]; origin != gradeHistory.OriginManual {
+		t.Errorf("Grade typed after the judge should be manual, got: %s.", origin)
+	}
+
+	if origin := origins[gradeHistory.Grade{EnrollmentID: enrollmentID, Source: grading.Lists, Index: 2}]; origin != gradeHistory.OriginImport {
+		t.Errorf("Imported grade should be import, got: %s.", origin)
+	}
+
+	origins = gradeHistory.Origins([]gradeHistory.Change{judged, typed, revert})
+
+	if origin := origins[gradeHistory.Grade{EnrollmentID: enrollmentID, Source: grading.Lists, Index: 1}]; origin != gradeHistory.OriginJudge {
+		t.Errorf("Reverting the manual grade should give it back to the judge, got: %s.", origin)
+	}
+}
+
+func TestListGrades(t *testing.T) {
+
+	now := time.Date(2019, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	ana := grade.StudentGrade{StudentID: primitive.NewObjectID(), EnrollmentID: primitive.NewObjectID(), Matricula: "1", Status: enrollment.Active}
+	joao := grade.StudentGrade{StudentID: primitive.NewObjectID(), EnrollmentID: primitive.NewObjectID(), Matricula: "2", Status: enrollment.Active}
+	maria := grade.StudentGrade{StudentID: primitive.NewObjectID(), EnrollmentID: primitive.NewObjectID(), Matricula: "3", Status: enrollment.Dropped}
+
+	// Ana got list 1 from the judge before, João typed by a monitor
+	ana.Grades.Lists = []float64{2}
+	joao.Grades.Lists = []float64{5}
+
+	origins := map[gradeHistory.Grade]string{
+		{EnrollmentID: ana.EnrollmentID, Source: grading.Lists, Index: 1}:  gradeHistory.OriginJudge,
+		{EnrollmentID: joao.EnrollmentID, Source: grading.Lists, Index: 1}: gradeHistory.OriginManual,
+	}
+
+	contests := []contest.Contest{
+		{ContestID: 100, List: 1, ProblemWeights: map[string]float64{"C": 2}},
+		{ContestID: 101, List: 2},
+		{ContestID: 102, List: 2},
+		{ContestID: 103, List: 3, OpensAT: now.Add(time.Hour)},
+		{ContestID: 104},
+	}
+
+	snapshot := func(student grade.StudentGrade, contestID int, problems []string, solved map[string]float64) progress.Snapshot {
+		return progress.Snapshot{StudentID: student.StudentID, ContestID: contestID, Problems: problems, Solved: solved}
+	}
+
+	snapshots := []progress.Snapshot{
+		snapshot(ana, 100, []string{"A", "B", "C"}, map[string]float64{"A": 1, "C": 1}),
+		snapshot(ana, 101, []string{"A", "B"}, map[string]float64{"A": 1, "B": 0.5}),
+		snapshot(ana, 102, []string{"A", "B"}, map[string]float64{}),
+		snapshot(ana, 103, []string{"A"}, map[string]float64{"A": 1}),
+		snapshot(ana, 104, []string{"A"}, map[string]float64{"A": 1}),
+		snapshot(joao, 100, []string{"A", "B", "C"}, map[string]float64{"A": 1, "B": 1, "C": 1}),
+		{StudentID: joao.StudentID, ContestID: 101, Credit: 1, Total: 4},
+		snapshot(maria, 100, []string{"A", "B", "C"}, map[string]float64{"A": 1}),
+	}
+
+	sheet := []grade.StudentGrade{ana, joao, maria}
+
+	grades := grade.ListGrades(sheet, contests, snapshots, listgrade.Curve{}, origins, false, now)
+
+	if len(grades) != 4 {
+		t.Fatalf("Active students should get the lists already open, got: %+v.", grades)
+	}
+
+	if first := grades[0]; first.List != 1 || first.Credit != 3 || first.Total != 4 || first.Grade != 7.5 || first.Action != roster.ActionUpdated {
+		t.Errorf("Judge grade should be updated with the weighted problems, got: %+v.", first)
+	}
+
+	if second := grades[1]; second.List != 2 || len(second.ContestIDs) != 2 || second.Grade != 3.75 || second.Old != nil {
+		t.Errorf("Contests of the same list should make a single grade, got: %+v.", second)
+	}
+
+	if override := grades[2]; override.Grade != 10 || *override.Old != 5 || override.Origin != gradeHistory.OriginManual || override.Action != grade.ActionOverridden {
+		t.Errorf("Grade typed by hand should be kept, got: %+v.", override)
+	}
+
+	if legacy := grades[3]; legacy.Credit != 1 || legacy.Total != 4 || legacy.Grade != 2.5 {
+		t.Errorf("Snapshot without problems should use the count, got: %+v.", legacy)
+	}
+
+	forced := grade.ListGrades(sheet, contests, snapshots, listgrade.Curve{}, nil, true, now)
+
+	if forced[0].Action != roster.ActionUpdated || forced[2].Action != roster.ActionUpdated {
+		t.Errorf("Force should replace every grade, got: %+v.", forced)
+	}
+
+	// Without history the grade was typed before it, so it is kept too
+	if kept := grade.ListGrades(sheet, contests, snapshots, listgrade.Curve{}, nil, false, now); kept[0].Action != grade.ActionOverridden || kept[0].Origin != "" {
+		t.Errorf("Grade without history should be kept, got: %+v.", kept[0])
+	}
+}
+
+func TestProgressSolvedProblems(t *testing.T) {
+
+	standings := judge.Standings{
+		ContestID: 100,
+		Problems:  []judge.Problem{{Index: "a"}, {Index: "B"}, {}},
+		Rows: []judge.Row{
+			progressRow("ana", 1, 0, 0),
+			progressRow("Ana", 1, 0, 1),
+		},
+	}
+
+	if problems := progress.Problems(standings); problems[0] != "A" || problems[2] != "C" {
+		t.Errorf("Problems should be in upper case and lettered when the judge doesn't index them, got: %v.", problems)
+	}
+
+	enrollments := []enrollment.Enrollment{
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "ana"}},
+	}
+
+	snapshots := progress.Snapshots(primitive.NewObjectID(), 100, enrollments, standings, time.Now())
+
+	if snapshot := snapshots[0]; snapshot.Done != 2 || len(snapshot.Problems) != 3 || snapshot.Solved["A"] != 1 || snapshot.Solved["C"] != 1 {
+		t.Fatalf("Snapshot should keep the problems solved, got: %+v.", snapshot)
+	}
+
+	policy := deadline.Policy{ContestID: 100, Kind: deadline.Fixed, Deadline: due, Penalty: 0.5}
+
+	solved := map[primitive.ObjectID]map[string]time.Time{
+		enrollments[0].StudentID: {"A": due.Add(-time.Hour), "C": due.Add(time.Hour)},
+	}
+
+	snapshots = progress.ApplyPolicy(snapshots, policy, solved)
+
+	if snapshot := snapshots[0]; snapshot.Solved["A"] != 1 || snapshot.Solved["C"] != 0.5 {
+		t.Errorf("Late problem should keep the credit of the policy, got: %v.", snapshot.Solved)
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/components/loginAttempt"
+	"github.com/apc-unb/apc-api/web/utils"
+)
+
+func TestLoginAttemptLockDuration(t *testing.T) {
+
+	policy := loginAttempt.Policy{
+		MaxFailures: 5,
+		BaseLock:    30 * time.Second,
+		MaxLock:     5 * time.Minute,
+		Window:      time.Hour,
+	}
+
+	cases := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, 30 * time.Second},
+		{6, time.Minute},
+		{7, 2 * time.Minute},
+		{8, 4 * time.Minute},
+		{9, 5 * time.Minute},
+		{100, 5 * time.Minute},
+	}
+
+	for _, c := range cases {
+		if got := policy.LockDuration(c.failures); got != c.want {
+			t.Errorf("Invalid lock after %d failures, got: %s, want: %s.", c.failures, got, c.want)
+		}
+	}
+
+	if loginAttempt.IPPolicy.MaxFailures <= loginAttempt.MatriculaPolicy.MaxFailures {
+		t.Errorf("IP policy should allow more failures than a single matricula")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+
+	request := httptest.NewRequest("POST", "/student/login", nil)
+	request.RemoteAddr = "172.18.0.2:51234"
+	request.Header.Set("X-Forwarded-For", "164.41.0.10, 172.18.0.2")
+
+	if ip := utils.ClientIP(request, false); ip != "172.18.0.2" {
+		t.Errorf("Invalid client ip, got: %s, want: %s.", ip, "172.18.0.2")
+	}
+
+	if ip := utils.ClientIP(request, true); ip != "164.41.0.10" {
+		t.Errorf("Invalid proxied client ip, got: %s, want: %s.", ip, "164.41.0.10")
+	}
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/mailer"
+)
+
+func TestFileMailer(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "mails")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var sender mailer.Mailer = &mailer.FileMailer{Dir: dir, From: "dragont@localhost"}
+
+	message := mailer.Message{
+		To:      []string{"thiago@unb.br"},
+		Subject: "Reset\r\nBcc: someone@evil.com",
+		Body:    "https://dragont/reset?token=abc",
+	}
+
+	if err := sender.Send(message); err != nil {
+		t.Fatalf("Error sending email: %s", err.Error())
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+
+	if len(files) != 1 {
+		t.Fatalf("Invalid amount of emails, got: %d, want: %d.", len(files), 1)
+	}
+
+	content, _ := ioutil.ReadFile(dir + "/" + files[0].Name())
+
+	if !strings.Contains(string(content), "To: thiago@unb.br\r\n") {
+		t.Errorf("Email without recipient header: %s", content)
+	}
+
+	if strings.Contains(string(content), "\r\nBcc:") {
+		t.Errorf("Email subject should not inject headers: %s", content)
+	}
+
+	if !strings.Contains(string(content), "token=abc") {
+		t.Errorf("Email without body: %s", content)
+	}
+
+	if err := sender.Send(mailer.Message{Subject: "Empty"}); err == nil {
+		t.Errorf("Email without recipients should fail")
+	}
+}
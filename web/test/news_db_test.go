@@ -54,8 +54,10 @@ func TestNewsDB(t *testing.T) {
 	// Test if news class array can be inserted in test database
 	// Checks if err variable is not null
 
-	if err := news.CreateNews(db, []news.NewsCreate{news1, news2, news3}, "apc_database_test", "news_test"); err != nil {
-		t.Errorf("Failed to insert news in Database : %s", err)
+	for _, current := range []news.NewsCreate{news1, news2, news3} {
+		if err := news.CreateNews(db, current, "apc_database_test", "news_test"); err != nil {
+			t.Errorf("Failed to insert news in Database : %s", err)
+		}
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -75,7 +77,7 @@ func TestNewsDB(t *testing.T) {
 	newsArray[0].Title = "Teste 7"
 	newsArray[0].Description = "Teste 7"
 
-	if err := news.UpdateNews(db, []news.News{newsArray[0]}, "apc_database_test", "news_test"); err != nil {
+	if err := news.UpdateNews(db, newsArray[0], "apc_database_test", "news_test"); err != nil {
 		t.Errorf("Failed to update news in Database : %s", err)
 	}
 
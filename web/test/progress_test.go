@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apc-unb/apc-api/web/codeforces"
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func progressRow(handle string, points ...float64) judge.Row {
+	return judge.Row{Handles: []string{handle}, Points: points}
+}
+
+func TestProgressSnapshots(t *testing.T) {
+
+	standings := judge.Standings{
+		ContestID: 100,
+		Name:      "Lista 1",
+		Problems:  make([]judge.Problem, 3),
+		Rows: []judge.Row{
+			progressRow("Ana", 1, 0, 0),
+			progressRow("ana", 1, 1, 0),
+			progressRow("joao", 0, 0, 1),
+		},
+	}
+
+	enrollments := []enrollment.Enrollment{
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "ANA"}},
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "pedro"}},
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Dropped, Handles: enrollment.Handles{Codeforces: "joao"}},
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Active},
+	}
+
+	if handles := progress.Handles(enrollments); len(handles) != 2 || handles[0] != "ANA" || handles[1] != "pedro" {
+		t.Errorf("Only active handles should be synced, got: %v.", handles)
+	}
+
+	now := time.Now()
+	snapshots := progress.Snapshots(primitive.NewObjectID(), 100, enrollments, standings, now)
+
+	if len(snapshots) != 2 {
+		t.Fatalf("Invalid number of snapshots, expected: 2, got: %d.", len(snapshots))
+	}
+
+	if snapshot := snapshots[0]; snapshot.Done != 2 || snapshot.Total != 3 || snapshot.ContestName != "Lista 1" || snapshot.StudentID != enrollments[0].StudentID {
+		t.Errorf("Problems solved in many rows should count once, got: %+v.", snapshot)
+	}
+
+	if snapshot := snapshots[1]; snapshot.Done != 0 || snapshot.Total != 3 || !snapshot.SyncedAT.Equal(now) {
+		t.Errorf("Student missing from the standings should have nothing done, got: %+v.", snapshot)
+	}
+}
+
+func TestProgressContests(t *testing.T) {
+
+	class := schoolClass.SchoolClass{ContestsIDs: []int{2, 1, 3}, GroupID: "abc"}
+
+	old := time.Now().Add(-time.Hour)
+	recent := time.Now()
+
+	snapshots := []progress.Snapshot{
+		{ContestID: 1, ContestName: "Lista 1", Handle: "Ana", Done: 2, Total: 5, SyncedAT: recent},
+		{ContestID: 2, ContestName: "Lista 2", Handle: "ana", Done: 1, Total: 4, SyncedAT: old},
+		{ContestID: 3, ContestName: "Lista 3", Handle: "old_handle", Done: 4, Total: 4, SyncedAT: recent},
+	}
+
+	classContests := contest.FromClass(class, nil)
+	classContests[2].Name = "Recursão"
+
+	contests := progress.Contests(class, classContests, codeforces.NewJudge(nil, 0, 0, 1, 0, 0), "ANA", snapshots)
+
+	if len(contests) != 3 || contests[0].Name != "Lista 2" || contests[1].Name != "Lista 1" || contests[1].List != 2 {
+		t.Fatalf("Contests should be in the order of the class, got: %+v.", contests)
+	}
+
+	if contests[2].Name != "Recursão" {
+		t.Errorf("Name of the contest should be used before the one of the judge, got: %s.", contests[2].Name)
+	}
+
+	if contests[0].URL != "https://codeforces.com/group/abc/contest/2" {
+		t.Errorf("Invalid contest url, got: %s.", contests[0].URL)
+	}
+
+	if contests[2].Done != 0 || contests[2].SyncedAT != nil {
+		t.Errorf("Snapshot of another handle should be ignored, got: %+v.", contests[2])
+	}
+
+	summary := progress.Summarize(contests)
+
+	if summary.Done != "3" || summary.Total != "9" || summary.SyncedAT == nil || !summary.SyncedAT.Equal(old) {
+		t.Errorf("Summary should add the contests with the oldest sync, got: %+v.", summary)
+	}
+
+	if summary := progress.Summarize(progress.Contests(class, classContests, judge.NewFake(), "ana", nil)); summary.Done != "0" || summary.SyncedAT != nil {
+		t.Errorf("Class never synced should have no sync time, got: %+v.", summary)
+	}
+}
+
+func TestCodeforcesRateLimited(t *testing.T) {
+
+	api := &fakeStandings{}
+	limited := codeforces.NewRateLimited(api, 30*time.Millisecond)
+
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, err := limited.GetContestStandings(context.Background(), 1, nil); err != nil {
+			t.Fatalf("Standings should be returned, got: %s", err.Error())
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Calls should wait the interval, took: %s.", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	limited.GetContestStandings(context.Background(), 1, nil)
+
+	if _, err := limited.GetContestStandings(ctx, 1, nil); err == nil || api.calls != 4 {
+		t.Errorf("Cancelled call shouldn't reach Codeforces, got: %d calls %v.", api.calls, err)
+	}
+}
+
+func TestProgressMark(t *testing.T) {
+
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	old := now.Add(-time.Hour)
+
+	cases := []struct {
+		summary progress.Summary
+		up      bool
+		want    string
+	}{
+		{progress.Summary{SyncedAT: &recent}, true, progress.Fresh},
+		{progress.Summary{SyncedAT: &old}, true, progress.Stale},
+		{progress.Summary{SyncedAT: &recent}, false, progress.Stale},
+		{progress.Summary{}, true, progress.Unavailable},
+		{progress.Summary{SyncedAT: &recent, Status: progress.Stale}, true, progress.Stale},
+	}
+
+	for _, c := range cases {
+		if got := progress.Mark(c.summary, 30*time.Minute, c.up, now).Status; got != c.want {
+			t.Errorf("Invalid status of %+v with judge up %v, expected: %s, got: %s.", c.summary, c.up, c.want, got)
+		}
+	}
+}
+
+func TestProgressMemory(t *testing.T) {
+
+	memory := progress.NewMemory()
+	classID, studentID := primitive.NewObjectID(), primitive.NewObjectID()
+
+	if summary := memory.Recall(classID, studentID); summary.Status != progress.Unavailable || summary.Done != "0" {
+		t.Errorf("Unknown student should have unavailable progress, got: %+v.", summary)
+	}
+
+	memory.Remember(classID, studentID, progress.Summary{Done: "3", Total: "10", Status: progress.Fresh})
+
+	if summary := memory.Recall(classID, studentID); summary.Status != progress.Stale || summary.Done != "3" {
+		t.Errorf("Last summary should be recalled as stale, got: %+v.", summary)
+	}
+
+	if summary := memory.Recall(primitive.NewObjectID(), studentID); summary.Status != progress.Unavailable {
+		t.Errorf("Summary of another class shouldn't be recalled, got: %+v.", summary)
+	}
+}
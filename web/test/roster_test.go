@@ -0,0 +1,203 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/apc-unb/apc-api/web/roster"
+)
+
+func TestRosterSIGAA(t *testing.T) {
+
+	file := "ANO/SEMESTE/TURMA,2019/2/A\n" +
+		"160140000,Thiago Veras Machado\n" +
+		"\"16/0140001\",\"Giovanni Guidini\"\n" +
+		"\n" +
+		"160140000,Vitor Dullens\n" +
+		"abc,\n"
+
+	parsed, err := roster.Parse([]byte(file), roster.Mapping{})
+
+	if err != nil {
+		t.Fatalf("Error parsing roster: %s", err.Error())
+	}
+
+	if len(parsed.Class) != 3 || parsed.Class[0] != "2019" || parsed.Class[2] != "A" {
+		t.Errorf("Invalid class line, got: %v.", parsed.Class)
+	}
+
+	if len(parsed.Rows) != 4 {
+		t.Fatalf("Invalid amount of rows, got: %d, want: %d.", len(parsed.Rows), 4)
+	}
+
+	first := parsed.Rows[0]
+
+	if first.Line != 2 || first.Matricula != "160140000" || first.FirstName != "Thiago" || first.LastName != "Veras Machado" {
+		t.Errorf("Invalid first row, got: %+v.", first)
+	}
+
+	if parsed.Rows[1].Matricula != "160140001" || parsed.Rows[1].Status != roster.StatusValid {
+		t.Errorf("Matricula with slash should be accepted, got: %+v.", parsed.Rows[1])
+	}
+
+	if parsed.Rows[2].Status != roster.StatusInvalid || parsed.Rows[2].Line != 5 {
+		t.Errorf("Duplicated matricula should be invalid, got: %+v.", parsed.Rows[2])
+	}
+
+	if len(parsed.Rows[3].Errors) != 2 {
+		t.Errorf("Row without matricula and name should have 2 errors, got: %v.", parsed.Rows[3].Errors)
+	}
+
+	parsed.MarkExisting(map[string]bool{"160140001": true}, "Matricula already registered")
+
+	report := parsed.Report(true)
+
+	if report.Total != 4 || report.Valid != 1 || report.Invalid != 3 {
+		t.Errorf("Invalid report, got: %d total %d valid %d invalid.", report.Total, report.Valid, report.Invalid)
+	}
+}
+
+func TestRosterLatin1(t *testing.T) {
+
+	// "Matrícula;Nome;E-mail;Handle" and "João" written in Latin-1
+	file := "Matr\xedcula;Nome;E-mail;Handle\r\n190012345;Jo\xe3o Ara\xfajo;joao@aluno.unb.br;joao_cf\r\n190012346;Maria;not-an-email;x\r\n"
+
+	parsed, err := roster.Parse([]byte(file), roster.Mapping{})
+
+	if err != nil {
+		t.Fatalf("Error parsing roster: %s", err.Error())
+	}
+
+	if parsed.Encoding != "latin-1" {
+		t.Errorf("Invalid encoding, got: %s, want: %s.", parsed.Encoding, "latin-1")
+	}
+
+	row := parsed.Rows[0]
+
+	if row.FirstName != "João" || row.LastName != "Araújo" || row.Email != "joao@aluno.unb.br" || row.Codeforces != "joao_cf" {
+		t.Errorf("Invalid row, got: %+v.", row)
+	}
+
+	if row.Line != 2 || row.Status != roster.StatusValid {
+		t.Errorf("Row should be valid on line 2, got: %+v.", row)
+	}
+
+	if len(parsed.Rows[1].Errors) != 2 {
+		t.Errorf("Invalid email and handle should be reported, got: %v.", parsed.Rows[1].Errors)
+	}
+
+	if _, err := roster.Parse([]byte(file), roster.Mapping{Email: "Correio Eletrônico"}); err == nil {
+		t.Errorf("Mapping to a missing column should fail")
+	}
+
+	mapped, err := roster.Parse([]byte("a;b;c\n1;190012345;Ana Lima\n"), roster.Mapping{Matricula: "2", Name: "3"})
+
+	if err != nil {
+		t.Fatalf("Error parsing mapped roster: %s", err.Error())
+	}
+
+	if len(mapped.Rows) != 1 || mapped.Rows[0].Matricula != "190012345" || mapped.Rows[0].FirstName != "Ana" {
+		t.Errorf("Invalid mapped rows, got: %+v.", mapped.Rows)
+	}
+}
+
+func TestRosterXLSX(t *testing.T) {
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	files := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Turma" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId3" Type="worksheet" Target="worksheets/alunos.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>Matrícula</t></si><si><t>Nome</t></si><si><r><t>Ana </t></r><r><t>Lima</t></r></si></sst>`,
+		"xl/worksheets/alunos.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="3"><c r="A3"><v>1.90012345E8</v></c><c r="C3" t="s"><v>2</v></c></row>` +
+			`<row r="4"><c r="A4" t="inlineStr"><is><t>190012346</t></is></c><c r="C4" t="inlineStr"><is><t>Bruno</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+	}
+
+	for name, content := range files {
+		writer, _ := archive.Create(name)
+		writer.Write([]byte(content))
+	}
+
+	archive.Close()
+
+	parsed, err := roster.Parse(buffer.Bytes(), roster.Mapping{})
+
+	if err != nil {
+		t.Fatalf("Error parsing roster: %s", err.Error())
+	}
+
+	if len(parsed.Rows) != 2 {
+		t.Fatalf("Invalid amount of rows, got: %d, want: %d.", len(parsed.Rows), 2)
+	}
+
+	if parsed.Rows[0].Line != 3 || parsed.Rows[0].Matricula != "190012345" || parsed.Rows[0].LastName != "Lima" {
+		t.Errorf("Invalid first row, got: %+v.", parsed.Rows[0])
+	}
+
+	if parsed.Rows[1].Matricula != "190012346" || parsed.Rows[1].FirstName != "Bruno" {
+		t.Errorf("Invalid second row, got: %+v.", parsed.Rows[1])
+	}
+
+	if _, err := roster.Parse([]byte("PK\x03\x04broken"), roster.Mapping{}); err == nil {
+		t.Errorf("Broken XLSX should fail")
+	}
+}
+
+func TestRosterXLSXMalformed(t *testing.T) {
+
+	sheets := []string{
+		`<row r="1"><c r="A1" t="s"><v>-1</v></c></row>`,
+		`<row r="1"><c r="A1" t="s"><v>1</v></c></row>`,
+		`<row r="1"><c r="1"><v>190012345</v></c></row>`,
+		`<row r="1"><c r="XFE1"><v>190012345</v></c></row>`,
+		`<row r="1"><c r="AAAAAAAAAAAAAAAA1"><v>190012345</v></c></row>`,
+		`<row r="-3"><c r="A1"><v>190012345</v></c></row>`,
+		`<row r="1048577"><c r="A1"><v>190012345</v></c></row>`,
+	}
+
+	for i, sheet := range sheets {
+
+		var buffer bytes.Buffer
+		archive := zip.NewWriter(&buffer)
+
+		files := map[string]string{
+			"xl/sharedStrings.xml":     `<sst><si><t>Matrícula</t></si></sst>`,
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + sheet + `</sheetData></worksheet>`,
+		}
+
+		for name, content := range files {
+			writer, _ := archive.Create(name)
+			writer.Write([]byte(content))
+		}
+
+		archive.Close()
+
+		if _, _, err := roster.Records(buffer.Bytes()); err == nil {
+			t.Errorf("Malformed sheet %d should fail.", i)
+		}
+	}
+}
+
+func TestRosterCountActions(t *testing.T) {
+
+	report := roster.Report{Rows: []roster.Row{
+		{Matricula: "190012345", Action: roster.ActionCreated},
+		{Matricula: "190012346", Action: roster.ActionUpdated},
+		{Matricula: "190012349", Action: roster.ActionEnrolled},
+		{Matricula: "190012347", Action: roster.ActionUnchanged},
+		{Matricula: "190012348", Action: roster.ActionUnchanged},
+	}}
+
+	report.CountActions()
+
+	if report.Created != 1 || report.Enrolled != 1 || report.Updated != 1 || report.Unchanged != 2 {
+		t.Errorf("Invalid actions, got: %d created %d enrolled %d updated %d unchanged.", report.Created, report.Enrolled, report.Updated, report.Unchanged)
+	}
+}
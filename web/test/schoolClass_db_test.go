@@ -5,8 +5,7 @@ import (
 	"log"
 	"testing"
 
-	"github.com/components/schoolClass"
-	"github.com/components/student"
+	"github.com/apc-unb/apc-api/web/components/schoolClass"
 
 	"github.com/mongodb/mongo-go-driver/mongo"
 )
@@ -49,66 +48,20 @@ func TestClassDB(t *testing.T) {
 	// Drop all content to start testing
 	collection.Drop(context.TODO())
 
-	grades := student.StudentGrades{
-		Exams:    []float64{1.4, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
-	}
-
 	// Instantiate some school class objects
-	student1 := student.Student{
-		FirstName: "Thiago",
-		LastName:  "Veras Machado",
-		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
-		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
-		Grade:     grades,
-	}
-
-	student2 := student.Student{
-		FirstName: "Vitor",
-		LastName:  "Fernandes Dullens",
-		Matricula: "160571946",
-		Handles:   []string{"vitordullens", "2353251"},
-		Password:  "Hgqwge1234",
-		PhotoURL:  "https://userpic.codeforces.com/551311/title/95d04d8b95b95302.jpg",
-		Grade:     grades,
-	}
-
-	student3 := student.Student{
-		FirstName: "Giovanni",
-		LastName:  "Guidini",
-		Matricula: "136246666",
-		Handles:   []string{"Gguidini", "11165"},
-		Password:  "12rw-1234",
-		PhotoURL:  "https://userpic.codeforces.com/765049/title/2075d6432eadaae9.jpg",
-		Grade:     grades,
-	}
-
-	student4 := student.Student{
-		FirstName: "Manoel",
-		LastName:  "Josias",
-		Matricula: "135426666",
-		Handles:   []string{"Hehe", "11525"},
-		Password:  "121521hh1234",
-		PhotoURL:  "https://userpic.codeforces.com/765049/title/2075d6432eadaae9.jpg",
-		Grade:     grades,
-	}
-
 	class1 := schoolClass.SchoolClassCreate{
 		ProfessorFirstName: "Carla",
 		ProfessorLastName:  "Castanho",
 		Year:               2019,
 		Season:             1,
-		Students:           []student.Student{student1, student2},
+		ContestsIDs:        []int{1100, 1101},
 	}
 	class2 := schoolClass.SchoolClassCreate{
 		ProfessorFirstName: "Carla",
 		ProfessorLastName:  "Castanho",
 		Year:               2018,
 		Season:             2,
-		Students:           []student.Student{student3, student4},
+		ContestsIDs:        []int{1102, 1103, 1104},
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -117,7 +70,7 @@ func TestClassDB(t *testing.T) {
 	// Test if class class array can be inserted in test database
 	// Checks if err variable is not null
 
-	if err := schoolClass.CreateClasses(db, []schoolClass.SchoolClassCreate{class1, class2}, "apc_database_test", "schoolClass_test"); err != nil {
+	if _, err := schoolClass.CreateClasses(db, []schoolClass.SchoolClassCreate{class1, class2}, "apc_database_test", "schoolClass_test"); err != nil {
 		t.Errorf("Failed to insert class in Database : %s", err)
 	}
 
@@ -138,7 +91,7 @@ func TestClassDB(t *testing.T) {
 	class[0].ProfessorFirstName = "Marcos"
 	class[0].ProfessorLastName = "Caetano"
 
-	if err := schoolClass.UpdateClasses(db, []schoolClass.SchoolClass{class[0]}, "apc_database_test", "schoolClass_test"); err != nil {
+	if err := schoolClass.UpdateClass(db, class[0], "apc_database_test", "schoolClass_test"); err != nil {
 		t.Errorf("Failed to update class in Database : %s", err)
 	}
 
@@ -153,7 +106,7 @@ func TestClassDB(t *testing.T) {
 	}
 
 	if class[0].ProfessorLastName != "Caetano" {
-		t.Errorf("Invalid class[0] professor first name, got: %s, want: %s.", class[2].ProfessorLastName, "Caetano")
+		t.Errorf("Invalid class[0] professor last name, got: %s, want: %s.", class[0].ProfessorLastName, "Caetano")
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -171,11 +124,11 @@ func TestClassDB(t *testing.T) {
 	}
 
 	if len(class) != 1 {
-		t.Errorf("Invalid students size, got: %d, want: %d.", len(class), 1)
+		t.Errorf("Invalid class size, got: %d, want: %d.", len(class), 1)
 	}
 
-	if len(class[0].Students) != 2 {
-		t.Errorf("Invalid students size from class, got: %d, want: %d.", len(class[0].Students), 2)
+	if len(class[0].ContestsIDs) != 3 {
+		t.Errorf("Invalid contests size from class, got: %d, want: %d.", len(class[0].ContestsIDs), 3)
 	}
 
 }
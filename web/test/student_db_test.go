@@ -5,7 +5,12 @@ import (
 	"log"
 	"testing"
 
-	"github.com/components/student"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/student"
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/apc-unb/apc-api/web/roster"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestStudentDB(t *testing.T) {
@@ -28,12 +33,16 @@ func TestStudentDB(t *testing.T) {
 
 	// Drop all content to start testing
 	collection.Drop(context.TODO())
+	db.Database("apc_database_test").Collection("student_test_login").Drop(context.TODO())
+
+	if err := student.CreateIndexes(db, "apc_database_test", "student_test"); err != nil {
+		t.Fatalf("Failed to create student indexes : %s", err)
+	}
 
 	// Instantiate grades for test
 	grades := student.StudentGrades{
-		Exams:    []float64{1.4, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
+		Exams: []float64{1.4, 2.3, 2.4},
+		Lists: []float64{1.2, 1.2, 1.2},
 	}
 
 	// Instantiate some students objects
@@ -41,8 +50,7 @@ func TestStudentDB(t *testing.T) {
 		FirstName: "Thiago",
 		LastName:  "Veras Machado",
 		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
+		Handles:   student.StudentHandles{Codeforces: "Veras", Uri: "113065"},
 		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
 		Email:     "teste@gmail.com",
 		Grades:    grades,
@@ -52,8 +60,7 @@ func TestStudentDB(t *testing.T) {
 		FirstName: "Vitor",
 		LastName:  "Fernandes Dullens",
 		Matricula: "160571946",
-		Handles:   []string{"vitordullens", "2353251"},
-		Password:  "Hgqwge1234",
+		Handles:   student.StudentHandles{Codeforces: "vitordullens", Uri: "2353251"},
 		PhotoURL:  "https://userpic.codeforces.com/551311/title/95d04d8b95b95302.jpg",
 		Email:     "teste@gmail.com",
 		Grades:    grades,
@@ -63,8 +70,7 @@ func TestStudentDB(t *testing.T) {
 		FirstName: "Giovanni",
 		LastName:  "Guidini",
 		Matricula: "136246666",
-		Handles:   []string{"Gguidini", "11165"},
-		Password:  "12rw-1234",
+		Handles:   student.StudentHandles{Codeforces: "Gguidini", Uri: "11165"},
 		PhotoURL:  "https://userpic.codeforces.com/765049/title/2075d6432eadaae9.jpg",
 		Email:     "teste@gmail.com",
 		Grades:    grades,
@@ -75,7 +81,11 @@ func TestStudentDB(t *testing.T) {
 	// Test if student class array can be inserted in test database
 	// Checks if err variable is not null
 
-	if err := student.CreateStudents(db, []student.StudentCreate{student1, student2, student3}, "apc_database_test", "student_test"); err != nil {
+	provider := judge.NewFake()
+
+	var credentials []user.UserCredentials
+
+	if credentials, err = student.CreateStudents(db, provider, []student.StudentCreate{student1, student2, student3}, user.DefaultPasswordPolicy, "apc_database_test", "student_test"); err != nil {
 		t.Errorf("Failed to insert students in Database : %s", err)
 	}
 
@@ -87,7 +97,7 @@ func TestStudentDB(t *testing.T) {
 	// Checks if err variable is not null
 	//
 
-	var students []student.Student
+	var students []student.StudentInfo
 
 	if students, err = student.GetStudents(db, "apc_database_test", "student_test"); err != nil {
 		t.Errorf("Failed to get students from Database : %s", err)
@@ -97,17 +107,17 @@ func TestStudentDB(t *testing.T) {
 	// 							UPDATE LIST OF STUDENTS FROM DB TEST   		         		 //
 	///////////////////////////////////////////////////////////////////////////////////////////
 	//
-	// Test if student class array can be updated in test database
-	// Change some students name, then update that class on DB
+	// Test if a student can change his email in test database
+	// The current password is the one returned when the student was created
 	// Checks if err variable is not null
 
-	students[0].FirstName = "Guilherme"
-	students[0].LastName = "Carvalho"
-
-	students[2].FirstName = "Henrique"
-	students[2].LastName = "Machado"
+	update := student.StudentUpdate{
+		ID:       credentials[0].ID,
+		Email:    "veras@aluno.unb.br",
+		Password: credentials[0].Password,
+	}
 
-	if err := student.UpdateStudents(db, []student.Student{students[0], students[2]}, "apc_database_test", "student_test"); err != nil {
+	if err := student.UpdateStudents(db, provider, update, user.DefaultPasswordPolicy, "apc_database_test", "student_test"); err != nil {
 		t.Errorf("Failed to update students in Database : %s", err)
 	}
 
@@ -117,12 +127,12 @@ func TestStudentDB(t *testing.T) {
 		t.Errorf("Failed to get students from Database : %s", err)
 	}
 
-	if students[0].FirstName != "Guilherme" {
-		t.Errorf("Invalid students[0] first name, got: %s, want: %s.", students[0].FirstName, "Thiago")
+	if students[0].Email != "veras@aluno.unb.br" {
+		t.Errorf("Invalid students[0] email, got: %s, want: %s.", students[0].Email, "veras@aluno.unb.br")
 	}
 
-	if students[2].LastName != "Machado" {
-		t.Errorf("Invalid students[2] last name, got: %s, want: %s.", students[2].LastName, "Machado")
+	if students[2].LastName != "Guidini" {
+		t.Errorf("Invalid students[2] last name, got: %s, want: %s.", students[2].LastName, "Guidini")
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -132,7 +142,7 @@ func TestStudentDB(t *testing.T) {
 	// Test if student class array can be deleted in test database
 	// Checks if err variable is not null
 
-	if err := student.DeleteStudents(db, []student.Student{students[0]}, "apc_database_test", "student_test"); err != nil {
+	if err := student.DeleteStudents(db, []student.Student{{ID: students[0].ID}}, "apc_database_test", "student_test"); err != nil {
 		t.Errorf("Failed to delete students in Database : %s", err)
 	}
 
@@ -146,4 +156,62 @@ func TestStudentDB(t *testing.T) {
 		t.Errorf("Invalid students size, got: %d, want: %d.", len(students), 2)
 	}
 
+	///////////////////////////////////////////////////////////////////////////////////////////
+	// 							REIMPORT DELETED STUDENT FROM DB TEST   		         		 //
+	///////////////////////////////////////////////////////////////////////////////////////////
+	//
+	// Test if the matricula of a deleted student can be imported again
+	// His old login must be gone, so only the new password works
+
+	file, err := roster.Parse([]byte("160156666,Thiago Veras Machado\n"), roster.Mapping{})
+
+	if err != nil {
+		t.Fatalf("Error parsing roster: %s", err)
+	}
+
+	report, created, err := student.CreateStudentsFile(db, file, primitive.NewObjectID(), roster.Options{}, user.DefaultPasswordPolicy, "apc_database_test", "student_test")
+
+	if err != nil {
+		t.Fatalf("Failed to import deleted student again : %s", err)
+	}
+
+	if len(created) != 1 || report.Rows[0].Action != roster.ActionCreated {
+		t.Fatalf("Deleted student should be created again, got: %+v.", report.Rows)
+	}
+
+	if _, err := student.AuthStudent(db, user.UserCredentials{Matricula: "160156666", Password: credentials[0].Password}, "apc_database_test", "student_test"); err == nil {
+		t.Errorf("Password of the deleted student shouldn't work")
+	}
+
+	if _, err := student.AuthStudent(db, created[0], "apc_database_test", "student_test"); err != nil {
+		t.Errorf("Failed to log in the imported student : %s", err)
+	}
+
+	///////////////////////////////////////////////////////////////////////////////////////////
+	// 							CREATE REGISTERED STUDENT FROM DB TEST   		         		 //
+	///////////////////////////////////////////////////////////////////////////////////////////
+	//
+	// Test if creating a matricula already registered only enrolls him in the new class
+	// No credentials are generated and his login keeps working
+
+	classID := primitive.NewObjectID()
+
+	again, err := student.CreateStudents(db, provider, []student.StudentCreate{{ClassID: classID, Matricula: "160156666"}}, user.DefaultPasswordPolicy, "apc_database_test", "student_test")
+
+	if err != nil {
+		t.Fatalf("Failed to create registered student : %s", err)
+	}
+
+	if len(again) != 0 {
+		t.Errorf("Registered student shouldn't get new credentials, got: %+v.", again)
+	}
+
+	if enrollments, err := enrollment.GetClassEnrollments(db, classID, "apc_database_test", "enrollment"); err != nil || len(enrollments) != 1 || enrollments[0].StudentID != created[0].ID {
+		t.Errorf("Registered student should be enrolled in the class, got: %+v, %v.", enrollments, err)
+	}
+
+	if _, err := student.AuthStudent(db, created[0], "apc_database_test", "student_test"); err != nil {
+		t.Errorf("Registered student should keep his login : %s", err)
+	}
+
 }
@@ -3,26 +3,26 @@ package test
 import (
 	"testing"
 
-	"github.com/components/student"
+	"github.com/apc-unb/apc-api/web/components/student"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestStudent(t *testing.T) {
 
 	// Instantiate grades for test
 	grades := student.StudentGrades{
-		Exams:    []float64{8.98, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
+		Exams: []float64{8.98, 2.3, 2.4},
+		Lists: []float64{1.2, 1.2, 1.2},
 	}
 
 	class1 := student.Student{
 		FirstName: "Thiago",
 		LastName:  "Veras Machado",
 		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
+		Handles:   student.StudentHandles{Codeforces: "Veras", Uri: "113065"},
 		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
-		Grade:     grades,
+		Email:     "veras@aluno.unb.br",
+		Grades:    grades,
 	}
 
 	if class1.FirstName != "Thiago" {
@@ -37,28 +37,53 @@ func TestStudent(t *testing.T) {
 		t.Errorf("Invalid matricula, got: %s, want: %s.", class1.Matricula, "160156666")
 	}
 
-	if len(class1.Handles) != 2 {
-		t.Errorf("Invalid handles size, got: %d, want: %d.", len(class1.Handles), 2)
+	if class1.Handles.Codeforces != "Veras" {
+		t.Errorf("Invalid codeforces handle, got: %s, want: %s.", class1.Handles.Codeforces, "Veras")
 	}
 
-	if class1.Handles[0] != "Veras" {
-		t.Errorf("Invalid handle[0], got: %s, want: %s.", class1.Handles[0], "Veras")
+	if class1.Handles.Uri != "113065" {
+		t.Errorf("Invalid uri handle, got: %s, want: %s.", class1.Handles.Uri, "113065")
 	}
 
-	if class1.Handles[1] != "113065" {
-		t.Errorf("Invalid handle[1], got: %s, want: %s.", class1.Handles[1], "113065")
-	}
-
-	if class1.Password != "HQFnf-1234" {
-		t.Errorf("Invalid password, got: %s, want: %s.", class1.Password, "HQFnf-1234")
+	if class1.Email != "veras@aluno.unb.br" {
+		t.Errorf("Invalid email, got: %s, want: %s.", class1.Email, "veras@aluno.unb.br")
 	}
 
 	if class1.PhotoURL != "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg" {
 		t.Errorf("Invalid photo url, got: %s, want: %s.", class1.PhotoURL, "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg")
 	}
 
-	if class1.Grade.Exams[0] != 8.98 {
-		t.Errorf("Invalid grade, got: %f, want: %f.", class1.Grade.Exams[0], 8.98)
+	if class1.Grades.Exams[0] != 8.98 {
+		t.Errorf("Invalid grade, got: %f, want: %f.", class1.Grades.Exams[0], 8.98)
+	}
+
+}
+
+func TestStudentDuplicates(t *testing.T) {
+
+	older, newer, changed, single := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+	students := []student.Student{
+		{ID: newer, Matricula: "160156666"},
+		{ID: older, Matricula: "160156666"},
+		{ID: single, Matricula: "170000000"},
+		{ID: first, Matricula: "180000000"},
+		{ID: changed, Matricula: "180000000"},
+		{ID: second, Matricula: "180000000"},
+	}
+
+	keepers := student.Duplicates(students, map[primitive.ObjectID]bool{changed: true})
+
+	if len(keepers) != 3 || keepers[newer] != older {
+		t.Errorf("Oldest student should be kept, got: %v.", keepers)
 	}
 
+	if keepers[first] != changed || keepers[second] != changed {
+		t.Errorf("Student that changed the password should be kept, got: %v.", keepers)
+	}
+
+	if _, found := keepers[single]; found {
+		t.Errorf("Student registered once shouldn't be merged.")
+	}
 }
@@ -4,9 +4,10 @@ import (
 	"context"
 	"log"
 	"testing"
+	"time"
 
-	"github.com/components/student"
-	"github.com/components/submission"
+	"github.com/apc-unb/apc-api/web/components/submission"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestSubmissionDB(t *testing.T) {
@@ -30,82 +31,53 @@ func TestSubmissionDB(t *testing.T) {
 	// Drop all content to start testing
 	collection.Drop(context.TODO())
 
-	// Instantiate grades for test
-	grades := student.StudentGrades{
-		Exams:    []float64{8.98, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
-	}
+	classID := primitive.NewObjectID()
+	student1 := primitive.NewObjectID()
+	student2 := primitive.NewObjectID()
+	student3 := primitive.NewObjectID()
+	student4 := primitive.NewObjectID()
 
-	// Instantiate some submissions objects
-	student1 := student.Student{
-		FirstName: "Thiago",
-		LastName:  "Veras Machado",
-		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
-		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
-		Grade:     grades,
-	}
-
-	student2 := student.Student{
-		FirstName: "Vitor",
-		LastName:  "Fernandes Dullens",
-		Matricula: "160571946",
-		Handles:   []string{"vitordullens", "2353251"},
-		Password:  "Hgqwge1234",
-		PhotoURL:  "https://userpic.codeforces.com/551311/title/95d04d8b95b95302.jpg",
-		Grade:     grades,
-	}
-
-	student3 := student.Student{
-		FirstName: "Giovanni",
-		LastName:  "Guidini",
-		Matricula: "136246666",
-		Handles:   []string{"Gguidini", "11165"},
-		Password:  "12rw-1234",
-		PhotoURL:  "https://userpic.codeforces.com/765049/title/2075d6432eadaae9.jpg",
-		Grade:     grades,
-	}
-
-	student4 := student.Student{
-		FirstName: "Manoel",
-		LastName:  "Josias",
-		Matricula: "135426666",
-		Handles:   []string{"Hehe", "11525"},
-		Password:  "121521hh1234",
-		PhotoURL:  "https://userpic.codeforces.com/765049/title/2075d6432eadaae9.jpg",
-		Grade:     grades,
-	}
+	start := time.Date(2019, time.June, 4, 19, 0, 0, 0, time.UTC)
 
+	// Instantiate some submissions objects
 	submission1 := submission.SubmissionCreate{
-		Student:  student1,
-		Veredict: "WA",
-		Time:     "19:03:55",
+		ClassID:      classID,
+		StudentID:    student1,
+		ProblemIndex: "A",
+		Verdict:      submission.WrongAnswer,
+		SubmittedAT:  start.Add(3 * time.Minute),
 	}
 
 	submission2 := submission.SubmissionCreate{
-		Student:  student2,
-		Veredict: "AC",
-		Time:     "19:07:55",
+		ClassID:      classID,
+		StudentID:    student2,
+		ProblemIndex: "A",
+		Verdict:      submission.Accepted,
+		SubmittedAT:  start.Add(7 * time.Minute),
 	}
 
 	submission3 := submission.SubmissionCreate{
-		Student:  student3,
-		Veredict: "TLE",
-		Time:     "19:23:55",
+		ClassID:      classID,
+		StudentID:    student3,
+		ProblemIndex: "B",
+		Verdict:      submission.TimeLimit,
+		SubmittedAT:  start.Add(23 * time.Minute),
 	}
 
 	submission4 := submission.SubmissionCreate{
-		Student:  student3,
-		Veredict: "AC",
-		Time:     "19:24:00",
+		ClassID:      classID,
+		StudentID:    student3,
+		ProblemIndex: "B",
+		Verdict:      submission.Accepted,
+		SubmittedAT:  start.Add(24 * time.Minute),
 	}
 
 	submission5 := submission.SubmissionCreate{
-		Student:  student4,
-		Veredict: "AC",
-		Time:     "19:33:55",
+		ClassID:      classID,
+		StudentID:    student4,
+		ProblemIndex: "C",
+		Verdict:      submission.Accepted,
+		SubmittedAT:  start.Add(33 * time.Minute),
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -132,8 +104,8 @@ func TestSubmissionDB(t *testing.T) {
 	// 							UPDATE LIST OF SUBMISSIONS FROM DB TEST   		      		 //
 	///////////////////////////////////////////////////////////////////////////////////////////
 
-	submissions[0].Veredict = "CE"
-	submissions[0].Student.FirstName, submissions[1].Student.FirstName = submissions[1].Student.FirstName, submissions[0].Student.FirstName
+	submissions[0].Verdict = submission.CompilationError
+	submissions[0].StudentID, submissions[1].StudentID = submissions[1].StudentID, submissions[0].StudentID
 
 	if err := submission.UpdateSubmissions(db, []submission.Submission{submissions[0], submissions[1]}, "apc_database_test", "submission_test"); err != nil {
 		t.Errorf("Failed to update submission in Database : %s", err)
@@ -145,20 +117,30 @@ func TestSubmissionDB(t *testing.T) {
 		t.Errorf("Failed to get submissions from Database : %s", err)
 	}
 
-	if submissions[0].Veredict != "CE" {
-		t.Errorf("Invalid submissions[0] veredict, got: %s, want: %s.", submissions[0].Veredict, "CE")
+	if submissions[0].Verdict != submission.CompilationError {
+		t.Errorf("Invalid submissions[0] verdict, got: %s, want: %s.", submissions[0].Verdict, submission.CompilationError)
+	}
+
+	if submissions[0].StudentID != student2 {
+		t.Errorf("Invalid submissions[0] student, got: %s, want: %s.", submissions[0].StudentID.Hex(), student2.Hex())
 	}
 
-	if submissions[0].Student.FirstName != "Vitor" {
-		t.Errorf("Invalid submissions[0] student name, got: %s, want: %s.", submissions[0].Student.FirstName, "Vitor")
+	if submissions[1].StudentID != student1 {
+		t.Errorf("Invalid submissions[1] student, got: %s, want: %s.", submissions[1].StudentID.Hex(), student1.Hex())
 	}
 
-	if submissions[1].Student.FirstName != "Thiago" {
-		t.Errorf("Invalid submissions[1] student name, got: %s, want: %s.", submissions[1].Student.FirstName, "Thiago")
+	if submissions[1].Verdict != submission.Accepted {
+		t.Errorf("Invalid submissions[1] verdict, got: %s, want: %s.", submissions[1].Verdict, submission.Accepted)
 	}
 
-	if submissions[1].Veredict != "AC" {
-		t.Errorf("Invalid submissions[0] veredict, got: %s, want: %s.", submissions[0].Veredict, "AC")
+	///////////////////////////////////////////////////////////////////////////////////////////
+	// 							FIND SUBMISSIONS OF A PROBLEM FROM DB TEST   		     	 //
+	///////////////////////////////////////////////////////////////////////////////////////////
+
+	found, err := submission.Find(db, submission.Filter{ClassID: classID, ProblemIndex: "b"}, "apc_database_test", "submission_test")
+
+	if err != nil || len(found) != 2 || found[0].Verdict != submission.Accepted {
+		t.Errorf("Invalid attempts of problem B, got: %v %v.", found, err)
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -1,47 +1,107 @@
 package test
 
 import (
+	"context"
 	"testing"
+	"time"
 
-	"github.com/components/student"
-	"github.com/components/submission"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/submission"
+	"github.com/apc-unb/apc-api/web/judge"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
 )
 
 func TestSubmission(t *testing.T) {
 
-	// Instantiate grades for test
-	grades := student.StudentGrades{
-		Exams:    []float64{8.98, 2.3, 2.4},
-		Projects: []float64{1.6, 3.1, 2.4},
-		Lists:    []float64{1.2, 1.2, 1.2},
+	verdicts := map[string]submission.Verdict{
+		"OK":                      submission.Accepted,
+		"WRONG_ANSWER":            submission.WrongAnswer,
+		"TIME_LIMIT_EXCEEDED":     submission.TimeLimit,
+		"IDLENESS_LIMIT_EXCEEDED": submission.TimeLimit,
+		"MEMORY_LIMIT_EXCEEDED":   submission.MemoryLimit,
+		"RUNTIME_ERROR":           submission.RuntimeError,
+		"COMPILATION_ERROR":       submission.CompilationError,
+		"TESTING":                 submission.Pending,
+		"":                        submission.Pending,
+		"CHALLENGED":              submission.Rejected,
 	}
 
-	student1 := student.Student{
-		FirstName: "Thiago",
-		LastName:  "Veras Machado",
-		Matricula: "160156666",
-		Handles:   []string{"Veras", "113065"},
-		Password:  "HQFnf-1234",
-		PhotoURL:  "https://userpic.codeforces.com/546204/title/d2ac05baf39339f.jpg",
-		Grade:     grades,
+	for verdict, want := range verdicts {
+		if got := submission.ParseVerdict(verdict); got != want {
+			t.Errorf("Invalid verdict of %q, got: %s, want: %s.", verdict, got, want)
+		}
 	}
 
-	class1 := submission.Submission{
-		Student:  student1,
-		Veredict: "AC",
-		Time:     "Jun/04/2019 03:51",
+	if !submission.IsVerdict(submission.Accepted) || submission.IsVerdict("OK") {
+		t.Error("Only the verdicts of the enum should be valid.")
+	}
+}
+
+func TestSubmissionsFromJudge(t *testing.T) {
+
+	classID := primitive.NewObjectID()
+	studentID := primitive.NewObjectID()
+	syncedAT := time.Date(2019, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	fake := judge.NewFake()
+
+	found, err := fake.GetUserSubmissions(context.Background(), "ana", 7)
+
+	if err != nil || len(found) == 0 {
+		t.Fatalf("Fake judge should generate submissions, got: %v %v.", found, err)
+	}
+
+	submissions := submission.Submissions(classID, studentID, found, syncedAT)
+
+	if len(submissions) != len(found) {
+		t.Fatalf("Every submission should be converted, got: %d, want: %d.", len(submissions), len(found))
 	}
 
-	if class1.Student.FirstName != "Thiago" {
-		t.Errorf("Invalid Submission student first name, got: %s, want: %s.", class1.Student.FirstName, "Thiago")
+	for i, current := range submissions {
+
+		if current.ClassID != classID || current.StudentID != studentID {
+			t.Errorf("Submission should reference the class and the student, got: %+v.", current)
+		}
+
+		if current.JudgeID != found[i].ID || current.ContestID != 7 || current.ProblemIndex != found[i].ProblemIndex {
+			t.Errorf("Submission should keep the judge id, contest and problem, got: %+v.", current)
+		}
+
+		if current.Verdict != submission.Accepted || current.Language != "GNU C11" || !current.SubmittedAT.Equal(found[i].CreatedAT) {
+			t.Errorf("Submission should keep verdict, language and time, got: %+v.", current)
+		}
+
+		if current.SyncedAT == nil || !current.SyncedAT.Equal(syncedAT) {
+			t.Errorf("Submission should keep the sync time, got: %v.", current.SyncedAT)
+		}
 	}
+}
 
-	if class1.Veredict != "AC" {
-		t.Errorf("Invalid Submission veredicit, got: %s, want: %s.", class1.Veredict, "AC")
+func TestSubmissionByStudent(t *testing.T) {
+
+	ana, bia := primitive.NewObjectID(), primitive.NewObjectID()
+
+	students := submission.Students([]enrollment.Enrollment{
+		{StudentID: ana, Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "Ana"}},
+		{StudentID: bia, Status: enrollment.Active, Handles: enrollment.Handles{Codeforces: "bia"}},
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Dropped, Handles: enrollment.Handles{Codeforces: "caio"}},
+		{StudentID: primitive.NewObjectID(), Status: enrollment.Active},
+	})
+
+	if len(students) != 2 || students["ana"] != ana {
+		t.Fatalf("Only active students with a handle should be read, got: %v.", students)
 	}
 
-	if class1.Time != "Jun/04/2019 03:51" {
-		t.Errorf("Invalid Submission time, got: %s, want: %s.", class1.Time, "Jun/04/2019 03:51")
+	found := []judge.Submission{
+		{ID: 1, Handle: "ANA"},
+		{ID: 2, Handle: "bia"},
+		{ID: 3, Handle: "caio"},
+		{ID: 4, Handle: "ana"},
 	}
 
+	split := submission.ByStudent(students, found)
+
+	if len(split) != 2 || len(split[ana]) != 2 || len(split[bia]) != 1 {
+		t.Errorf("Submissions should be split by the handle of the student, got: %v.", split)
+	}
 }
@@ -5,8 +5,7 @@ import (
 	"log"
 	"testing"
 
-	"github.com/components/submission"
-	"github.com/components/task"
+	"github.com/apc-unb/apc-api/web/components/task"
 )
 
 func TestTaskDB(t *testing.T) {
@@ -32,31 +31,27 @@ func TestTaskDB(t *testing.T) {
 
 	// Instantiate some tasks objects
 	task1 := task.TaskCreate{
-		Statement:   "Some 2 números inteiros",
-		Score:       2.5,
-		Tags:        []string{"String", "Matrix", "Array"},
-		Submissions: []submission.Submission{},
+		Statement: "Some 2 números inteiros",
+		Score:     2.5,
+		Tags:      []string{"String", "Matrix", "Array"},
 	}
 
 	task2 := task.TaskCreate{
-		Statement:   "Some 3 números inteiros",
-		Score:       4.5,
-		Tags:        []string{"Dp", "Array"},
-		Submissions: []submission.Submission{},
+		Statement: "Some 3 números inteiros",
+		Score:     4.5,
+		Tags:      []string{"Dp", "Array"},
 	}
 
 	task3 := task.TaskCreate{
-		Statement:   "Some 4 números inteiros",
-		Score:       5.5,
-		Tags:        []string{"Sement Tree", "Trie"},
-		Submissions: []submission.Submission{},
+		Statement: "Some 4 números inteiros",
+		Score:     5.5,
+		Tags:      []string{"Sement Tree", "Trie"},
 	}
 
 	task4 := task.TaskCreate{
-		Statement:   "Some",
-		Score:       1.5,
-		Tags:        []string{"Ad Hoc"},
-		Submissions: []submission.Submission{},
+		Statement: "Some",
+		Score:     1.5,
+		Tags:      []string{"Ad Hoc"},
 	}
 
 	///////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/apc-unb/apc-api/auth"
+	"github.com/apc-unb/apc-api/web/components/user"
+	"github.com/apc-unb/apc-api/web/utils"
+	"github.com/mongodb/mongo-go-driver/bson/primitive"
+)
+
+func TestUserGeneratePassword(t *testing.T) {
+
+	policy := user.PasswordPolicy{Length: 10, Charset: "ABCDEFGHJK23456789"}
+	seen := map[string]bool{}
+	chars := map[rune]bool{}
+
+	for i := 0; i < 500; i++ {
+
+		password, err := user.GeneratePassword(policy)
+
+		if err != nil {
+			t.Fatalf("Error generating password: %s", err.Error())
+		}
+
+		if len(password) != policy.Length {
+			t.Errorf("Invalid password length, got: %d, want: %d.", len(password), policy.Length)
+		}
+
+		for _, c := range password {
+			if !strings.ContainsRune(policy.Charset, c) {
+				t.Errorf("Password %s has character %c out of the charset", password, c)
+			}
+			chars[c] = true
+		}
+
+		seen[password] = true
+	}
+
+	if len(seen) < 500 {
+		t.Errorf("Generated passwords should not repeat, got %d distinct of 500", len(seen))
+	}
+
+	if len(chars) != len(policy.Charset) {
+		t.Errorf("Every character of the charset should be used, got: %d, want: %d.", len(chars), len(policy.Charset))
+	}
+
+	for _, invalid := range []user.PasswordPolicy{
+		{Length: 4, Charset: user.DefaultPasswordPolicy.Charset},
+		{Length: 8, Charset: "ABC"},
+		{Length: 8, Charset: "AABCDEFGHIJK"},
+	} {
+		if _, err := user.GeneratePassword(invalid); err == nil {
+			t.Errorf("Policy %v should be rejected", invalid)
+		}
+	}
+}
+
+func TestUserNewCredentials(t *testing.T) {
+
+	login, plain, err := user.NewCredentials(primitive.NewObjectID(), "160156666", user.DefaultPasswordPolicy)
+
+	if err != nil {
+		t.Fatalf("Error creating credentials: %s", err.Error())
+	}
+
+	if login.Password == plain.Password {
+		t.Errorf("Login record should store the hash, not the plain password")
+	}
+
+	if err := utils.ComparePasswords(login.Password, plain.Password); err != nil {
+		t.Errorf("Plain password should match the stored hash")
+	}
+
+	if !login.MustChangePassword || !plain.MustChangePassword {
+		t.Errorf("New credentials should require a password change")
+	}
+}
+
+func TestUserPasswordCheck(t *testing.T) {
+
+	policy := user.PasswordPolicy{Length: 8, Charset: user.DefaultPasswordPolicy.Charset}
+
+	for _, weak := range []string{"", "1", "a1b2c3", "abcdefgh", "12345678", "        "} {
+		if err := policy.Check(weak); err == nil {
+			t.Errorf("Password %q should be rejected", weak)
+		}
+	}
+
+	if err := policy.Check("apc-2019"); err != nil {
+		t.Errorf("Password long enough with letters and digits should be accepted: %s", err.Error())
+	}
+
+	// A policy shorter than the minimum still requires MinPasswordLength
+	if err := (user.PasswordPolicy{Length: 2}).Check("ab1"); err == nil {
+		t.Errorf("Password shorter than %d should be rejected", user.MinPasswordLength)
+	}
+}
+
+func TestAuthPasswordChanged(t *testing.T) {
+
+	request := httptest.NewRequest("GET", "/student", nil)
+	claims := auth.Claims{UserID: primitive.NewObjectID(), Role: auth.RoleStudent, MustChangePassword: true}
+
+	if err := auth.PasswordChanged()(&claims, request); err == nil {
+		t.Errorf("First login token should only change the password")
+	}
+
+	claims.MustChangePassword = false
+
+	if err := auth.PasswordChanged()(&claims, request); err != nil {
+		t.Errorf("Token should be accepted after the password change: %s", err.Error())
+	}
+}
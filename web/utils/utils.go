@@ -3,7 +3,9 @@ package utils
 import (
 	"encoding/json"
 	"github.com/sirupsen/logrus"
+	"net"
 	"net/http"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -42,3 +44,42 @@ func ComparePasswords(hashedPwd string, plainPwd string) error {
 
 	return nil
 }
+
+// ClientIP return the IP of who sent the request
+// Headers set by the reverse proxy are only read when trustProxy is true, otherwise anyone could fake them
+func ClientIP(r *http.Request, trustProxy bool) string {
+
+	if trustProxy {
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// FileName keeps only letters, digits, - and _ of name, so it is safe in a Content-Disposition header
+func FileName(name string) string {
+
+	var builder strings.Builder
+
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			builder.WriteRune(r)
+		case r == ' ':
+			builder.WriteRune('_')
+		}
+	}
+
+	return builder.String()
+}
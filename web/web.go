@@ -1,13 +1,30 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/apc-unb/apc-api/auth"
+	"github.com/apc-unb/apc-api/web/components/audit"
+	"github.com/apc-unb/apc-api/web/components/contest"
+	"github.com/apc-unb/apc-api/web/components/enrollment"
+	"github.com/apc-unb/apc-api/web/components/grade"
+	"github.com/apc-unb/apc-api/web/components/gradeHistory"
+	"github.com/apc-unb/apc-api/web/components/loginAttempt"
+	"github.com/apc-unb/apc-api/web/components/passwordReset"
+	"github.com/apc-unb/apc-api/web/components/progress"
+	"github.com/apc-unb/apc-api/web/components/session"
+	"github.com/apc-unb/apc-api/web/components/student"
+	"github.com/apc-unb/apc-api/web/components/submission"
 	"github.com/apc-unb/apc-api/web/config"
 	"github.com/apc-unb/apc-api/web/middleware"
 	"github.com/apc-unb/apc-api/web/prometheus"
@@ -33,11 +50,100 @@ func (s *Server) InitFromWebBuilder(webBuilder *config.WebBuilder) *Server {
 	return s
 }
 
+// reloadKeysOnSignal reads the JWT key directory again on SIGHUP
+// so a new signing key can be rotated in without restarting the server
+func (s *Server) reloadKeysOnSignal() {
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			if err := s.Keys.Reload(); err != nil {
+				logrus.Errorf("Not able to reload JWT keys: %s", err.Error())
+			} else {
+				logrus.Infof("JWT keys reloaded, signing with key %s", s.Keys.Signer().ID)
+			}
+		}
+	}()
+}
+
 // Creates and run the server
 func (s *Server) Run() error {
 
 	prometheus.RecordUpTime()
 
+	s.reloadKeysOnSignal()
+
+	if err := session.CreateIndexes(s.DataBase, "apc_database", "session", "revoked_token"); err != nil {
+		logrus.Errorf("Not able to create session indexes: %s", err.Error())
+	}
+
+	if err := passwordReset.CreateIndexes(s.DataBase, "apc_database", "password_reset"); err != nil {
+		logrus.Errorf("Not able to create password reset indexes: %s", err.Error())
+	}
+
+	if err := loginAttempt.CreateIndexes(s.DataBase, "apc_database", "login_attempt"); err != nil {
+		logrus.Errorf("Not able to create login attempt indexes: %s", err.Error())
+	}
+
+	if err := enrollment.CreateIndexes(s.DataBase, "apc_database", "enrollment"); err != nil {
+		logrus.Errorf("Not able to create enrollment indexes: %s", err.Error())
+	}
+
+	if err := gradeHistory.CreateIndexes(s.DataBase, "apc_database", "gradeHistory"); err != nil {
+		logrus.Errorf("Not able to create grade history indexes: %s", err.Error())
+	}
+
+	// Legacy students are enrolled and merged by matricula before a matricula can only be a single person
+	if created, err := student.MigrateEnrollments(s.DataBase, "apc_database", "student"); err != nil {
+		return errors.New("Not able to migrate students to enrollments: " + err.Error())
+	} else if created > 0 {
+		logrus.Infof("%d students enrolled in their class", created)
+	}
+
+	if merged, err := student.MergeDuplicates(s.DataBase, "apc_database", "student"); err != nil {
+		return errors.New("Not able to merge students with the same matricula: " + err.Error())
+	} else if merged > 0 {
+		logrus.Infof("%d students merged with another registration of the same matricula", merged)
+	}
+
+	if err := student.CreateIndexes(s.DataBase, "apc_database", "student"); err != nil {
+		return errors.New("Not able to create student indexes: " + err.Error())
+	}
+
+	if err := audit.CreateIndexes(s.DataBase, "apc_database", "audit"); err != nil {
+		logrus.Errorf("Not able to create audit indexes: %s", err.Error())
+	}
+
+	if err := progress.CreateIndexes(s.DataBase, "apc_database", "progress"); err != nil {
+		logrus.Errorf("Not able to create progress indexes: %s", err.Error())
+	}
+
+	if err := submission.CreateIndexes(s.DataBase, "apc_database", "submission"); err != nil {
+		logrus.Errorf("Not able to create submission indexes: %s", err.Error())
+	}
+
+	if err := contest.CreateIndexes(s.DataBase, "apc_database", "contest"); err != nil {
+		logrus.Errorf("Not able to create contest indexes: %s", err.Error())
+	}
+
+	if created, err := contest.MigrateClasses(s.DataBase, "apc_database", "contest"); err != nil {
+		logrus.Errorf("Not able to migrate the contests of the classes: %s", err.Error())
+	} else if created > 0 {
+		logrus.Infof("%d contests created from the contestsids of the classes", created)
+	}
+
+	// Progress is only read from the judges by the sync, logins read the stored snapshots
+	if s.CodeforcesSync > 0 {
+		go progress.Run(context.Background(), s.DataBase, s.Judges, s.CodeforcesSync, "apc_database")
+		go grade.RunLists(context.Background(), s.DataBase, s.CodeforcesSync, "apc_database")
+	} else {
+		logrus.Infof("Codeforces sync disabled, run the sync command to update the progress and the list grades of the students")
+	}
+
+	auditStore := audit.Store{DataBase: s.DataBase, DatabaseName: "apc_database", CollectionName: "audit"}
+
 	router := mux.NewRouter()
 	router.Use(middleware.GetPrometheusMiddleware())
 	router.Use(middleware.GetCorsMiddleware())
@@ -50,75 +156,152 @@ func (s *Server) Run() error {
 
 	router.HandleFunc("/student/login", s.studentLogin).Methods("POST", "OPTIONS")
 	router.HandleFunc("/admin/login", s.adminLogin).Methods("POST", "OPTIONS")
+	router.HandleFunc("/refresh", s.refreshToken).Methods("POST", "OPTIONS")
+	router.HandleFunc("/student/password/forgot", s.forgotStudentPassword).Methods("POST", "OPTIONS")
+	router.HandleFunc("/admin/password/forgot", s.forgotAdminPassword).Methods("POST", "OPTIONS")
+	router.HandleFunc("/password/reset", s.resetPassword).Methods("POST", "OPTIONS")
+	router.HandleFunc("/.well-known/jwks.json", s.getJWKS).Methods("GET", "OPTIONS")
+	router.HandleFunc("/health", s.health).Methods("GET", "OPTIONS")
 	router.HandleFunc("/data", s.insertData).Methods("GET")
 	router.Handle("/metrics", promhttp.Handler())
 
 
+	//////////////////////
+	// PASSWORD ROUTERS //
+	//////////////////////
+
+	// First login tokens can only reach these routes
+	passwordRouter := router.NewRoute().Subrouter()
+	passwordRouter.Use(middleware.SetMiddlewareAuthorization(s.Keys, s.isTokenRevoked,
+		auth.AllowRoles(auth.RoleStudent, auth.RoleMonitor, auth.RoleProfessor),
+	))
+	passwordRouter.Use(middleware.SetMiddlewareJSON())
+
+	passwordRouter.HandleFunc("/password", s.changePassword).Methods("PUT", "OPTIONS")
+
 	////////////////////
 	// SECURE ROUTERS //
 	////////////////////
 
 	secureRouter := router.NewRoute().Subrouter()
-	secureRouter.Use(middleware.SetMiddlewareAuthentication(s.JwtSecret))
+	secureRouter.Use(middleware.SetMiddlewareAuthorization(s.Keys, s.isTokenRevoked,
+		auth.AllowRoles(auth.RoleStudent, auth.RoleMonitor, auth.RoleProfessor),
+		middleware.OwnRouteVars(),
+		auth.PasswordChanged(),
+	))
 	secureRouter.Use(middleware.SetMiddlewareJSON())
+	secureRouter.Use(middleware.SetMiddlewareAudit(auditStore, auditRoutes))
+
+	secureRouter.HandleFunc("/logout", s.logout).Methods("POST", "OPTIONS")
+	secureRouter.HandleFunc("/logout/all", s.logoutAll).Methods("POST", "OPTIONS")
 
-	secureRouter.HandleFunc("/student", s.getStudents).Methods("GET", "OPTIONS")
 	secureRouter.HandleFunc("/student/{classid}", s.getStudentsClass).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/student", s.createStudents).Methods("POST", "OPTIONS")
 	secureRouter.HandleFunc("/student", s.updateStudents).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/student/file", s.createStudentsFile).Methods("POST", "OPTIONS")
 	secureRouter.HandleFunc("/student/contest/{studentid}", s.getStudentIndividualProgress).Methods("GET", "OPTIONS")
 
-	secureRouter.HandleFunc("/admin", s.getAdmins).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/admin", s.updateAdmins).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/admin/file", s.createAdminsFile).Methods("POST", "OPTIONS")
-	secureRouter.HandleFunc("/admin/student", s.updateAdminStudent).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/admin/{classid}", s.getAdminsClass).Methods("GET", "OPTIONS")
+	secureRouter.HandleFunc("/enrollment/{studentid}", s.getStudentEnrollments).Methods("GET", "OPTIONS")
 
 	secureRouter.HandleFunc("/class", s.getClasses).Methods("GET", "OPTIONS")
 	secureRouter.HandleFunc("/class/{professorid}", s.getClassProfessor).Methods("GET", "OPTIONS")
 
-	secureRouter.HandleFunc("/submission", s.getSubmissions).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/submission", s.createSubmissions).Methods("POST", "OPTIONS")
-	secureRouter.HandleFunc("/submission", s.updateSubmissions).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/submission", s.deleteSubmissions).Methods("DELETE", "OPTIONS")
+	secureRouter.HandleFunc("/contest/{classid}", s.getClassContests).Methods("GET", "OPTIONS")
 
 	secureRouter.HandleFunc("/task", s.getTasks).Methods("GET", "OPTIONS")
 	secureRouter.HandleFunc("/task/{examid}", s.getTasksExam).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/task", s.createTasks).Methods("POST", "OPTIONS")
-	secureRouter.HandleFunc("/task", s.updateTasks).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/task", s.deleteTasks).Methods("DELETE", "OPTIONS")
 
 	secureRouter.HandleFunc("/exam", s.getExams).Methods("GET", "OPTIONS")
 	secureRouter.HandleFunc("/exam/{classid}", s.getExamsClass).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/exam", s.createExams).Methods("POST", "OPTIONS")
-	secureRouter.HandleFunc("/exam", s.updateExams).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/exam", s.deleteExams).Methods("DELETE", "OPTIONS")
 
 	secureRouter.HandleFunc("/news", s.getNews).Methods("GET", "OPTIONS")
 	secureRouter.HandleFunc("/news/{classid}", s.getNewsClass).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/news", s.createNews).Methods("POST", "OPTIONS")
-	secureRouter.HandleFunc("/news", s.updateNews).Methods("PUT", "OPTIONS")
-	secureRouter.HandleFunc("/news", s.deleteNews).Methods("DELETE", "OPTIONS")
 
 	secureRouter.HandleFunc("/project", s.createProject).Methods("POST", "OPTIONS")
 	secureRouter.HandleFunc("/project", s.updateProject).Methods("PUT", "OPTIONS")
 	secureRouter.HandleFunc("/project/type", s.getProjectType).Methods("GET", "OPTIONS")
-	secureRouter.HandleFunc("/project/status", s.updateStatusProject).Methods("PUT", "OPTIONS")
 	secureRouter.HandleFunc("/project/{studentid}", s.getProjectStudent).Methods("GET", "OPTIONS")
 
-	////////////////////
-	// SECURE ROUTERS //
-	////////////////////
+	///////////////////
+	// ADMIN ROUTERS //
+	///////////////////
+
+	adminRouter := router.NewRoute().Subrouter()
+	adminRouter.Use(middleware.SetMiddlewareAuthorization(s.Keys, s.isTokenRevoked,
+		auth.AllowRoles(auth.RoleMonitor, auth.RoleProfessor),
+		middleware.OwnRouteVars(),
+		auth.PasswordChanged(),
+	))
+	adminRouter.Use(middleware.SetMiddlewareJSON())
+	adminRouter.Use(middleware.SetMiddlewareAudit(auditStore, auditRoutes))
+
+	adminRouter.HandleFunc("/student", s.createStudents).Methods("POST", "OPTIONS")
+
+	adminRouter.HandleFunc("/enrollment", s.updateEnrollmentStatus).Methods("PUT", "OPTIONS")
+
+	adminRouter.HandleFunc("/grade/{classid}", s.getClassGrades).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/grade/{classid}", s.computeClassGrades).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/grade/{classid}/preview", s.previewClassGrades).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/grade/{classid}/lists", s.computeListGrades).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/grade/{classid}/history/{studentid}", s.getGradeHistory).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/grade/{classid}/history/revert", s.revertGradeChange).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/class/{classid}/gradebook", s.getGradebook).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/class/{classid}/progress", s.getClassProgress).Methods("GET", "OPTIONS")
+
+	adminRouter.HandleFunc("/admin", s.getAdmins).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/admin", s.updateAdmins).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/admin/student", s.updateAdminStudent).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/admin/{classid}", s.getAdminsClass).Methods("GET", "OPTIONS")
+
+	adminRouter.HandleFunc("/contest", s.createContests).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/contest", s.updateContests).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/contest", s.deleteContests).Methods("DELETE", "OPTIONS")
+
+	adminRouter.HandleFunc("/submission", s.createSubmissions).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/submission", s.updateSubmissions).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/submission", s.deleteSubmissions).Methods("DELETE", "OPTIONS")
+	adminRouter.HandleFunc("/submission/class/{classid}", s.getClassSubmissions).Methods("GET", "OPTIONS")
+
+	adminRouter.HandleFunc("/task", s.createTasks).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/task", s.updateTasks).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/task", s.deleteTasks).Methods("DELETE", "OPTIONS")
+
+	adminRouter.HandleFunc("/exam", s.createExams).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/exam", s.updateExams).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/exam", s.deleteExams).Methods("DELETE", "OPTIONS")
+
+	adminRouter.HandleFunc("/news", s.createNews).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/news", s.updateNews).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/news", s.deleteNews).Methods("DELETE", "OPTIONS")
+
+	adminRouter.HandleFunc("/project/status", s.updateStatusProject).Methods("PUT", "OPTIONS")
+
+	///////////////////////
+	// PROFESSOR ROUTERS //
+	///////////////////////
 
 	professorRouter := router.NewRoute().Subrouter()
-	professorRouter.Use(middleware.SetMiddlewareAuthenticationProfessor(s.JwtSecret))
+	professorRouter.Use(middleware.SetMiddlewareAuthorization(s.Keys, s.isTokenRevoked,
+		auth.AllowRoles(auth.RoleProfessor),
+		auth.PasswordChanged(),
+	))
 	professorRouter.Use(middleware.SetMiddlewareJSON())
+	professorRouter.Use(middleware.SetMiddlewareAudit(auditStore, auditRoutes))
 
 	professorRouter.HandleFunc("/admin", s.createAdmins).Methods("POST", "OPTIONS")
 	professorRouter.HandleFunc("/admin", s.deleteAdmin).Methods("DELETE", "OPTIONS")
+	professorRouter.HandleFunc("/admin/file", s.createAdminsFile).Methods("POST", "OPTIONS")
+	professorRouter.HandleFunc("/admin/unlock", s.unlockLogin).Methods("POST", "OPTIONS")
+
+	professorRouter.HandleFunc("/credential/delivery/{batchid}", s.getCredentialDeliveries).Methods("GET", "OPTIONS")
 
+	professorRouter.HandleFunc("/student", s.getStudents).Methods("GET", "OPTIONS")
 	professorRouter.HandleFunc("/student", s.deleteStudents).Methods("DELETE", "OPTIONS")
+	professorRouter.HandleFunc("/student/file", s.createStudentsFile).Methods("POST", "OPTIONS")
+
+	professorRouter.HandleFunc("/audit", s.getAuditLog).Methods("GET", "OPTIONS")
+
+	professorRouter.HandleFunc("/submission", s.getSubmissions).Methods("GET", "OPTIONS")
+
+	professorRouter.HandleFunc("/grade/{classid}/import", s.importClassGrades).Methods("POST", "OPTIONS")
 
 	professorRouter.HandleFunc("/class", s.createClasses).Methods("POST", "OPTIONS")
 	professorRouter.HandleFunc("/class", s.updateClass).Methods("PUT", "OPTIONS")
@@ -0,0 +1,143 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+// MaxSheetName is the longest sheet name a spreadsheet application accepts
+const MaxSheetName = 31
+
+const contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const rootRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRelationships = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+// Write return an XLSX file with a single sheet
+// Cells may be strings or numbers (float64 or int), anything else is left empty
+// @param	sheetName		name of the sheet, cut to MaxSheetName characters
+// @param	rows			cells of each row
+// @return 	[]byte			the XLSX file
+// @return 	error 			function error
+func Write(sheetName string, rows [][]interface{}) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	archive := zip.NewWriter(&buffer)
+
+	if name := []rune(sheetName); len(name) > MaxSheetName {
+		sheetName = string(name[:MaxSheetName])
+	}
+
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(contentTypes)},
+		{"_rels/.rels", []byte(rootRelationships)},
+		{"xl/workbook.xml", workbook(sheetName)},
+		{"xl/_rels/workbook.xml.rels", []byte(workbookRelationships)},
+		{"xl/worksheets/sheet1.xml", worksheet(rows)},
+	}
+
+	for _, file := range files {
+
+		writer, err := archive.Create(file.name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = writer.Write(file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// ColumnName return the letters of a column, 0 is A and 26 is AA
+func ColumnName(index int) string {
+
+	name := ""
+
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+
+	return name
+}
+
+func workbook(sheetName string) []byte {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="`)
+	xml.EscapeText(&buffer, []byte(sheetName))
+	buffer.WriteString(`" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`)
+
+	return buffer.Bytes()
+}
+
+func worksheet(rows [][]interface{}) []byte {
+
+	var buffer bytes.Buffer
+
+	buffer.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	for i, row := range rows {
+
+		line := strconv.Itoa(i + 1)
+
+		buffer.WriteString(`<row r="` + line + `">`)
+
+		for j, cell := range row {
+
+			reference := ColumnName(j) + line
+
+			switch value := cell.(type) {
+			case string:
+				buffer.WriteString(`<c r="` + reference + `" t="inlineStr"><is><t xml:space="preserve">`)
+				xml.EscapeText(&buffer, []byte(value))
+				buffer.WriteString(`</t></is></c>`)
+			case float64:
+				buffer.WriteString(`<c r="` + reference + `"><v>` + strconv.FormatFloat(value, 'f', -1, 64) + `</v></c>`)
+			case int:
+				buffer.WriteString(`<c r="` + reference + `"><v>` + strconv.Itoa(value) + `</v></c>`)
+			}
+		}
+
+		buffer.WriteString(`</row>`)
+	}
+
+	buffer.WriteString(`</sheetData></worksheet>`)
+
+	return buffer.Bytes()
+}